@@ -0,0 +1,23 @@
+package exex
+
+import "regexp"
+
+// ansiRe matches the ANSI escape sequences: control sequences, like
+// colors and cursor movements, operating system commands, like window
+// titles and hyperlinks, and other two-byte escapes.
+var ansiRe = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[()][0-9A-Za-z]|\x1b[0-?@-Z\\-_]`)
+
+// WithStripANSI removes the ANSI escape sequences, like colors and
+// cursor movements, from the output captured by the command: the
+// standard error reported in CmdError.Stderr and ExitError.Stderr,
+// and the output returned by Output and CombinedOutput. The output
+// given to other writers, like Stdout or the ones set with TeeStderr,
+// is not modified.
+func WithStripANSI() Option {
+	return func(c *Cmd) { c.stripANSI = true }
+}
+
+// StripANSI returns b without its ANSI escape sequences.
+func StripANSI(b []byte) []byte {
+	return ansiRe.ReplaceAll(b, nil)
+}
@@ -0,0 +1,67 @@
+package exex_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestStripANSI(t *testing.T) {
+	tests := map[string]struct {
+		in, exp string
+	}{
+		"plain":     {"hello\n", "hello\n"},
+		"color":     {"\x1b[1;31merror\x1b[0m: failed", "error: failed"},
+		"cursor":    {"50%\x1b[2K\r\x1b[1A100%", "50%\r100%"},
+		"title":     {"\x1b]0;title\x07done", "done"},
+		"hyperlink": {"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", "link"},
+		"charset":   {"\x1b(Bbox", "box"},
+		"keypad":    {"\x1b=on\x1b>", "on"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := string(exex.StripANSI([]byte(tt.in))); got != tt.exp {
+				t.Fatalf("expecting %q, got %q", tt.exp, got)
+			}
+		})
+	}
+}
+
+func TestWithStripANSI(t *testing.T) {
+	const script = `printf '\033[32mok\033[0m\n'; printf '\033[31mfailed\033[0m\n' >&2; exit 1`
+
+	t.Run("output", func(t *testing.T) {
+		out, err := exex.Command("/bin/sh", "-c", script).Apply(exex.WithStripANSI()).Output()
+		if string(out) != "ok\n" {
+			t.Fatalf("expecting %q, got %q", "ok\n", out)
+		}
+
+		var cmdErr *exex.CmdError
+		if !errors.As(err, &cmdErr) {
+			t.Fatalf("expecting *CmdError, got %v", err)
+		}
+		if string(cmdErr.Stderr) != "failed\n" {
+			t.Fatalf("expecting %q, got %q", "failed\n", cmdErr.Stderr)
+		}
+		var exitErr *exex.ExitError
+		if !errors.As(err, &exitErr) || string(exitErr.Stderr) != "failed\n" {
+			t.Fatalf("expecting ExitError.Stderr to be stripped, got %v", err)
+		}
+	})
+
+	t.Run("combined", func(t *testing.T) {
+		out, _ := exex.Command("/bin/sh", "-c", script).Apply(exex.WithStripANSI()).CombinedOutput()
+		if string(out) != "ok\nfailed\n" {
+			t.Fatalf("expecting %q, got %q", "ok\nfailed\n", out)
+		}
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		out, _ := exex.Command("/bin/sh", "-c", script).Output()
+		if string(out) != "\x1b[32mok\x1b[0m\n" {
+			t.Fatalf("expecting output unmodified, got %q", out)
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package exex
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OutputFS runs the command, which must write an archive to its
+// standard output, and returns the contents of the archive as an
+// fs.FS, e.g. for the output of git archive or docker save. The
+// archive is kept in memory and never written to disk.
+//
+// The format is detected from the output, which can be a zip archive,
+// or a tar archive optionally compressed with gzip. Only regular files
+// and directories are exposed; other entries of tar archives, such as
+// symbolic links, are ignored.
+//
+// If the command fails its error is returned the same way as Output
+// does, and no parsing is attempted.
+func (c *Cmd) OutputFS() (fs.FS, error) {
+	out, err := c.Output()
+	if err != nil {
+		return nil, err
+	}
+	return archiveFS(out)
+}
+
+func archiveFS(b []byte) (fs.FS, error) {
+	switch {
+	case bytes.HasPrefix(b, []byte("PK\x03\x04")), bytes.HasPrefix(b, []byte("PK\x05\x06")):
+		return zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	case bytes.HasPrefix(b, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		return tarFS(zr)
+	default:
+		return tarFS(bytes.NewReader(b))
+	}
+}
+
+// tarFS reads the tar archive from r into an in-memory fs.FS.
+func tarFS(r io.Reader) (fs.FS, error) {
+	m := memFS{".": &memFile{name: ".", mode: fs.ModeDir | 0o755}}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "/"))
+		if !fs.ValidPath(name) {
+			return nil, fmt.Errorf("exex: invalid path %q in archive", hdr.Name)
+		}
+
+		var f *memFile
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			f = &memFile{mode: fs.ModeDir | fs.FileMode(hdr.Mode).Perm()}
+		case tar.TypeReg:
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			f = &memFile{mode: fs.FileMode(hdr.Mode).Perm(), data: data}
+		default:
+			continue
+		}
+		f.name = path.Base(name)
+		f.modTime = hdr.ModTime
+
+		m.add(name, f)
+	}
+
+	return m, nil
+}
+
+// memFS is a read-only in-memory fs.FS.
+type memFS map[string]*memFile
+
+type memFile struct {
+	name    string
+	mode    fs.FileMode
+	modTime time.Time
+	data    []byte
+}
+
+// add adds f as name, creating its parent directories if missing.
+func (m memFS) add(name string, f *memFile) {
+	if prev, ok := m[name]; ok && prev.mode.IsDir() && f.mode.IsDir() {
+		prev.mode, prev.modTime = f.mode, f.modTime
+		return
+	}
+	m[name] = f
+
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		if _, ok := m[dir]; ok {
+			break
+		}
+		m[dir] = &memFile{name: path.Base(dir), mode: fs.ModeDir | 0o755}
+	}
+}
+
+func (m memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	f, ok := m[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if !f.mode.IsDir() {
+		return &openFile{memFile: f, r: bytes.NewReader(f.data)}, nil
+	}
+
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+
+	var entries []fs.DirEntry
+	for p, e := range m {
+		if p != "." && strings.HasPrefix(p, prefix) && !strings.Contains(p[len(prefix):], "/") {
+			entries = append(entries, fs.FileInfoToDirEntry(e))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	return &openDir{memFile: f, path: name, entries: entries}, nil
+}
+
+// memFile implements fs.FileInfo.
+
+func (f *memFile) Name() string       { return f.name }
+func (f *memFile) Size() int64        { return int64(len(f.data)) }
+func (f *memFile) Mode() fs.FileMode  { return f.mode }
+func (f *memFile) ModTime() time.Time { return f.modTime }
+func (f *memFile) IsDir() bool        { return f.mode.IsDir() }
+func (f *memFile) Sys() any           { return nil }
+
+type openFile struct {
+	*memFile
+	r *bytes.Reader
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) { return f.memFile, nil }
+func (f *openFile) Read(b []byte) (int, error) { return f.r.Read(b) }
+func (f *openFile) Close() error               { return nil }
+
+// Seek and ReadAt allow using the file with http.FileServer and
+// io.SectionReader.
+func (f *openFile) Seek(offset int64, whence int) (int64, error) { return f.r.Seek(offset, whence) }
+func (f *openFile) ReadAt(b []byte, off int64) (int, error)      { return f.r.ReadAt(b, off) }
+
+type openDir struct {
+	*memFile
+	path    string
+	entries []fs.DirEntry
+}
+
+func (d *openDir) Stat() (fs.FileInfo, error) { return d.memFile, nil }
+func (d *openDir) Close() error               { return nil }
+
+func (d *openDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: errors.New("is a directory")}
+}
+
+func (d *openDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,125 @@
+package exex_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/inkel/exex"
+)
+
+var archiveFiles = []struct{ name, body string }{
+	{"README", "readme"},
+	{"dir/a.txt", "a"},
+	{"dir/sub/b.txt", "b"},
+}
+
+func tarArchive(t *testing.T) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0o755})
+	for _, f := range archiveFiles {
+		tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0o644, Size: int64(len(f.body))})
+		tw.Write([]byte(f.body))
+	}
+	tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "README"})
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestOutputFS(t *testing.T) {
+	tgz := func(t *testing.T) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(tarArchive(t))
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	zipArchive := func(t *testing.T) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, f := range archiveFiles {
+			w, _ := zw.Create(f.name)
+			w.Write([]byte(f.body))
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	tests := map[string]func(*testing.T) []byte{
+		"tar":    tarArchive,
+		"tar.gz": tgz,
+		"zip":    zipArchive,
+	}
+
+	for name, archive := range tests {
+		t.Run(name, func(t *testing.T) {
+			fsys, err := exex.Command(os.Args[0]).Apply(
+				exex.WithEnv("TEST_MAIN=cat"),
+				exex.WithStdin(bytes.NewReader(archive(t))),
+			).OutputFS()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, f := range archiveFiles {
+				names = append(names, f.name)
+
+				b, err := fs.ReadFile(fsys, f.name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(b) != f.body {
+					t.Errorf("%s: expecting %q, got %q", f.name, f.body, b)
+				}
+			}
+
+			if err := fstest.TestFS(fsys, names...); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestOutputFSInvalid(t *testing.T) {
+	t.Run("failed", func(t *testing.T) {
+		_, err := exex.Command(os.Args[0], "archive").OutputFS()
+		assertErr(t, err, "error: archive")
+	})
+
+	t.Run("path", func(t *testing.T) {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		tw.WriteHeader(&tar.Header{Name: "../escape", Mode: 0o644})
+		tw.Close()
+
+		_, err := exex.Command(os.Args[0]).Apply(
+			exex.WithEnv("TEST_MAIN=cat"),
+			exex.WithStdin(&buf),
+		).OutputFS()
+		if err == nil {
+			t.Fatal("expecting an error")
+		}
+	})
+
+	t.Run("garbage", func(t *testing.T) {
+		_, err := exex.Command(os.Args[0]).Apply(
+			exex.WithEnv("TEST_MAIN=cat"),
+			exex.WithStdin(bytes.NewReader(bytes.Repeat([]byte("x"), 1024))),
+		).OutputFS()
+		if err == nil {
+			t.Fatal("expecting an error")
+		}
+	})
+}
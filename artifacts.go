@@ -0,0 +1,96 @@
+package exex
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WithArtifacts collects, once the command finished, regardless of
+// whether it succeeded, the files in its working directory matching
+// pattern, and reports them in Result.Artifacts.
+//
+// pattern uses the syntax of filepath.Match, is relative to the
+// working directory of the command, and directories matching it are
+// collected recursively. If destDir is not empty, the files are
+// copied into it preserving their path relative to the working
+// directory, and Result.ArtifactsFS is rooted at destDir; otherwise
+// the files are left in place and Result.ArtifactsFS is rooted at the
+// working directory.
+//
+// Errors while collecting the artifacts are returned by Wait if the
+// command succeeded. Calling WithArtifacts again replaces the previous
+// pattern and destination.
+func WithArtifacts(pattern, destDir string) Option {
+	return func(c *Cmd) {
+		c.artifacts = &artifacts{pattern: pattern, destDir: destDir}
+	}
+}
+
+type artifacts struct {
+	pattern string
+	destDir string
+}
+
+// collect gathers the artifacts produced in dir and sets them in r.
+func (a *artifacts) collect(dir string, r *Result) error {
+	names, err := globFiles(dir, a.pattern)
+	if err != nil {
+		return fmt.Errorf("exex: artifacts: %w", err)
+	}
+
+	root := dir
+	if root == "" {
+		root = "."
+	}
+
+	if a.destDir != "" {
+		for _, name := range names {
+			err := copyFile(filepath.Join(a.destDir, name), filepath.Join(root, name))
+			if err != nil {
+				return fmt.Errorf("exex: artifacts: %w", err)
+			}
+		}
+		root = a.destDir
+	}
+
+	r.Artifacts = make([]string, len(names))
+	for i, name := range names {
+		r.Artifacts[i] = filepath.ToSlash(name)
+	}
+	r.ArtifactsFS = os.DirFS(root)
+
+	return nil
+}
+
+// copyFile copies the regular file src to dst, creating the parent
+// directories of the latter if needed.
+func copyFile(dst, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
@@ -0,0 +1,80 @@
+package exex_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(t.TempDir(), "artifacts")
+	os.Mkdir(filepath.Join(dir, "reports"), 0o755)
+	os.Mkdir(filepath.Join(dir, "reports", "sub"), 0o755)
+
+	cmd := exex.Command(os.Args[0],
+		"reports/a.xml=a",
+		"reports/sub/b.xml=b",
+		"other.txt=other",
+	).Apply(
+		exex.WithDir(dir),
+		exex.WithEnv("TEST_MAIN=files"),
+		exex.WithArtifacts("report*", dest),
+	)
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := cmd.Result()
+	exp := []string{"reports/a.xml", "reports/sub/b.xml"}
+	got := append([]string(nil), r.Artifacts...)
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expecting %q, got %q", exp, got)
+	}
+
+	for _, name := range exp {
+		b, err := fs.ReadFile(r.ArtifactsFS, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := name[len(name)-5 : len(name)-4]; string(b) != want {
+			t.Errorf("%s: expecting %q, got %q", name, want, b)
+		}
+
+		if _, err := os.Stat(filepath.Join(dest, filepath.FromSlash(name))); err != nil {
+			t.Errorf("expecting artifact to be copied: %v", err)
+		}
+	}
+}
+
+func TestWithArtifactsInPlace(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "report.txt"), []byte("report"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Artifacts are collected even if the command fails.
+	cmd := exex.Command(os.Args[0], "failed").Apply(
+		exex.WithDir(dir),
+		exex.WithArtifacts("*.txt", ""),
+	)
+	assertErr(t, cmd.Run(), "error: failed")
+
+	r := cmd.Result()
+	if len(r.Artifacts) != 1 || r.Artifacts[0] != "report.txt" {
+		t.Fatalf("unexpected artifacts %q", r.Artifacts)
+	}
+	b, err := fs.ReadFile(r.ArtifactsFS, "report.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "report" {
+		t.Fatalf("unexpected contents %q", b)
+	}
+}
@@ -0,0 +1,187 @@
+package exex
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Backpressure is the policy applied by the streaming APIs,
+// StreamStdout, StreamStderr and Lines, when the consumer of the
+// lines is slower than the command writing them.
+type Backpressure int
+
+const (
+	// BackpressureBlock blocks the command writing its output until
+	// the consumer takes each line.
+	BackpressureBlock Backpressure = iota
+
+	// BackpressureDrop buffers up to BackpressureLines lines per
+	// stream in memory and drops the lines written while the buffer
+	// is full. The number of dropped lines is reported in
+	// Result.DroppedLines.
+	BackpressureDrop
+
+	// BackpressureSpool buffers the lines that the consumer has not
+	// taken in a temporary file, so neither the command blocks nor
+	// the lines are kept in memory.
+	BackpressureSpool
+)
+
+// BackpressureLines is the number of lines per stream buffered in
+// memory with BackpressureDrop.
+var BackpressureLines = 1024
+
+// WithBackpressure sets the policy applied by the streaming APIs when
+// the consumer is slower than the command. It defaults to
+// BackpressureBlock.
+//
+// With policies other than BackpressureBlock the lines are consumed
+// concurrently with the command, and the streaming APIs return once
+// all the buffered lines were consumed.
+func WithBackpressure(b Backpressure) Option {
+	return func(c *Cmd) { c.backpressure = b }
+}
+
+// pace returns a function delivering lines to fn according to the
+// backpressure policy of the command, and a function to call once no
+// more lines are delivered, which waits until fn was called with all
+// the buffered lines.
+func (c *Cmd) pace(fn func(line string)) (func(line string), func(), error) {
+	switch c.backpressure {
+	case BackpressureDrop:
+		lines := make(chan string, BackpressureLines)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for l := range lines {
+				fn(l)
+			}
+		}()
+
+		deliver := func(line string) {
+			select {
+			case lines <- line:
+			default:
+				c.dropped.Add(1)
+			}
+		}
+		finish := func() {
+			close(lines)
+			<-done
+			if c.result != nil {
+				c.result.DroppedLines = int(c.dropped.Load())
+			}
+		}
+		return deliver, finish, nil
+
+	case BackpressureSpool:
+		s, err := newSpool()
+		if err != nil {
+			return nil, nil, fmt.Errorf("exex: backpressure: %w", err)
+		}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			s.consume(fn)
+		}()
+
+		finish := func() {
+			s.close()
+			<-done
+			s.remove()
+		}
+		return s.push, finish, nil
+	}
+
+	return fn, func() {}, nil
+}
+
+// spool is a queue of lines backed by a temporary file.
+type spool struct {
+	mu      sync.Mutex
+	cond    sync.Cond
+	w       *os.File
+	r       *os.File
+	pending int
+	closed  bool
+	err     error
+}
+
+func newSpool() (*spool, error) {
+	w, err := os.CreateTemp("", "exex-spool-*")
+	if err != nil {
+		return nil, err
+	}
+	r, err := os.Open(w.Name())
+	if err != nil {
+		w.Close()
+		os.Remove(w.Name())
+		return nil, err
+	}
+
+	s := &spool{w: w, r: r}
+	s.cond.L = &s.mu
+	return s, nil
+}
+
+// push appends line to the spool, prefixed with its length, as lines
+// can contain newlines when using WithSplit. Lines written after
+// failing to write to the spool are dropped.
+func (s *spool) push(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return
+	}
+	b := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+len(line)), uint64(len(line)))
+	if _, s.err = s.w.Write(append(b, line...)); s.err == nil {
+		s.pending++
+		s.cond.Signal()
+	}
+}
+
+// consume calls fn with each line pushed to the spool until it is
+// closed and all its lines were consumed.
+func (s *spool) consume(fn func(line string)) {
+	r := bufio.NewReader(s.r)
+	for {
+		s.mu.Lock()
+		for s.pending == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if s.pending == 0 {
+			s.mu.Unlock()
+			return
+		}
+		s.pending--
+		s.mu.Unlock()
+
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return
+		}
+		line := make([]byte, n)
+		if _, err := io.ReadFull(r, line); err != nil {
+			return
+		}
+		fn(string(line))
+	}
+}
+
+func (s *spool) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.cond.Signal()
+	s.mu.Unlock()
+}
+
+func (s *spool) remove() {
+	s.r.Close()
+	s.w.Close()
+	os.Remove(s.w.Name())
+}
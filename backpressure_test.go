@@ -0,0 +1,94 @@
+package exex_test
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithBackpressure(t *testing.T) {
+	out := strings.Repeat("line\n", 100)
+
+	t.Run("drop", func(t *testing.T) {
+		defer func(n int) { exex.BackpressureLines = n }(exex.BackpressureLines)
+		exex.BackpressureLines = 10
+
+		cmd := exex.Command(os.Args[0], strconv.Quote(out)).Apply(
+			exex.WithEnv("TEST_MAIN=print"),
+			exex.WithBackpressure(exex.BackpressureDrop),
+		)
+
+		var n int
+		err := cmd.StreamStdout(func(string) {
+			time.Sleep(time.Millisecond)
+			n++
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dropped := cmd.Result().DroppedLines
+		if dropped == 0 {
+			t.Error("expecting dropped lines")
+		}
+		if n+dropped != 100 {
+			t.Errorf("expecting 100 lines consumed or dropped, got %d and %d", n, dropped)
+		}
+	})
+
+	t.Run("spool", func(t *testing.T) {
+		cmd := exex.Command(os.Args[0], strconv.Quote(out+"last")).Apply(
+			exex.WithEnv("TEST_MAIN=print"),
+			exex.WithBackpressure(exex.BackpressureSpool),
+		)
+
+		var lines []string
+		err := cmd.StreamStdout(func(line string) {
+			time.Sleep(100 * time.Microsecond)
+			lines = append(lines, line)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(lines) != 101 || lines[0] != "line" || lines[100] != "last" {
+			t.Fatalf("unexpected lines %q", lines)
+		}
+		if d := cmd.Result().DroppedLines; d != 0 {
+			t.Errorf("expecting no dropped lines, got %d", d)
+		}
+	})
+	t.Run("spool split", func(t *testing.T) {
+		// Tokens separated by NUL bytes, containing newlines.
+		splitNUL := func(data []byte, atEOF bool) (int, []byte, error) {
+			if i := bytes.IndexByte(data, 0); i >= 0 {
+				return i + 1, data[:i], nil
+			}
+			if atEOF && len(data) > 0 {
+				return len(data), data, nil
+			}
+			return 0, nil, nil
+		}
+
+		cmd := exex.Command(os.Args[0], strconv.Quote("a\nb\x00c\x00\nd\n\x00")).Apply(
+			exex.WithEnv("TEST_MAIN=print"),
+			exex.WithSplit(splitNUL),
+			exex.WithBackpressure(exex.BackpressureSpool),
+		)
+
+		var tokens []string
+		if err := cmd.StreamStdout(func(tok string) { tokens = append(tokens, tok) }); err != nil {
+			t.Fatal(err)
+		}
+
+		if exp := []string{"a\nb", "c", "\nd\n"}; !slices.Equal(tokens, exp) {
+			t.Fatalf("expecting %q, got %q", exp, tokens)
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package exex
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BenchOption configures Bench.
+type BenchOption func(*benchConfig)
+
+type benchConfig struct {
+	warmup   int
+	parallel int
+}
+
+// BenchWarmup runs the command n additional times before the measured
+// runs, discarding their results.
+func BenchWarmup(n int) BenchOption {
+	return func(b *benchConfig) { b.warmup = n }
+}
+
+// BenchParallel runs up to n commands concurrently. Defaults to 1.
+func BenchParallel(n int) BenchOption {
+	return func(b *benchConfig) { b.parallel = n }
+}
+
+// BenchRun holds the measurements of a single run of Bench.
+type BenchRun struct {
+	// Duration is the wall time of the run.
+	Duration time.Duration
+
+	// UserTime and SystemTime are the CPU time consumed by the
+	// command, if reported by its Runner.
+	UserTime   time.Duration
+	SystemTime time.Duration
+}
+
+// BenchStats summarizes a set of durations. Percentiles are computed
+// using the nearest-rank method.
+type BenchStats struct {
+	Min    time.Duration
+	Median time.Duration
+	P95    time.Duration
+	Max    time.Duration
+	Mean   time.Duration
+}
+
+// BenchResult holds the results of Bench.
+type BenchResult struct {
+	// Runs holds the measured runs, in the order they finished.
+	Runs []BenchRun
+
+	// Wall, User and System summarize respectively the Duration,
+	// UserTime and SystemTime of the runs.
+	Wall   BenchStats
+	User   BenchStats
+	System BenchStats
+}
+
+// Bench runs the command n times, after the warmup runs if any, and
+// returns statistics of their duration and CPU usage. cmd is used as
+// a template and is not run itself: each run uses a copy of it
+// associated with ctx, with the same command line, environment,
+// working directory and options. Its standard output is discarded,
+// and its standard input, if any, is read once and given to every
+// run.
+//
+// Bench stops at the first failed run and returns its error.
+func Bench(ctx context.Context, n int, cmd *Cmd, opts ...BenchOption) (*BenchResult, error) {
+	cfg := benchConfig{parallel: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.parallel < 1 {
+		cfg.parallel = 1
+	}
+
+	var stdin []byte
+	if cmd.Stdin != nil {
+		var err error
+		if stdin, err = io.ReadAll(cmd.Stdin); err != nil {
+			return nil, err
+		}
+	}
+
+	run := func() (BenchRun, error) {
+		c := cmd.clone(ctx)
+		if cmd.Stdin != nil {
+			c.Stdin = bytes.NewReader(stdin)
+		}
+		start := time.Now()
+		if err := c.Run(); err != nil {
+			return BenchRun{}, err
+		}
+		r := BenchRun{Duration: time.Since(start)}
+		if c.ProcessState != nil {
+			r.UserTime = c.ProcessState.UserTime()
+			r.SystemTime = c.ProcessState.SystemTime()
+		}
+		return r, nil
+	}
+
+	if _, err := benchRuns(ctx, cfg.warmup, cfg.parallel, run); err != nil {
+		return nil, err
+	}
+
+	runs, err := benchRuns(ctx, n, cfg.parallel, run)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &BenchResult{Runs: runs}
+	res.Wall = benchStats(runs, func(r BenchRun) time.Duration { return r.Duration })
+	res.User = benchStats(runs, func(r BenchRun) time.Duration { return r.UserTime })
+	res.System = benchStats(runs, func(r BenchRun) time.Duration { return r.SystemTime })
+
+	return res, nil
+}
+
+// benchRuns calls run n times with up to parallel concurrent calls,
+// stopping at the first error.
+func benchRuns(ctx context.Context, n, parallel int, run func() (BenchRun, error)) ([]BenchRun, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		runs     = make([]BenchRun, 0, n)
+		firstErr error
+		next     int
+	)
+
+	for i := 0; i < parallel && i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				if firstErr != nil || next >= n {
+					mu.Unlock()
+					return
+				}
+				if err := ctx.Err(); err != nil {
+					firstErr = err
+					mu.Unlock()
+					return
+				}
+				next++
+				mu.Unlock()
+
+				r, err := run()
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					runs = append(runs, r)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return runs, firstErr
+}
+
+func benchStats(runs []BenchRun, f func(BenchRun) time.Duration) BenchStats {
+	if len(runs) == 0 {
+		return BenchStats{}
+	}
+
+	ds := make([]time.Duration, len(runs))
+	var sum time.Duration
+	for i, r := range runs {
+		ds[i] = f(r)
+		sum += ds[i]
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+
+	return BenchStats{
+		Min:    ds[0],
+		Median: percentile(ds, 50),
+		P95:    percentile(ds, 95),
+		Max:    ds[len(ds)-1],
+		Mean:   sum / time.Duration(len(ds)),
+	}
+}
+
+// percentile returns the p-th percentile of the sorted durations
+// using the nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
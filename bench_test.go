@@ -0,0 +1,58 @@
+package exex_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestBench(t *testing.T) {
+	var runs int32
+	count := exex.Hooks{
+		Before: func(*exex.Cmd) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		},
+	}.Middleware()
+
+	cmd := exex.Command(os.Args[0]).Apply(
+		exex.WithEnv("TEST_MAIN=cat"),
+		exex.WithStdin(strings.NewReader("input")),
+		exex.WithMiddleware(count),
+	)
+
+	res, err := exex.Bench(context.Background(), 5, cmd, exex.BenchWarmup(2), exex.BenchParallel(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Runs) != 5 {
+		t.Fatalf("expecting 5 runs, got %d", len(res.Runs))
+	}
+	if n := atomic.LoadInt32(&runs); n != 7 {
+		t.Fatalf("expecting 7 executions including warmup, got %d", n)
+	}
+	if cmd.ProcessState != nil {
+		t.Fatal("not expecting the template command to run")
+	}
+
+	w := res.Wall
+	if !(0 < w.Min && w.Min <= w.Median && w.Median <= w.P95 && w.P95 <= w.Max) {
+		t.Fatalf("inconsistent stats %+v", w)
+	}
+	if w.Mean < w.Min || w.Mean > w.Max {
+		t.Fatalf("mean out of range %+v", w)
+	}
+}
+
+func TestBenchError(t *testing.T) {
+	res, err := exex.Bench(context.Background(), 3, exex.Command(os.Args[0], "bench"))
+	if res != nil {
+		t.Fatalf("not expecting a result, got %+v", res)
+	}
+	assertErr(t, err, "error: bench")
+}
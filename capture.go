@@ -0,0 +1,223 @@
+package exex
+
+import (
+	"io"
+	"sync"
+)
+
+// CaptureMode selects which part of the standard error stream is
+// kept when it exceeds the capture limit.
+type CaptureMode int
+
+const (
+	// KeepHead keeps the first bytes written to the stream.
+	KeepHead CaptureMode = iota
+
+	// KeepTail keeps the last bytes written to the stream.
+	KeepTail
+)
+
+// StderrLimit is the default maximum number of bytes of the standard
+// error stream that a Cmd captures. Zero or negative means no limit.
+var StderrLimit int
+
+// StderrMode is the default CaptureMode used when the standard error
+// stream exceeds StderrLimit.
+var StderrMode = KeepHead
+
+// WithStderrLimit bounds the standard error captured by the command
+// to n bytes, keeping the part selected by mode, instead of using
+// StderrLimit and StderrMode. Zero or negative n means no limit.
+func WithStderrLimit(n int, mode CaptureMode) Option {
+	return func(c *Cmd) {
+		c.stderrLimit = &captureLimit{n: n, mode: mode}
+	}
+}
+
+// CaptureConfig configures how the buffers capturing the standard
+// error of commands grow. The maximum size of the buffers is set with
+// StderrLimit or WithStderrLimit.
+type CaptureConfig struct {
+	// InitialSize is the initial capacity of the buffers. Zero or
+	// negative means no preallocation.
+	InitialSize int
+
+	// GrowthFactor is how much the capacity of the buffers is
+	// multiplied when they are full. Values lower than or equal to 1
+	// use the growth strategy of append.
+	GrowthFactor float64
+
+	// Pool reuses the buffers of the commands that succeeded, whose
+	// standard error is not reported, reducing allocations when
+	// running many commands. Buffers larger than 64KB are not
+	// reused. The standard error of successful commands is then not
+	// available, e.g. in the errors of OutputJSON.
+	Pool bool
+}
+
+// DefaultCapture is the CaptureConfig used by commands without one set
+// with WithCaptureConfig.
+var DefaultCapture = CaptureConfig{InitialSize: 1024}
+
+// WithCaptureConfig sets how the buffer capturing the standard error
+// of the command grows, instead of using DefaultCapture.
+func WithCaptureConfig(cfg CaptureConfig) Option {
+	return func(c *Cmd) { c.captureCfg = &cfg }
+}
+
+// captureStderr sets the Stderr of the command to capture it, copying
+// it also to w and the writer set by TeeStderr.
+func (c *Cmd) captureStderr(w ...io.Writer) {
+	cfg := DefaultCapture
+	if c.captureCfg != nil {
+		cfg = *c.captureCfg
+	}
+	c.stderr = newCapture(c.stderrLimit, cfg)
+	ws := append([]io.Writer{c.stderr}, w...)
+	if c.stderrTee != nil {
+		ws = append(ws, c.stderrTee)
+	}
+	if len(ws) == 1 {
+		c.Stderr = c.stderr
+	} else {
+		c.Stderr = io.MultiWriter(ws...)
+	}
+}
+
+type captureLimit struct {
+	n    int
+	mode CaptureMode
+}
+
+// capture is an io.Writer that stores the bytes written to it,
+// optionally bounded to a limit. Writes never fail, so the command
+// writing to it is never affected by the limit.
+type capture struct {
+	buf    []byte
+	limit  int
+	mode   CaptureMode
+	pos    int // next write position once a KeepTail buffer is full
+	factor float64
+	pooled bool
+}
+
+// maxPooled is the maximum capacity of the buffers reused with
+// CaptureConfig.Pool.
+const maxPooled = 64 << 10
+
+var capturePool sync.Pool // *[]byte
+
+func newCapture(l *captureLimit, cfg CaptureConfig) *capture {
+	c := &capture{limit: StderrLimit, mode: StderrMode, factor: cfg.GrowthFactor, pooled: cfg.Pool}
+	if l != nil {
+		c.limit, c.mode = l.n, l.mode
+	}
+
+	size := cfg.InitialSize
+	if c.limit > 0 && c.limit < size {
+		size = c.limit
+	}
+	if cfg.Pool {
+		if b, ok := capturePool.Get().(*[]byte); ok {
+			c.buf = (*b)[:0]
+		}
+	}
+	if cap(c.buf) < size {
+		c.buf = make([]byte, 0, size)
+	}
+
+	return c
+}
+
+// release returns the buffer to the pool. The capture must not be
+// used afterwards.
+func (c *capture) release() {
+	if c.pos == 0 && cap(c.buf) <= maxPooled {
+		b := c.buf[:0]
+		capturePool.Put(&b)
+	}
+	c.buf = nil
+}
+
+// grow makes room in the buffer for n more bytes using the growth
+// factor, if any.
+func (c *capture) grow(n int) {
+	if c.factor <= 1 || cap(c.buf)-len(c.buf) >= n {
+		return
+	}
+
+	size := int(float64(cap(c.buf)) * c.factor)
+	if size < len(c.buf)+n {
+		size = len(c.buf) + n
+	}
+	if c.limit > 0 && size > c.limit {
+		size = c.limit
+	}
+
+	b := make([]byte, len(c.buf), size)
+	copy(b, c.buf)
+	c.buf = b
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	n := len(p)
+
+	switch {
+	case c.limit <= 0:
+		c.grow(len(p))
+		c.buf = append(c.buf, p...)
+
+	case c.mode == KeepHead:
+		if free := c.limit - len(c.buf); free < len(p) {
+			p = p[:free]
+		}
+		c.grow(len(p))
+		c.buf = append(c.buf, p...)
+
+	default:
+		if len(p) > c.limit {
+			p = p[len(p)-c.limit:]
+		}
+		if free := c.limit - len(c.buf); free > 0 {
+			if free > len(p) {
+				free = len(p)
+			}
+			c.buf, p = append(c.buf, p[:free]...), p[free:]
+		}
+		// The buffer is full, continue writing it as a ring.
+		for len(p) > 0 {
+			m := copy(c.buf[c.pos:], p)
+			p = p[m:]
+			c.pos = (c.pos + m) % c.limit
+		}
+	}
+
+	return n, nil
+}
+
+// Bytes returns the captured bytes in the order they were written.
+func (c *capture) Bytes() []byte {
+	if c.pos == 0 {
+		return c.buf
+	}
+	b := make([]byte, 0, len(c.buf))
+	return append(append(b, c.buf[c.pos:]...), c.buf[:c.pos]...)
+}
+
+// captured returns the captured output b of the command, converted
+// to UTF-8 if set with WithOutputEncoding and stripped of ANSI escape
+// sequences if set with WithStripANSI.
+func (c *Cmd) captured(b []byte) []byte {
+	if len(b) == 0 {
+		return b
+	}
+	if c.decoder != nil {
+		if d, err := c.decoder.Bytes(b); err == nil {
+			b = d
+		}
+	}
+	if c.stripANSI {
+		b = StripANSI(b)
+	}
+	return b
+}
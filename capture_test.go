@@ -0,0 +1,64 @@
+package exex_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestStderrLimit(t *testing.T) {
+	args := []string{"foo", "bar", "baz", strings.Repeat("x", 2000), "quux"}
+	full := "error: " + strings.Join(args, " ")
+
+	tests := map[string]struct {
+		n    int
+		mode exex.CaptureMode
+		exp  string
+	}{
+		"unlimited": {0, exex.KeepHead, full},
+		"head":      {8, exex.KeepHead, full[:8]},
+		"tail":      {13, exex.KeepTail, full[len(full)-13:]},
+		"tail big":  {1500, exex.KeepTail, full[len(full)-1500:]},
+		"no limit":  {len(full) + 10, exex.KeepTail, full},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := exex.Command(os.Args[0], args...).Apply(exex.WithStderrLimit(tt.n, tt.mode)).Run()
+			assertErr(t, err, tt.exp)
+		})
+	}
+
+	t.Run("default", func(t *testing.T) {
+		exex.StderrLimit, exex.StderrMode = 10, exex.KeepTail
+		defer func() { exex.StderrLimit, exex.StderrMode = 0, exex.KeepHead }()
+
+		err := exex.Run(os.Args[0], args...)
+		assertErr(t, err, full[len(full)-10:])
+	})
+}
+
+func TestWithCaptureConfig(t *testing.T) {
+	args := []string{strings.Repeat("x", 5000)}
+	full := "error: " + args[0]
+
+	tests := map[string]exex.CaptureConfig{
+		"no prealloc": {},
+		"factor":      {InitialSize: 16, GrowthFactor: 1.5},
+		"pool":        {InitialSize: 64, GrowthFactor: 4, Pool: true},
+	}
+
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 3; i++ {
+				if err := exex.Command(os.Args[0], "0").Apply(exex.WithEnv("TEST_MAIN=exit"), exex.WithCaptureConfig(cfg)).Run(); err != nil {
+					t.Fatal(err)
+				}
+				err := exex.Command(os.Args[0], args...).Apply(exex.WithCaptureConfig(cfg)).Run()
+				assertErr(t, err, full)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Package cgroup runs exex commands in transient Linux control groups
+// (cgroup v2), capping the memory, CPU and number of processes used by
+// them and their descendants:
+//
+//	cmd := exex.Command("plugin").Apply(cgroup.With(cgroup.Config{
+//		Memory: 512 << 20,
+//		CPU:    0.5,
+//	}))
+//
+// By default the control group is created when the command is
+// started, under Parent, and the command is placed in it atomically
+// using clone3 with CLONE_INTO_CGROUP, which requires Linux 5.7 or
+// later. Once the command finished and was waited for, the processes
+// left in the control group are killed and it is removed.
+//
+// With Config.Systemd set, the command is run in a transient systemd
+// scope with systemd-run instead, which does not require privileges
+// when using the service manager of the user.
+//
+// On other systems starting the command fails with an
+// *exex.UnsupportedFeatureError.
+package cgroup
+
+import "github.com/inkel/exex"
+
+// Config configures the control group of a command. The zero value
+// creates a control group without limits.
+type Config struct {
+	// Memory, if positive, is the maximum memory in bytes, set in
+	// memory.max.
+	Memory int64
+
+	// CPU, if positive, is the maximum CPU time as a number of CPUs,
+	// e.g. 0.5 for half a CPU, set in cpu.max.
+	CPU float64
+
+	// PIDs, if positive, is the maximum number of processes, set in
+	// pids.max.
+	PIDs int64
+
+	// Parent is the directory of the control group under which the
+	// control groups of the commands are created. It must be
+	// writable and have the controllers needed for the limits
+	// available. Defaults to the root of the cgroup v2 hierarchy.
+	Parent string
+
+	// Systemd runs the command in a transient scope with
+	// systemd-run, using the service manager of the user unless the
+	// current process runs as root, instead of creating the control
+	// group directly.
+	Systemd bool
+}
+
+// Middleware returns an exex.Middleware running commands in control
+// groups configured by cfg.
+func Middleware(cfg Config) exex.Middleware {
+	return func(next exex.Runner) exex.Runner { return &runner{next: next, cfg: cfg} }
+}
+
+// With runs the command in a control group configured by cfg.
+func With(cfg Config) exex.Option {
+	return exex.WithMiddleware(Middleware(cfg))
+}
+
+type runner struct {
+	next exex.Runner
+	cfg  Config
+	dir  string // control group created for the command
+}
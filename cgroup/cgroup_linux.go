@@ -0,0 +1,164 @@
+package cgroup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+// cpuPeriod is the period of cpu.max, in microseconds.
+const cpuPeriod = 100000
+
+var seq atomic.Int64
+
+func (r *runner) Start(c *exex.Cmd) error {
+	if r.cfg.Systemd {
+		if err := r.systemd(c); err != nil {
+			return err
+		}
+		return r.next.Start(c)
+	}
+
+	dir, err := r.create()
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		remove(dir)
+		return fmt.Errorf("cgroup: %w", err)
+	}
+	defer f.Close()
+
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.UseCgroupFD = true
+	c.SysProcAttr.CgroupFD = int(f.Fd())
+
+	if err := r.next.Start(c); err != nil {
+		remove(dir)
+		return err
+	}
+	r.dir = dir
+	return nil
+}
+
+func (r *runner) Wait(c *exex.Cmd) error {
+	err := r.next.Wait(c)
+	if r.dir != "" {
+		if rerr := remove(r.dir); err == nil {
+			err = rerr
+		}
+		r.dir = ""
+	}
+	return err
+}
+
+// create creates the control group of a command with its limits.
+func (r *runner) create() (string, error) {
+	parent := r.cfg.Parent
+	if parent == "" {
+		var err error
+		if parent, err = root(); err != nil {
+			return "", err
+		}
+	}
+
+	files := make(map[string]string)
+	if r.cfg.Memory > 0 {
+		files["memory.max"] = strconv.FormatInt(r.cfg.Memory, 10)
+	}
+	if r.cfg.CPU > 0 {
+		files["cpu.max"] = fmt.Sprintf("%d %d", max(int64(r.cfg.CPU*cpuPeriod), 1000), cpuPeriod)
+	}
+	if r.cfg.PIDs > 0 {
+		files["pids.max"] = strconv.FormatInt(r.cfg.PIDs, 10)
+	}
+
+	// Enable the controllers of the limits for the children of the
+	// parent, which is a no-op if already enabled.
+	for name := range files {
+		ctrl := "+" + name[:len(name)-len(".max")]
+		if err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte(ctrl), 0); err != nil {
+			return "", fmt.Errorf("cgroup: enabling controller %s: %w", ctrl[1:], err)
+		}
+	}
+
+	dir := filepath.Join(parent, fmt.Sprintf("exex-%d-%d", os.Getpid(), seq.Add(1)))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return "", fmt.Errorf("cgroup: %w", err)
+	}
+	for name, v := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(v), 0); err != nil {
+			remove(dir)
+			return "", fmt.Errorf("cgroup: %w", err)
+		}
+	}
+	return dir, nil
+}
+
+// root returns the root directory of the cgroup v2 hierarchy, which
+// is mounted at /sys/fs/cgroup/unified on hybrid systems.
+func root() (string, error) {
+	for _, dir := range []string{"/sys/fs/cgroup", "/sys/fs/cgroup/unified"} {
+		if _, err := os.Stat(filepath.Join(dir, "cgroup.controllers")); err == nil {
+			return dir, nil
+		}
+	}
+	return "", errors.New("cgroup: cgroup v2 hierarchy not found")
+}
+
+// remove kills the processes left in the control group dir, if
+// supported, and removes it.
+func remove(dir string) error {
+	os.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0)
+
+	// Killed processes leave the control group asynchronously.
+	var err error
+	for i := 0; i < 100; i++ {
+		if err = syscall.Rmdir(dir); err != syscall.EBUSY {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil && err != syscall.ENOENT {
+		return fmt.Errorf("cgroup: removing %s: %w", dir, err)
+	}
+	return nil
+}
+
+// systemd makes the command run in a transient scope with systemd-run.
+func (r *runner) systemd(c *exex.Cmd) error {
+	path, err := exec.LookPath("systemd-run")
+	if err != nil {
+		return fmt.Errorf("cgroup: %w", err)
+	}
+
+	args := []string{"systemd-run", "--scope", "--quiet", "--collect"}
+	if os.Geteuid() != 0 {
+		args = append(args, "--user")
+	}
+	if r.cfg.Memory > 0 {
+		args = append(args, "-p", "MemoryMax="+strconv.FormatInt(r.cfg.Memory, 10))
+	}
+	if r.cfg.CPU > 0 {
+		args = append(args, "-p", fmt.Sprintf("CPUQuota=%d%%", max(int64(r.cfg.CPU*100), 1)))
+	}
+	if r.cfg.PIDs > 0 {
+		args = append(args, "-p", "TasksMax="+strconv.FormatInt(r.cfg.PIDs, 10))
+	}
+	args = append(args, "--", c.Path)
+
+	c.Args = append(args, c.Args[1:]...)
+	c.Path = path
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package cgroup
+
+import (
+	"runtime"
+
+	"github.com/inkel/exex"
+)
+
+func (r *runner) Start(c *exex.Cmd) error {
+	return &exex.UnsupportedFeatureError{Feature: "cgroup", GOOS: runtime.GOOS}
+}
+
+func (r *runner) Wait(c *exex.Cmd) error {
+	return r.next.Wait(c)
+}
@@ -0,0 +1,86 @@
+//go:build linux
+
+package cgroup_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+	"github.com/inkel/exex/cgroup"
+)
+
+// testRoot returns the root of the cgroup v2 hierarchy, skipping the
+// test if it is not writable.
+func testRoot(t *testing.T) string {
+	t.Helper()
+
+	for _, dir := range []string{"/sys/fs/cgroup", "/sys/fs/cgroup/unified"} {
+		if _, err := os.Stat(filepath.Join(dir, "cgroup.controllers")); err != nil {
+			continue
+		}
+		probe := filepath.Join(dir, "exex-test-probe")
+		if err := os.Mkdir(probe, 0o755); err != nil {
+			t.Skip(err)
+		}
+		os.Remove(probe)
+		return dir
+	}
+
+	t.Skip("cgroup v2 hierarchy not found")
+	return ""
+}
+
+func TestWith(t *testing.T) {
+	root := testRoot(t)
+
+	out, err := exex.Command("/bin/cat", "/proc/self/cgroup").Apply(cgroup.With(cgroup.Config{})).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var name string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if p, ok := strings.CutPrefix(line, "0::/"); ok {
+			name = p
+		}
+	}
+	if !strings.HasPrefix(name, "exex-") {
+		t.Fatalf("expecting the command in an exex control group, got %q", out)
+	}
+	if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+		t.Fatalf("expecting control group %s to be removed, got %v", name, err)
+	}
+}
+
+func TestWithLimits(t *testing.T) {
+	root := testRoot(t)
+
+	b, _ := os.ReadFile(filepath.Join(root, "cgroup.controllers"))
+	for _, ctrl := range []string{"memory", "cpu", "pids"} {
+		if !strings.Contains(string(b), ctrl) {
+			t.Skipf("controller %s not available", ctrl)
+		}
+	}
+
+	cfg := cgroup.Config{Memory: 64 << 20, CPU: 0.5, PIDs: 16}
+	out, err := exex.Command("/bin/sh", "-c", `cd "$0$(sed -n 's/^0:://p' /proc/self/cgroup)" && cat memory.max cpu.max pids.max`, root).Apply(
+		exex.WithEnv("PATH=/usr/bin:/bin"),
+		cgroup.With(cfg),
+	).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "67108864\n50000 100000\n16\n"; string(out) != exp {
+		t.Fatalf("expecting %q, got %q", exp, out)
+	}
+}
+
+func TestMiddlewareStartFailure(t *testing.T) {
+	err := exex.Command("/bin/true").Apply(cgroup.With(cgroup.Config{Parent: filepath.Join(t.TempDir(), "missing")})).Run()
+	if err == nil {
+		t.Fatal("expecting error")
+	}
+}
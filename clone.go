@@ -0,0 +1,114 @@
+package exex
+
+import (
+	"context"
+	"os/exec"
+)
+
+// Clone returns a new Cmd configured as c, so that it can be run
+// again, e.g. to retry it or to run it in a loop: it has the same
+// path, arguments, environment, working directory, system process
+// attributes, context and options, but not its standard streams,
+// redirections, the command set with StdinFromCommand nor its
+// execution state. The Cancel functions set by options, such as
+// WithGracefulStop, are set again for the new Cmd, but a Cancel
+// function set directly on c is not copied, as it refers to the
+// process of c. It can be used with Retry:
+//
+//	err := exex.Retry(ctx, &exex.Backoff{}, cmd.Clone)
+func (c *Cmd) Clone() *Cmd {
+	return c.clone(c.ctx)
+}
+
+// clone returns a new Cmd configured as c, associated with ctx if not
+// nil: same path, arguments, environment, working directory, system
+// process attributes and options. Streams, redirections, the command
+// set with StdinFromCommand and execution state are not copied.
+func (c *Cmd) clone(ctx context.Context) *Cmd {
+	var ec *exec.Cmd
+	if ctx != nil {
+		ec = exec.CommandContext(ctx, c.Path)
+	} else {
+		ec = exec.Command(c.Path)
+	}
+	ec.Path = c.Path
+	ec.Args = append([]string(nil), c.Args...)
+	ec.Err = c.Err
+	ec.Dir = c.Dir
+	ec.WaitDelay = c.WaitDelay
+	if c.Env != nil {
+		ec.Env = append([]string(nil), c.Env...)
+	}
+	if c.SysProcAttr != nil {
+		attr := *c.SysProcAttr
+		ec.SysProcAttr = &attr
+	}
+
+	n := &Cmd{
+		Cmd:          ec,
+		ctx:          ctx,
+		stderrLimit:  c.stderrLimit,
+		mergeStderr:  c.mergeStderr,
+		runner:       c.runner,
+		middlewares:  append([]Middleware(nil), c.middlewares...),
+		artifacts:    c.artifacts,
+		quiet:        c.quiet,
+		auditTags:    append([]string(nil), c.auditTags...),
+		logger:       c.logger,
+		runOnce:      c.runOnce,
+		backpressure: c.backpressure,
+		orphans:      c.orphans,
+		secrets:      c.secrets,
+		sanitize:     c.sanitize,
+		captureCfg:   c.captureCfg,
+		split:        c.split,
+		degradation:  c.degradation,
+		sysroot:      c.sysroot,
+		stripANSI:    c.stripANSI,
+		decoder:      c.decoder,
+		credential:   c.credential,
+		elevation:    c.elevation,
+		sandbox:      c.sandbox,
+		rlimits:      append([]rlimit(nil), c.rlimits...),
+		nice:         c.nice,
+		ioPriority:   c.ioPriority,
+		spill:        c.spill,
+		recording:    c.recording,
+	}
+	if c.idle != nil {
+		n.idle = &idleWatch{timeout: c.idle.timeout}
+	}
+	if l := c.outputLimit; l != nil {
+		n.outputLimit = &outputLimit{max: l.max, truncate: l.truncate}
+	}
+	if c.tempDir != nil {
+		n.tempDir = &tempTracking{}
+	}
+	if c.pty != nil {
+		c.pty.mu.Lock()
+		n.pty = &ptyState{rows: c.pty.rows, cols: c.pty.cols}
+		c.pty.mu.Unlock()
+	}
+	if c.heuristics != nil {
+		n.heuristics = &heuristics{}
+	}
+	if c.snapshot != nil {
+		n.snapshot = &dirSnapshot{patterns: c.snapshot.patterns}
+	}
+	if c.watch != nil {
+		n.watch = &fsWatch{paths: c.watch.paths}
+	}
+	// Re-apply the options setting Cancel, as their functions refer
+	// to c. WithGracefulStop goes last, as it overrides the others.
+	if c.group {
+		WithProcessGroup()(n)
+	}
+	if c.job != nil {
+		WithJobObject(c.job.limits)(n)
+	}
+	if c.stopSignal != nil {
+		WithGracefulStop(c.stopSignal, c.WaitDelay)(n)
+	}
+
+	return n
+}
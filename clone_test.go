@@ -0,0 +1,68 @@
+package exex_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestCmd_Clone(t *testing.T) {
+	cmd := exex.Command(os.Args[0], "one", "two").Apply(
+		exex.WithEnv("TEST_MAIN=echo"),
+		exex.WithDir(os.TempDir()),
+		exex.WithHeuristics(),
+	)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	c := cmd.Clone()
+	if c.Path != cmd.Path || !reflect.DeepEqual(c.Args, cmd.Args) || !reflect.DeepEqual(c.Env, cmd.Env) || c.Dir != cmd.Dir {
+		t.Fatalf("expecting %v, got %v", cmd, c)
+	}
+	if c.Stdout != nil || c.Process != nil || c.Result() != nil {
+		t.Fatal("expecting streams and execution state not to be copied")
+	}
+
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, stdout.Bytes()) {
+		t.Fatalf("expecting %q, got %q", stdout.Bytes(), out)
+	}
+
+	c.Args = append(c.Args, "three")
+	if len(cmd.Args) != 3 {
+		t.Fatalf("modifying the clone changed the original args: %q", cmd.Args)
+	}
+
+	t.Run("context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		cmd := exex.CommandContext(ctx, os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=hang"))
+		if err := cmd.Clone().Run(); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expecting context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		cmd := exex.Command(os.Args[0], "1", "failed").Apply(exex.WithEnv("TEST_MAIN=exit"))
+		var attempts int
+		err := exex.Retry(context.Background(), &exex.Backoff{Initial: 1}, func() *exex.Cmd {
+			attempts++
+			return cmd.Clone()
+		})
+		assertErr(t, err, "failed")
+		if attempts != 3 {
+			t.Fatalf("expecting 3 attempts, got %d", attempts)
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package exex
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Row is a row of tabular output parsed by ParseColumns, mapping the
+// name of each column to its value.
+type Row map[string]string
+
+// Int returns the value of column col parsed as an integer.
+func (r Row) Int(col string) (int, error) {
+	v, ok := r[col]
+	if !ok {
+		return 0, fmt.Errorf("exex: unknown column %q", col)
+	}
+	return strconv.Atoi(v)
+}
+
+// Float returns the value of column col parsed as a floating point
+// number. A trailing percent sign, as in the output of df, is
+// ignored.
+func (r Row) Float(col string) (float64, error) {
+	v, ok := r[col]
+	if !ok {
+		return 0, fmt.Errorf("exex: unknown column %q", col)
+	}
+	return strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+}
+
+// ParseColumns parses the human-formatted tabular output of tools
+// like ps, df or netstat. The first non-empty line of output must be
+// a header with the given column names, which can contain spaces,
+// like "Mounted on". If no columns are given they are taken from the
+// header, split by whitespace.
+//
+// Values are separated by whitespace, and the last column takes the
+// remainder of the line, so it can contain spaces, like the COMMAND
+// column of ps. Rows with fewer values than columns, because of empty
+// cells, are matched to the columns using the position of the values
+// relative to the header.
+func ParseColumns(output []byte, columns ...string) ([]Row, error) {
+	sc := bufio.NewScanner(bytes.NewReader(output))
+
+	var header string
+	for sc.Scan() {
+		if header = sc.Text(); strings.TrimSpace(header) != "" {
+			break
+		}
+	}
+	if strings.TrimSpace(header) == "" {
+		return nil, sc.Err()
+	}
+
+	if len(columns) == 0 {
+		columns = strings.Fields(header)
+	}
+
+	spans, err := headerSpans(header, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []Row
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		rows = append(rows, parseRow(line, columns, spans))
+	}
+
+	return rows, sc.Err()
+}
+
+type span struct{ start, end int }
+
+type token struct {
+	span
+	s string
+}
+
+// headerSpans returns the position of each column in the header.
+func headerSpans(header string, columns []string) ([]span, error) {
+	spans := make([]span, len(columns))
+	pos := 0
+	for i, col := range columns {
+		j := strings.Index(header[pos:], col)
+		if j < 0 || strings.TrimSpace(header[pos:pos+j]) != "" {
+			return nil, fmt.Errorf("exex: column %q not found in header %q", col, header)
+		}
+		spans[i] = span{pos + j, pos + j + len(col)}
+		pos = spans[i].end
+	}
+	if rest := strings.TrimSpace(header[pos:]); rest != "" {
+		return nil, fmt.Errorf("exex: unexpected columns %q in header %q", rest, header)
+	}
+	return spans, nil
+}
+
+func parseRow(line string, columns []string, spans []span) Row {
+	toks := tokenize(line)
+	row := make(Row, len(columns))
+
+	if len(toks) >= len(columns) {
+		last := len(columns) - 1
+		for i := 0; i < last; i++ {
+			row[columns[i]] = toks[i].s
+		}
+		row[columns[last]] = strings.TrimSpace(line[toks[last].start:])
+		return row
+	}
+
+	for _, col := range columns {
+		row[col] = ""
+	}
+	for _, t := range toks {
+		col := columns[nearestSpan(t.span, spans)]
+		if row[col] != "" {
+			row[col] += " "
+		}
+		row[col] += t.s
+	}
+
+	return row
+}
+
+func tokenize(line string) []token {
+	var toks []token
+	start := -1
+	for i, r := range line {
+		switch {
+		case unicode.IsSpace(r) && start >= 0:
+			toks = append(toks, token{span{start, i}, line[start:i]})
+			start = -1
+		case !unicode.IsSpace(r) && start < 0:
+			start = i
+		}
+	}
+	if start >= 0 {
+		toks = append(toks, token{span{start, len(line)}, line[start:]})
+	}
+	return toks
+}
+
+// nearestSpan returns the index of the span that overlaps the most
+// with s or, if none does, the closest one.
+func nearestSpan(s span, spans []span) int {
+	best, bestScore := 0, 0
+	for i, h := range spans {
+		start, end := s.start, s.end
+		if h.start > start {
+			start = h.start
+		}
+		if h.end < end {
+			end = h.end
+		}
+		// The score is the length of the overlap, or the negative
+		// distance between both spans if they do not overlap.
+		if score := end - start; i == 0 || score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,83 @@
+package exex_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestParseColumns(t *testing.T) {
+	t.Run("ps", func(t *testing.T) {
+		out := `
+  PID TTY          TIME CMD
+    1 ?        00:00:02 /sbin/init splash
+12345 pts/0    00:00:00 bash
+`
+		rows, err := exex.ParseColumns([]byte(out))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		exp := []exex.Row{
+			{"PID": "1", "TTY": "?", "TIME": "00:00:02", "CMD": "/sbin/init splash"},
+			{"PID": "12345", "TTY": "pts/0", "TIME": "00:00:00", "CMD": "bash"},
+		}
+		if !reflect.DeepEqual(rows, exp) {
+			t.Fatalf("expecting %q, got %q", exp, rows)
+		}
+
+		pid, err := rows[1].Int("PID")
+		if err != nil || pid != 12345 {
+			t.Fatalf("expecting PID 12345, got %d (%v)", pid, err)
+		}
+	})
+
+	t.Run("df", func(t *testing.T) {
+		out := `Filesystem     1K-blocks     Used Available Use% Mounted on
+/dev/sda1       61255492 12345678  45763410  22% /
+tmpfs            8161240        0   8161240   0% /mnt/my disk
+`
+		rows, err := exex.ParseColumns([]byte(out), "Filesystem", "1K-blocks", "Used", "Available", "Use%", "Mounted on")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(rows) != 2 {
+			t.Fatalf("expecting 2 rows, got %d", len(rows))
+		}
+		if got := rows[1]["Mounted on"]; got != "/mnt/my disk" {
+			t.Fatalf("expecting %q, got %q", "/mnt/my disk", got)
+		}
+		if use, err := rows[0].Float("Use%"); err != nil || use != 22 {
+			t.Fatalf("expecting 22, got %v (%v)", use, err)
+		}
+	})
+
+	t.Run("empty cells", func(t *testing.T) {
+		out := `Proto Recv-Q Send-Q Local Address  State
+tcp        0      0 0.0.0.0:22     LISTEN
+udp        0      0 0.0.0.0:68
+`
+		rows, err := exex.ParseColumns([]byte(out), "Proto", "Recv-Q", "Send-Q", "Local Address", "State")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		exp := exex.Row{"Proto": "udp", "Recv-Q": "0", "Send-Q": "0", "Local Address": "0.0.0.0:68", "State": ""}
+		if !reflect.DeepEqual(rows[1], exp) {
+			t.Fatalf("expecting %q, got %q", exp, rows[1])
+		}
+	})
+
+	t.Run("header mismatch", func(t *testing.T) {
+		_, err := exex.ParseColumns([]byte("PID CMD\n1 init\n"), "PID", "COMMAND")
+		if err == nil {
+			t.Fatal("expecting an error")
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		rows, err := exex.ParseColumns(nil)
+		if err != nil || rows != nil {
+			t.Fatalf("expecting no rows nor error, got %v, %v", rows, err)
+		}
+	})
+}
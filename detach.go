@@ -0,0 +1,78 @@
+package exex
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// StartDetached starts the command detached from the current process,
+// e.g. a long-lived helper launched by a command line tool, and
+// returns its PID. The command runs in a new session on Unix systems,
+// and as a detached process in a new process group on Windows, so it
+// is not affected by the terminal or the signals of the current
+// process. Elsewhere it fails with an *UnsupportedFeatureError,
+// unless set otherwise with WithDegradation.
+//
+// The standard input and outputs of the command, if set, must be
+// files, e.g. using RedirectFD, so that no copying goroutines depend
+// on the current process; those not set are connected to the null
+// device.
+//
+// The command must not be waited for: it is reaped in the background
+// if it exits while the current process is running. Its context,
+// middlewares and the options observing its execution, like
+// WithIdleTimeout or WithTempTracking, are not used, and Result
+// returns nil.
+func (c *Cmd) StartDetached() (int, error) {
+	if c.Process != nil {
+		return 0, errors.New("exec: already started")
+	}
+	if err := c.checkPolicy(); err != nil {
+		return 0, err
+	}
+	if errs := c.checkSanitize(); len(errs) > 0 {
+		return 0, errs[0]
+	}
+	if err := c.resolveSysroot(); err != nil {
+		return 0, err
+	}
+	if err := c.applyRedirects(); err != nil {
+		return 0, err
+	}
+
+	for _, f := range []struct {
+		name string
+		v    any
+	}{{"Stdin", c.Stdin}, {"Stdout", c.Stdout}, {"Stderr", c.Stderr}} {
+		if !detachable(f.v) {
+			return 0, fmt.Errorf("exex: %s is not a file", f.name)
+		}
+	}
+
+	if err := c.degrade(c.setDetached()); err != nil {
+		return 0, err
+	}
+
+	// Start the embedded command directly, without its middlewares,
+	// and make sure the context of the command does not kill it.
+	c.Cancel, c.WaitDelay = nil, 0
+	if err := c.Cmd.Start(); err != nil {
+		return 0, err
+	}
+	go c.Cmd.Wait()
+
+	return c.Process.Pid, nil
+}
+
+// detachable reports whether v, a standard input or output of a
+// command, can be used by a detached process.
+func detachable(v any) bool {
+	switch f := v.(type) {
+	case nil:
+		return true
+	case *os.File:
+		return f != nil
+	}
+	return false
+}
@@ -0,0 +1,7 @@
+//go:build !unix && !windows
+
+package exex
+
+func (c *Cmd) setDetached() error {
+	return unsupported("detached process")
+}
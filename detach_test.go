@@ -0,0 +1,75 @@
+//go:build unix
+
+package exex_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+func TestStartDetached(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "out")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cmd := exex.CommandContext(ctx, "/bin/sh", "-c", "echo ready; exec sleep 1000").Apply(
+		exex.WithEnv("PATH=/usr/bin:/bin"),
+		exex.RedirectFD(1, f),
+	)
+	pid, err := cmd.StartDetached()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { syscall.Kill(pid, syscall.SIGKILL) })
+
+	for i := 0; ; i++ {
+		if b, _ := os.ReadFile(name); string(b) == "ready\n" {
+			break
+		}
+		if i == 50 {
+			t.Fatal("expecting the command to write its output")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// The fields after the command name are the state, the parent
+	// PID, the group ID and the session ID.
+	if b, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat"); err == nil {
+		f := bytes.Fields(b[bytes.LastIndexByte(b, ')')+1:])
+		if string(f[3]) != strconv.Itoa(pid) {
+			t.Fatalf("expecting session %d, got %s", pid, f[3])
+		}
+	}
+
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	if !alive(pid) {
+		t.Fatal("expecting the command to outlive its context")
+	}
+
+	if _, err := cmd.StartDetached(); err == nil {
+		t.Fatal("expecting error starting twice")
+	}
+
+	t.Run("not a file", func(t *testing.T) {
+		cmd := exex.Command("/bin/sh", "-c", "true")
+		cmd.Stdout = new(bytes.Buffer)
+		if _, err := cmd.StartDetached(); err == nil {
+			t.Fatal("expecting error")
+		}
+	})
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package exex
+
+import "syscall"
+
+// setDetached makes the command run in a new session.
+func (c *Cmd) setDetached() error {
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// A session leader cannot change its process group.
+	c.SysProcAttr.Setsid = true
+	c.SysProcAttr.Setpgid = false
+	return nil
+}
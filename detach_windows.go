@@ -0,0 +1,16 @@
+package exex
+
+import "syscall"
+
+// detachedProcess is the DETACHED_PROCESS process creation flag.
+const detachedProcess = 0x00000008
+
+// setDetached makes the command run without a console in a new
+// process group.
+func (c *Cmd) setDetached() error {
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.CreationFlags |= detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP
+	return nil
+}
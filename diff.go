@@ -0,0 +1,262 @@
+package exex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Normalizer transforms the output of a command before it is compared
+// by Diff, e.g. to remove timestamps or absolute paths.
+type Normalizer func([]byte) []byte
+
+// Diff runs both commands concurrently and returns the unified diff,
+// with three lines of context, between their standard outputs after
+// applying the normalizers in order. An empty string means that both
+// normalized outputs are equal.
+//
+// If any of the commands fails, or ctx is done before they finish,
+// the commands are killed and the error is returned.
+func Diff(ctx context.Context, a, b *Cmd, normalizers ...Normalizer) (string, error) {
+	cmds := [2]*Cmd{a, b}
+	var outs [2]bytes.Buffer
+
+	for _, c := range cmds {
+		if c.Stdout != nil {
+			return "", errors.New("exex: Stdout already set")
+		}
+	}
+	for i, c := range cmds {
+		c.Stdout = &outs[i]
+	}
+
+	// Both commands are started before waiting for them, so that they
+	// are never killed while being started.
+	if err := a.Start(); err != nil {
+		return "", err
+	}
+	if err := b.Start(); err != nil {
+		a.abort(err)
+		a.Wait()
+		return "", err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	stop := context.AfterFunc(ctx, func() {
+		a.abort(ctx.Err())
+		b.abort(ctx.Err())
+	})
+
+	for i, c := range cmds {
+		wg.Add(1)
+		go func(i int, c *Cmd) {
+			defer wg.Done()
+			if err := c.Wait(); err != nil {
+				// Report only the first failure, as the other
+				// command is then killed.
+				once.Do(func() {
+					firstErr = err
+					cmds[1-i].abort(err)
+				})
+			}
+		}(i, c)
+	}
+
+	wg.Wait()
+	stop()
+
+	if firstErr != nil {
+		return "", firstErr
+	}
+
+	out := [2][]byte{outs[0].Bytes(), outs[1].Bytes()}
+	for _, n := range normalizers {
+		out[0], out[1] = n(out[0]), n(out[1])
+	}
+
+	return unifiedDiff(
+		strings.Join(RedactArgs(a.Args), " "),
+		strings.Join(RedactArgs(b.Args), " "),
+		splitLines(string(out[0])),
+		splitLines(string(out[1])),
+	), nil
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+type editOp byte
+
+const (
+	opEqual  editOp = ' '
+	opDelete editOp = '-'
+	opInsert editOp = '+'
+)
+
+type edit struct {
+	op   editOp
+	line string
+	a, b int // line indexes in a and b before this edit
+}
+
+// diffLines returns the shortest edit script transforming a into b
+// using the Myers algorithm.
+func diffLines(a, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	off := max + 1
+	v := make([]int, 2*max+3)
+
+	var trace [][]int
+	var x, y int
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y = x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var edits []edit
+	x, y = n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var pk int
+		if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+			pk = k + 1
+		} else {
+			pk = k - 1
+		}
+		px := v[off+pk]
+		py := px - pk
+
+		for x > px && y > py {
+			x--
+			y--
+			edits = append(edits, edit{op: opEqual, line: a[x], a: x, b: y})
+		}
+		if d > 0 {
+			if x == px {
+				edits = append(edits, edit{op: opInsert, line: b[py], a: px, b: py})
+			} else {
+				edits = append(edits, edit{op: opDelete, line: a[px], a: px, b: py})
+			}
+		}
+		x, y = px, py
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+
+	return edits
+}
+
+// unifiedDiff returns the unified diff between a and b, or an empty
+// string if they are equal.
+func unifiedDiff(nameA, nameB string, a, b []string) string {
+	const context = 3
+
+	edits := diffLines(a, b)
+
+	var sb strings.Builder
+	for i := 0; i < len(edits); {
+		// Find the next change.
+		for i < len(edits) && edits[i].op == opEqual {
+			i++
+		}
+		if i == len(edits) {
+			break
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+
+		// Extend the hunk while changes are close enough.
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].op != opEqual {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		hunkEnd := end + context
+		if hunkEnd > len(edits) {
+			hunkEnd = len(edits)
+		}
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", nameA, nameB)
+		}
+
+		var countA, countB int
+		for _, e := range edits[start:hunkEnd] {
+			if e.op != opInsert {
+				countA++
+			}
+			if e.op != opDelete {
+				countB++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n",
+			hunkRange(edits[start].a, countA), hunkRange(edits[start].b, countB))
+
+		for _, e := range edits[start:hunkEnd] {
+			sb.WriteByte(byte(e.op))
+			sb.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		i = hunkEnd
+	}
+
+	return sb.String()
+}
+
+// hunkRange formats the range of a hunk starting at the 0-based line
+// index start.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
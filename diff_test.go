@@ -0,0 +1,117 @@
+package exex_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+func printCmd(s string) *exex.Cmd {
+	return exex.Command(os.Args[0], strconv.Quote(s)).Apply(exex.WithEnv("TEST_MAIN=print"))
+}
+
+func TestDiff(t *testing.T) {
+	ctx := context.Background()
+	name := os.Args[0] + " "
+
+	tests := []struct {
+		name string
+		a, b string
+		exp  string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"empty", "", "", ""},
+		{
+			"changed",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"1\n2\n3\nfour\n5\n6\n7\n8\n9\n10\n11\n12\n13\n",
+			"@@ -1,7 +1,7 @@\n 1\n 2\n 3\n-4\n+four\n 5\n 6\n 7\n" +
+				"@@ -10,3 +10,4 @@\n 10\n 11\n 12\n+13\n",
+		},
+		{
+			"no newline",
+			"a\nb",
+			"a\nc\n",
+			"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n",
+		},
+		{"added", "", "a\n", "@@ -0,0 +1 @@\n+a\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := printCmd(tt.a), printCmd(tt.b)
+			got, err := exex.Diff(ctx, a, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			exp := tt.exp
+			if exp != "" {
+				exp = "--- " + name + strconv.Quote(tt.a) + "\n+++ " + name + strconv.Quote(tt.b) + "\n" + exp
+			}
+			if got != exp {
+				t.Fatalf("expecting:\n%s\ngot:\n%s", exp, got)
+			}
+		})
+	}
+}
+
+func TestDiffNormalizers(t *testing.T) {
+	stripDates := func(b []byte) []byte { return bytes.ReplaceAll(b, []byte("2024"), []byte("YYYY")) }
+	upper := func(b []byte) []byte { return bytes.ToUpper(b) }
+
+	got, err := exex.Diff(context.Background(),
+		printCmd("built on 2024\n"),
+		printCmd("BUILT ON YYYY\n"),
+		stripDates, upper,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Fatalf("expecting no differences, got:\n%s", got)
+	}
+}
+
+func TestDiffError(t *testing.T) {
+	hang := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=hang"))
+
+	start := time.Now()
+	_, err := exex.Diff(context.Background(), exex.Command(os.Args[0], "diff"), hang)
+	assertErr(t, err, "error: diff")
+
+	if time.Since(start) > 30*time.Second {
+		t.Fatal("expecting the other command to be killed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = exex.Diff(ctx,
+		exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=hang")),
+		exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=hang")),
+	)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expecting context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestDiffStdoutSet(t *testing.T) {
+	a := printCmd("a\n")
+	b := printCmd("b\n").Apply(exex.WithStdout(&bytes.Buffer{}))
+
+	if _, err := exex.Diff(context.Background(), a, b); err == nil {
+		t.Fatal("expecting error")
+	}
+	if a.Stdout != nil {
+		t.Fatal("expecting the first command not modified")
+	}
+	if out, err := a.Output(); err != nil || string(out) != "a\n" {
+		t.Fatalf("expecting the first command usable, got %q, %v", out, err)
+	}
+}
@@ -0,0 +1,50 @@
+package exex
+
+import "sync"
+
+// DryRun is a Runner that does not execute commands but records them,
+// to implement dry-run modes: starting and waiting for commands
+// always succeed, without output. It can be set for every command
+// with:
+//
+//	if *dryRun {
+//		exex.DefaultRunner = &exex.DryRun{}
+//	}
+//
+// Commands executed with LocalRunner explicitly are still executed.
+type DryRun struct {
+	// Hook, if not nil, is called with every command instead of
+	// starting it, e.g. to print it. If it returns an error, starting
+	// the command fails with it.
+	Hook func(c *Cmd) error
+
+	mu   sync.Mutex
+	cmds [][]string
+}
+
+// Start records the command and calls Hook, if any.
+func (r *DryRun) Start(c *Cmd) error {
+	if r.Hook != nil {
+		if err := r.Hook(c); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cmds = append(r.cmds, append([]string(nil), c.Args...))
+	return nil
+}
+
+// Wait always succeeds.
+func (r *DryRun) Wait(c *Cmd) error { return nil }
+
+// Commands returns the command lines of the commands that would have
+// been executed, in the order they were started.
+func (r *DryRun) Commands() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([][]string(nil), r.cmds...)
+}
@@ -0,0 +1,46 @@
+package exex_test
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestDryRun(t *testing.T) {
+	var hooked []string
+	dry := &exex.DryRun{Hook: func(c *exex.Cmd) error {
+		hooked = append(hooked, c.Args[1])
+		if c.Args[1] == "refused" {
+			return errRefused
+		}
+		return nil
+	}}
+
+	defer func(r exex.Runner) { exex.DefaultRunner = r }(exex.DefaultRunner)
+	exex.DefaultRunner = dry
+
+	// Would fail if executed.
+	if err := exex.Run(os.Args[0], "rm", "-rf", "/"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, err := exex.Command(os.Args[0], "output").Output()
+	if err != nil || len(out) != 0 {
+		t.Fatalf("expecting no output nor error, got %q and %v", out, err)
+	}
+
+	if err := exex.Run(os.Args[0], "refused"); !errors.Is(err, errRefused) {
+		t.Fatalf("expecting errRefused, got %v", err)
+	}
+
+	exp := [][]string{{os.Args[0], "rm", "-rf", "/"}, {os.Args[0], "output"}}
+	if got := dry.Commands(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expecting %q, got %q", exp, got)
+	}
+	if exp := []string{"rm", "output", "refused"}; !reflect.DeepEqual(hooked, exp) {
+		t.Fatalf("expecting hook called with %q, got %q", exp, hooked)
+	}
+}
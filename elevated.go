@@ -0,0 +1,108 @@
+package exex
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+// DoasCommand is the command, and its arguments, that Elevated
+// prefixes to a command when SudoCommand is not available. It must
+// not prompt for a password.
+var DoasCommand = []string{"doas", "-n"}
+
+// passwordMessages are substrings of the standard error of the
+// escalation tools failing because they require a password.
+var passwordMessages = [][]byte{
+	[]byte("a password is required"),
+	[]byte("Authentication required"),
+}
+
+// PasswordRequiredError is returned when running a command created by
+// Elevated fails because the escalation tool requires a password,
+// which it is not allowed to prompt for.
+type PasswordRequiredError struct {
+	// Tool is the name of the escalation tool, e.g. "sudo".
+	Tool string
+
+	// Err is the error of the command, usually a *CmdError.
+	Err error
+}
+
+func (e *PasswordRequiredError) Error() string {
+	return fmt.Sprintf("exex: %s requires a password: %v", e.Tool, e.Err)
+}
+
+func (e *PasswordRequiredError) Unwrap() error { return e.Err }
+
+// Elevated returns the Cmd struct to execute the named program with
+// elevated privileges, i.e. as root or Administrator. If the current
+// process already has them, the command is run as is; otherwise on
+// Unix systems it is prefixed with SudoCommand or, if sudo is not
+// available, DoasCommand, which run non-interactively, and if a
+// password is required the command fails with a
+// *PasswordRequiredError.
+//
+// If no escalation tool is found, the Err field of the command is
+// set, wrapping ErrNotFound. Windows offers no non-interactive
+// escalation tool, so there Err is an *UnsupportedFeatureError unless
+// the current process is elevated.
+func Elevated(name string, args ...string) *Cmd {
+	return elevated(nil, name, args...)
+}
+
+// ElevatedContext is like Elevated but the Cmd is associated with a
+// context.
+func ElevatedContext(ctx context.Context, name string, args ...string) *Cmd {
+	return elevated(ctx, name, args...)
+}
+
+// elevated returns the Cmd struct to execute the named program with
+// elevated privileges, associated with ctx if not nil.
+func elevated(ctx context.Context, name string, args ...string) *Cmd {
+	command := func(name string, args ...string) *Cmd {
+		if ctx == nil {
+			return Command(name, args...)
+		}
+		return CommandContext(ctx, name, args...)
+	}
+
+	if privileged() {
+		return command(name, args...)
+	}
+	if err := elevationUnsupported(); err != nil {
+		c := command(name, args...)
+		c.Err = err
+		return c
+	}
+
+	for _, tool := range [][]string{SudoCommand, DoasCommand} {
+		if len(tool) == 0 {
+			continue
+		}
+		path, err := LookPath(tool[0])
+		if err != nil {
+			continue
+		}
+		targs := append(append(tool[1:len(tool):len(tool)], name), args...)
+		c := command(path, targs...)
+		c.elevation = filepath.Base(tool[0])
+		return c
+	}
+
+	c := command(name, args...)
+	c.Err = fmt.Errorf("exex: no privilege escalation tool: %w", ErrNotFound)
+	return c
+}
+
+// passwordRequired reports whether stderr, the standard error of an
+// escalation tool, tells that a password is required.
+func passwordRequired(stderr []byte) bool {
+	for _, msg := range passwordMessages {
+		if bytes.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,7 @@
+//go:build !unix && !windows
+
+package exex
+
+func privileged() bool { return false }
+
+func elevationUnsupported() error { return unsupported("privilege escalation") }
@@ -0,0 +1,74 @@
+//go:build unix
+
+package exex_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestElevated(t *testing.T) {
+	if os.Geteuid() == 0 {
+		cmd := exex.Elevated("tool", "a")
+		if exp := []string{"tool", "a"}; !reflect.DeepEqual(cmd.Args, exp) {
+			t.Fatalf("expecting %q, got %q", exp, cmd.Args)
+		}
+		t.Skip("escalation tools are not used as root")
+	}
+
+	sudo, doas := exex.SudoCommand, exex.DoasCommand
+	defer func() { exex.SudoCommand, exex.DoasCommand = sudo, doas }()
+
+	// Fake escalation tools using the test binary, which prints its
+	// arguments to standard error and fails.
+	exex.SudoCommand = []string{os.Args[0], "-n"}
+	exex.DoasCommand = nil
+
+	t.Run("password required", func(t *testing.T) {
+		err := exex.Elevated(os.Args[0], "a password is required").Run()
+		var pwErr *exex.PasswordRequiredError
+		if !errors.As(err, &pwErr) {
+			t.Fatalf("expecting *PasswordRequiredError, got %v", err)
+		}
+		if exp := filepath.Base(os.Args[0]); pwErr.Tool != exp {
+			t.Fatalf("expecting tool %q, got %q", exp, pwErr.Tool)
+		}
+		var cmdErr *exex.CmdError
+		if !errors.As(err, &cmdErr) {
+			t.Fatalf("expecting *CmdError, got %v", err)
+		}
+	})
+
+	t.Run("other error", func(t *testing.T) {
+		err := exex.Elevated(os.Args[0], "foo").Run()
+		var pwErr *exex.PasswordRequiredError
+		if errors.As(err, &pwErr) {
+			t.Fatalf("not expecting *PasswordRequiredError, got %v", err)
+		}
+		assertErr(t, err, "error: -n "+os.Args[0]+" foo")
+	})
+
+	t.Run("doas", func(t *testing.T) {
+		exex.SudoCommand = []string{"exex-no-such-sudo"}
+		exex.DoasCommand = []string{os.Args[0], "-n"}
+
+		cmd := exex.Elevated("tool", "a")
+		if exp := []string{os.Args[0], "-n", "tool", "a"}; !reflect.DeepEqual(cmd.Args, exp) {
+			t.Fatalf("expecting %q, got %q", exp, cmd.Args)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		exex.SudoCommand = []string{"exex-no-such-sudo"}
+		exex.DoasCommand = []string{"exex-no-such-doas"}
+
+		if err := exex.Elevated("tool").Run(); !errors.Is(err, exex.ErrNotFound) {
+			t.Fatalf("expecting ErrNotFound, got %v", err)
+		}
+	})
+}
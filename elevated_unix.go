@@ -0,0 +1,10 @@
+//go:build unix
+
+package exex
+
+import "os"
+
+// privileged reports whether the current process runs as root.
+func privileged() bool { return os.Geteuid() == 0 }
+
+func elevationUnsupported() error { return nil }
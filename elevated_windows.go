@@ -0,0 +1,28 @@
+package exex
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// tokenElevation is the TokenElevation token information class.
+const tokenElevation = 20
+
+// privileged reports whether the current process is elevated.
+func privileged() bool {
+	p, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return false
+	}
+	var t syscall.Token
+	if err := syscall.OpenProcessToken(p, syscall.TOKEN_QUERY, &t); err != nil {
+		return false
+	}
+	defer t.Close()
+
+	var elevated, n uint32
+	err = syscall.GetTokenInformation(t, tokenElevation, (*byte)(unsafe.Pointer(&elevated)), uint32(unsafe.Sizeof(elevated)), &n)
+	return err == nil && elevated != 0
+}
+
+func elevationUnsupported() error { return unsupported("privilege escalation") }
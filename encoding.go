@@ -0,0 +1,54 @@
+package exex
+
+import "unicode/utf8"
+
+// Decoder converts text in a character encoding to UTF-8. The
+// decoders of golang.org/x/text/encoding, e.g. the one returned by
+// japanese.ShiftJIS.NewDecoder(), implement it.
+type Decoder interface {
+	Bytes(b []byte) ([]byte, error)
+}
+
+// Latin1 decodes ISO-8859-1 text.
+var Latin1 Decoder = charmap{}
+
+// Windows1252 decodes Windows-1252 text, the legacy code page of
+// Windows for western languages.
+var Windows1252 Decoder = charmap{high: &windows1252}
+
+// WithOutputEncoding converts the output captured by the command from
+// the encoding decoded by dec to UTF-8: the standard error reported in
+// CmdError.Stderr and ExitError.Stderr, and the output returned by
+// Output and CombinedOutput. It is useful with tools printing in the
+// legacy code page of the system, e.g. on Windows. The output given
+// to other writers, like Stdout or the ones set with TeeStderr, is not
+// converted, and the output is kept unmodified if dec fails.
+func WithOutputEncoding(dec Decoder) Option {
+	return func(c *Cmd) { c.decoder = dec }
+}
+
+// charmap is a single-byte encoding, matching Latin-1 except for the
+// bytes between 0x80 and 0x9F, mapped by high if not nil.
+type charmap struct {
+	high *[32]rune
+}
+
+func (m charmap) Bytes(b []byte) ([]byte, error) {
+	out := make([]byte, 0, len(b)+len(b)/4)
+	for _, c := range b {
+		r := rune(c)
+		if m.high != nil && c >= 0x80 && c < 0xA0 {
+			r = m.high[c-0x80]
+		}
+		out = utf8.AppendRune(out, r)
+	}
+	return out, nil
+}
+
+// windows1252 are the characters of Windows-1252 between 0x80 and
+// 0x9F, with the bytes not defined mapped to the C1 controls as
+// Windows does.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
@@ -0,0 +1,53 @@
+package exex_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestDecoders(t *testing.T) {
+	tests := map[string]struct {
+		dec exex.Decoder
+		in  string
+		exp string
+	}{
+		"latin1":      {dec: exex.Latin1, in: "caf\xe9 \x80", exp: "café \u0080"},
+		"windows1252": {dec: exex.Windows1252, in: "caf\xe9 \x80 \x93q\x94 \x81", exp: "café € “q” \u0081"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			out, err := tt.dec.Bytes([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.exp {
+				t.Fatalf("expecting %q, got %q", tt.exp, out)
+			}
+		})
+	}
+}
+
+type failingDecoder struct{}
+
+func (failingDecoder) Bytes([]byte) ([]byte, error) { return nil, errors.New("invalid") }
+
+func TestWithOutputEncoding(t *testing.T) {
+	const script = `printf 'caf\351\n'; printf 'fall\363\n' >&2; exit 1`
+
+	out, err := exex.Command("/bin/sh", "-c", script).Apply(exex.WithOutputEncoding(exex.Windows1252)).Output()
+	if string(out) != "café\n" {
+		t.Fatalf("expecting %q, got %q", "café\n", out)
+	}
+	var cmdErr *exex.CmdError
+	if !errors.As(err, &cmdErr) || string(cmdErr.Stderr) != "falló\n" {
+		t.Fatalf("expecting stderr %q, got %v", "falló\n", err)
+	}
+
+	out, _ = exex.Command("/bin/sh", "-c", script).Apply(exex.WithOutputEncoding(failingDecoder{})).Output()
+	if string(out) != "caf\xe9\n" {
+		t.Fatalf("expecting output unmodified, got %q", out)
+	}
+}
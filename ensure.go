@@ -0,0 +1,44 @@
+package exex
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Requirement describes an external program required by a program,
+// as checked by EnsureTools.
+type Requirement struct {
+	// Name is the name or path of the executable, as in Tool.
+	Name string
+
+	// MinVersion, if not empty, is the minimum version required,
+	// e.g. "2.30".
+	MinVersion string
+
+	// VersionArgs are the arguments used to get the version of the
+	// program. Defaults to "--version".
+	VersionArgs []string
+}
+
+// EnsureTools checks that the required programs are installed, and
+// that their versions are not older than MinVersion, concurrently. It
+// returns all the problems found joined with errors.Join, each of them
+// a *ToolError wrapping ErrNotFound, a *VersionError or the error
+// getting the version, or nil if none.
+func EnsureTools(ctx context.Context, reqs ...Requirement) error {
+	errs := make([]error, len(reqs))
+
+	var wg sync.WaitGroup
+	for i, r := range reqs {
+		wg.Add(1)
+		go func(i int, r Requirement) {
+			defer wg.Done()
+			t := &Tool{Name: r.Name, MinVersion: r.MinVersion, VersionArgs: r.VersionArgs}
+			_, errs[i] = t.Path(ctx)
+		}(i, r)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
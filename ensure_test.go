@@ -0,0 +1,48 @@
+package exex_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestEnsureTools(t *testing.T) {
+	t.Setenv("TEST_MAIN", "version")
+	ctx := context.Background()
+
+	err := exex.EnsureTools(ctx,
+		exex.Requirement{Name: os.Args[0]},
+		exex.Requirement{Name: os.Args[0], MinVersion: "1.2"},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = exex.EnsureTools(ctx,
+		exex.Requirement{Name: "exex-missing-tool"},
+		exex.Requirement{Name: os.Args[0], MinVersion: "1.2.3"},
+		exex.Requirement{Name: os.Args[0], MinVersion: "1.10"},
+	)
+	if !errors.Is(err, exex.ErrNotFound) {
+		t.Errorf("expecting ErrNotFound, got %v", err)
+	}
+
+	var vErr *exex.VersionError
+	if !errors.As(err, &vErr) {
+		t.Fatalf("expecting *exex.VersionError, got %v", err)
+	}
+	if vErr.Version != "1.2.3" || vErr.MinVersion != "1.10" {
+		t.Errorf("unexpected version error %+v", vErr)
+	}
+
+	var tErr *exex.ToolError
+	if !errors.As(err, &tErr) || tErr.Name != "exex-missing-tool" {
+		t.Errorf("expecting *exex.ToolError for the missing tool, got %v", err)
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 2 {
+		t.Errorf("expecting 2 errors, got %d: %v", n, err)
+	}
+}
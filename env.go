@@ -0,0 +1,95 @@
+package exex
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Env builds the environment of commands. The zero value is an empty
+// environment, ready to use. Variables are set, inherited or unset
+// in the order the methods are called, later calls overriding
+// earlier ones, and there is never more than one entry per variable.
+// As on the operating system, variable names are case-insensitive on
+// Windows.
+//
+//	var env exex.Env
+//	env.Inherit("HOME", "PATH").Set("LANG", "C")
+//	env.Apply(cmd)
+type Env struct {
+	vars map[string]envVar
+}
+
+type envVar struct {
+	key, value string
+}
+
+func envKey(key string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToUpper(key)
+	}
+	return key
+}
+
+// Set sets the variable key to value.
+func (e *Env) Set(key, value string) *Env {
+	if e.vars == nil {
+		e.vars = make(map[string]envVar)
+	}
+	e.vars[envKey(key)] = envVar{key, value}
+	return e
+}
+
+// Unset removes the variable key.
+func (e *Env) Unset(key string) *Env {
+	delete(e.vars, envKey(key))
+	return e
+}
+
+// Inherit sets the given variables to their values in the environment
+// of the current process, or all of its variables if no keys are
+// given. Variables missing in the current environment are left as
+// they are.
+func (e *Env) Inherit(keys ...string) *Env {
+	if len(keys) == 0 {
+		for _, kv := range os.Environ() {
+			// On Windows variables like "=C:" are valid.
+			if i := strings.IndexByte(kv[min(1, len(kv)):], '='); i >= 0 {
+				e.Set(kv[:i+1], kv[i+2:])
+			}
+		}
+		return e
+	}
+
+	for _, key := range keys {
+		if v, ok := os.LookupEnv(key); ok {
+			e.Set(key, v)
+		}
+	}
+	return e
+}
+
+// Merge sets the variables in vars.
+func (e *Env) Merge(vars map[string]string) *Env {
+	for k, v := range vars {
+		e.Set(k, v)
+	}
+	return e
+}
+
+// Environ returns the environment as "key=value" strings sorted by
+// key.
+func (e *Env) Environ() []string {
+	keys := sortedKeys(e.vars)
+	env := make([]string, len(keys))
+	for i, k := range keys {
+		v := e.vars[k]
+		env[i] = v.key + "=" + v.value
+	}
+	return env
+}
+
+// Apply replaces the environment of c with e.
+func (e *Env) Apply(c *Cmd) {
+	c.Env = e.Environ()
+}
@@ -0,0 +1,51 @@
+package exex_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestEnv(t *testing.T) {
+	t.Setenv("EXEX_INHERITED", "yes")
+	t.Setenv("EXEX_OTHER", "no")
+
+	var env exex.Env
+	env.Inherit("EXEX_INHERITED", "EXEX_MISSING").
+		Set("FOO", "bar").
+		Set("FOO", "baz").
+		Merge(map[string]string{"A1": "1", "A": "2", "GONE": "x"}).
+		Unset("GONE")
+
+	exp := []string{"A=2", "A1=1", "EXEX_INHERITED=yes", "FOO=baz"}
+	if got := env.Environ(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expecting %q, got %q", exp, got)
+	}
+
+	cmd := exex.Command(os.Args[0], "FOO")
+	env.Set("TEST_MAIN", "getenv").Apply(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "baz" {
+		t.Fatalf("expecting %q, got %q", "baz", out)
+	}
+}
+
+func TestEnv_InheritAll(t *testing.T) {
+	t.Setenv("EXEX_INHERITED", "yes")
+
+	var env exex.Env
+	got := env.Inherit().Environ()
+
+	var found bool
+	for _, kv := range got {
+		found = found || kv == "EXEX_INHERITED=yes"
+	}
+	if !found || len(got) != len(os.Environ()) {
+		t.Fatalf("expecting the whole environment, got %q", got)
+	}
+}
@@ -0,0 +1,166 @@
+package exex
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CmdError is the error returned by Cmd when the command it runs
+// fails, e.g. exits with a non-zero status. It records information
+// about the execution to help identify the failing command.
+//
+// Err is usually of type *exec.ExitError, so both errors.As and
+// errors.Is can be used to inspect it, though commands executed by
+// other Runners can report different types.
+type CmdError struct {
+	// Path is the path of the command that was run.
+	Path string
+
+	// Args holds the command line arguments, including the command
+	// as Args[0], with the values of those that look like secrets
+	// redacted.
+	Args []string
+
+	// Dir is the working directory of the command, if set.
+	Dir string
+
+	// Duration is the time elapsed since the command was started
+	// until it finished.
+	Duration time.Duration
+
+	// ExitCode is the exit code of the command, or -1 if it was
+	// terminated by a signal.
+	ExitCode int
+
+	// Stderr holds the captured standard error of the command, if
+	// any, as described in *Cmd.Run.
+	Stderr []byte
+
+	// Err is the underlying error.
+	Err error
+
+	redact func([]byte) []byte // set by the Cmd reporting the error
+}
+
+func (e *CmdError) Error() string {
+	var sb strings.Builder
+
+	sb.WriteString("exex: ")
+	for i, arg := range e.Args {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(quoteArg(arg))
+	}
+	if e.Dir != "" {
+		sb.WriteString(" (in ")
+		sb.WriteString(e.Dir)
+		sb.WriteByte(')')
+	}
+	fmt.Fprintf(&sb, ": %v", e.Err)
+	if desc := e.Description(); desc != "" {
+		fmt.Fprintf(&sb, " (%s)", desc)
+	}
+	fmt.Fprintf(&sb, " after %v", e.Duration.Round(time.Millisecond))
+
+	if e.redact != nil {
+		return string(e.redact([]byte(sb.String())))
+	}
+	return sb.String()
+}
+
+func (e *CmdError) Unwrap() error { return e.Err }
+
+// Description returns the meaning of the exit code of the command
+// according to ExitCodeDescriptions, or an empty string if unknown.
+func (e *CmdError) Description() string {
+	return describeExitCode(e.Path, e.ExitCode)
+}
+
+// Is reports whether target is an ExitCodeError matching the exit
+// code of the command.
+func (e *CmdError) Is(target error) bool {
+	code, ok := target.(ExitCodeError)
+	return ok && e.ExitCode >= 0 && e.ExitCode == int(code)
+}
+
+// ExitCodeError is an exit code that can be used as the target of
+// errors.Is to check whether a command exited with it, e.g.:
+//
+//	if errors.Is(err, exex.ExitCodeError(1)) {
+//		// grep found no matches
+//	}
+type ExitCodeError int
+
+func (e ExitCodeError) Error() string { return "exit status " + strconv.Itoa(int(e)) }
+
+// ExitCode returns the exit code of the command that caused err. It
+// reports false if err is nil, is not the result of a command exiting
+// or if the command was terminated by a signal.
+func ExitCode(err error) (int, bool) {
+	var ec exitCoder
+	if !errors.As(err, &ec) {
+		return 0, false
+	}
+	code := ec.ExitCode()
+	return code, code >= 0
+}
+
+// exitCoder is implemented by the errors of commands that exited,
+// such as *ExitError.
+type exitCoder interface {
+	ExitCode() int
+}
+
+func quoteArg(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\"'\\") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+const redacted = "xxxxx"
+
+var secretRe = regexp.MustCompile(`(?i)(password|passwd|passphrase|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credentials?)$`)
+
+// RedactArgs returns a copy of args where the values of the
+// arguments that look like secrets are redacted. These are flags or
+// variable assignments whose names contain words like password or
+// token, e.g. --password=foo, --token foo or API_TOKEN=foo, and URLs
+// with passwords.
+func RedactArgs(args []string) []string {
+	out := make([]string, len(args))
+	copy(out, args)
+
+	for i := 1; i < len(out); i++ {
+		arg := out[i]
+
+		if name, _, ok := strings.Cut(arg, "="); ok {
+			if secretRe.MatchString(name) {
+				out[i] = name + "=" + redacted
+				continue
+			}
+		} else if strings.HasPrefix(arg, "-") && secretRe.MatchString(arg) {
+			if i+1 < len(out) && !strings.HasPrefix(out[i+1], "-") {
+				i++
+				out[i] = redacted
+			}
+			continue
+		}
+
+		if strings.Contains(arg, "://") {
+			if u, err := url.Parse(arg); err == nil && u.User != nil {
+				if _, ok := u.User.Password(); ok {
+					out[i] = u.Redacted()
+				}
+			}
+		}
+	}
+
+	return out
+}
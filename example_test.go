@@ -78,3 +78,17 @@ func ExampleRunCommand() {
 		fmt.Printf("Expecting an *exec.ExitError, got %T: %[1]v\n", err)
 	}
 }
+
+func ExampleCmd_Apply() {
+	err := exex.Command("git", "status").Apply(
+		exex.WithDir("/tmp"),
+		exex.WithEnv("GIT_PAGER=cat"),
+	).Run()
+
+	var exErr *exec.ExitError
+	if errors.As(err, &exErr) {
+		fmt.Printf("Captured stderr: %q\n", exErr.Stderr)
+	} else {
+		fmt.Printf("Expecting an *exec.ExitError, got %T: %[1]v\n", err)
+	}
+}
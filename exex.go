@@ -23,11 +23,20 @@
 package exex
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
+	"os"
 	"os/exec"
+	"regexp"
+	"runtime/trace"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Cmd wraps exec.Cmd and represents an external command.
@@ -36,16 +45,81 @@ import (
 // for the first time.
 //
 // Refer to the exec.Cmd documentation for information on all the
-// functions this type provides except for Run, which is overwritten
-// by this struct.
-type Cmd exec.Cmd
+// fields and functions this type provides except for the ones
+// overwritten by this struct.
+//
+// An existing *exec.Cmd can be wrapped with &exex.Cmd{Cmd: cmd}.
+type Cmd struct {
+	*exec.Cmd
+
+	stderr       *capture      // captured standard error, if any
+	stderrLimit  *captureLimit // set by WithStderrLimit
+	stdinCmd     *Cmd          // command set by StdinFromCommand
+	stdin        *os.File      // read end of the pipe from stdinCmd
+	stderrTee    io.Writer     // set by TeeStderr
+	combined     *bytes.Buffer // output captured by CombinedOutput
+	stdinEOF     *eofReader    // wraps Stdin if not a file
+	orphans      *orphanCheck  // set by WithOrphanCheck
+	started      time.Time     // when the command was started
+	redirects    []redirect    // set by RedirectFD
+	mergeStderr  bool          // set by MergeStderrIntoStdout
+	ctx          context.Context
+	stopSignal   os.Signal     // set by WithGracefulStop
+	done         chan struct{} // closed once the command finished
+	doneOnce     sync.Once
+	idle         *idleWatch   // set by WithIdleTimeout
+	snapshot     *dirSnapshot // set by WithDirSnapshot
+	watch        *fsWatch     // set by WithWatch
+	runner       Runner       // set by WithRunner
+	middlewares  []Middleware // set by WithMiddleware
+	active       Runner       // runner wrapped with middlewares
+	artifacts    *artifacts   // set by WithArtifacts
+	running      bool         // whether the Runner started the command
+	quiet        bool         // set by WithQuiet
+	auditTags    []string     // set by WithAuditTag
+	logger       *slog.Logger // set by WithLogger
+	runOnce      string       // set by WithRunOnce
+	backpressure Backpressure // set by WithBackpressure
+	dropped      atomic.Int64 // lines dropped by BackpressureDrop
+	traceCtx     context.Context
+	traceTask    *trace.Task
+	result       *Result
+	secrets      []*regexp.Regexp // set by RedactSecrets
+	sanitize     *Sanitize        // set by WithSanitize
+	captureCfg   *CaptureConfig   // set by WithCaptureConfig
+	heuristics   *heuristics      // set by WithHeuristics
+	split        bufio.SplitFunc  // set by WithSplit
+	degradation  Degradation      // set by WithDegradation
+	unsupported  []error          // features skipped by DegradeWarn
+	sysroot      string           // set by WithSysroot
+	tempDir      *tempTracking    // set by WithTempTracking
+	group        bool             // set by WithProcessGroup
+	stripANSI    bool             // set by WithStripANSI
+	decoder      Decoder          // set by WithOutputEncoding
+	job          *jobObject       // set by WithJobObject
+	credential   *credential      // set by WithUser and WithCredential
+	elevation    string           // escalation tool set by Elevated
+	sandbox      *fsSandbox       // set by WithFilesystemSandbox
+	rlimits      []rlimit         // set by WithRLimit
+	nice         *int             // set by WithNice
+	ioPriority   *ioPriority      // set by WithIOClass
+	spill        *int             // set by WithSpill
+	outputLimit  *outputLimit     // set by WithMaxOutput and WithTruncatedOutput
+	stdoutSpool  *Spool           // standard output captured by WithSpill
+	stderrSpool  *Spool           // standard error captured by WithSpill
+	pty          *ptyState        // set by WithPTY
+	recording    *recording       // set by WithPTYRecording
+
+	mu       sync.Mutex
+	abortErr error // reason why the package killed the command
+}
 
 // Command returns the Cmd struct to execute the named program with
 // the given arguments.
 //
 // Refer to the exec.Command documentation for additional information.
 func Command(name string, args ...string) *Cmd {
-	return (*Cmd)(exec.Command(name, args...))
+	return &Cmd{Cmd: exec.Command(name, args...)}
 }
 
 // CommandContext is like Command but the Cmd is associated with a
@@ -53,7 +127,7 @@ func Command(name string, args ...string) *Cmd {
 //
 // Refer to the exec.Command documentation for additional information.
 func CommandContext(ctx context.Context, name string, args ...string) *Cmd {
-	return (*Cmd)(exec.CommandContext(ctx, name, args...))
+	return &Cmd{Cmd: exec.CommandContext(ctx, name, args...), ctx: ctx}
 }
 
 // Run starts the command and waits for it to end.
@@ -61,84 +135,331 @@ func CommandContext(ctx context.Context, name string, args ...string) *Cmd {
 // If the command executes successfully (e.g. exits with a zero
 // status), it returns nil.
 //
-// If the command fails to execute, the error will be of type
-// *exec.ExitError and it's always guaranteed that its Stderr property
-// will have the contexts of the standard error stream, unless
-// *Cmd.Stderr is specified.
+// If the command fails to execute, the error will be of type *CmdError
+// wrapping an *exec.ExitError, and it's always guaranteed that the
+// Stderr property of the latter will have the contexts of the
+// standard error stream, unless *Cmd.Stderr is specified. The
+// captured contents can be bounded using StderrLimit or
+// WithStderrLimit.
 //
 // Refer to exec.Cmd.Run documentation for additional information.
 func (c *Cmd) Run() error {
-	var stderr *bytes.Buffer
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
 
-	if c.Stderr == nil {
-		stderr = bytes.NewBuffer(make([]byte, 0, 1024))
-		c.Stderr = stderr
+// Start starts the specified command but does not wait for it to
+// complete. The command is started by its Runner, which is
+// DefaultRunner unless set with WithRunner, wrapped with the
+// middlewares added with Use and WithMiddleware.
+func (c *Cmd) Start() error {
+	if err := c.before(); err != nil {
+		return err
 	}
 
-	err := (*exec.Cmd)(c).Run()
+	if c.spill != nil {
+		c.startSpill()
+	}
+	if c.Stderr == nil && !c.mergeStderr {
+		c.captureStderr()
+	}
 
-	var exErr *exec.ExitError
+	c.watchStdin()
+	c.watchHeuristics()
+	c.watchIdle()
+	c.watchOutputLimit()
+	c.traceStart()
 
-	if stderr != nil && errors.As(err, &exErr) {
-		exErr.Stderr = stderr.Bytes()
-		return exErr
+	c.active = c.chain()
+	err := c.traceRegion("start", func() error {
+		return c.startTracked(func() error {
+			return c.startSandboxed(func() error { return c.active.Start(c) })
+		})
+	})
+	c.logStart(err)
+	if err != nil {
+		return c.after(err)
 	}
+	c.running = true
 
-	return err
-}
-
-// Start starts the specified command but does not wait for it to
-// complete.
-func (c *Cmd) Start() error {
-	if c.Stderr == nil {
-		c.Stderr = bytes.NewBuffer(make([]byte, 0, 1024))
+	if c.pty != nil && c.pty.slave != nil {
+		c.pty.start()
+	}
+	if c.job != nil {
+		if err := c.startJob(); err != nil {
+			c.active.Wait(c)
+			return c.after(err)
+		}
+	}
+	if len(c.rlimits) > 0 {
+		if err := c.startRLimits(); err != nil {
+			c.active.Wait(c)
+			return c.after(err)
+		}
+	}
+	if c.nice != nil || c.ioPriority != nil {
+		if err := c.startPriority(); err != nil {
+			c.active.Wait(c)
+			return c.after(err)
+		}
+	}
+	c.startIdleTimer()
+	if c.watch != nil {
+		c.watch.poll()
 	}
-	return (*exec.Cmd)(c).Start()
+
+	return nil
 }
 
 // Wait waits for the command to exit and waits for any copying to
 // stdin or copying from stdout or stderr to complete.
 func (c *Cmd) Wait() error {
-	err := (*exec.Cmd)(c).Wait()
+	if c.active == nil {
+		return errors.New("exec: not started")
+	}
+	return c.after(c.traceRegion("wait", func() error { return c.active.Wait(c) }))
+}
 
-	var exErr *exec.ExitError
+// Output runs the command and returns its standard output. Any
+// returned error will usually be of type *CmdError wrapping an
+// *ExitError. If c.Stderr was nil, Output populates ExitError.Stderr
+// with the whole standard error, unlike exec.Cmd.Output which
+// truncates it, unless bounded using StderrLimit or WithStderrLimit.
+func (c *Cmd) Output() ([]byte, error) {
+	if c.mergeStderr {
+		return c.CombinedOutput()
+	}
+	if c.Stdout != nil {
+		return nil, errors.New("exex: Stdout already set")
+	}
+
+	var stdout bytes.Buffer
+	c.Stdout = &stdout
+	err := c.Run()
+
+	return c.captured(stdout.Bytes()), err
+}
 
-	if stderr, ok := c.Stderr.(*bytes.Buffer); ok && errors.As(err, &exErr) {
-		exErr.Stderr = stderr.Bytes()
-		return exErr
+// CombinedOutput runs the command and returns its combined standard
+// output and standard error. If the command fails, the Stderr field of
+// the returned *CmdError and its *ExitError hold the combined output,
+// as both streams are written to the same pipe to preserve their
+// order.
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("exex: Stdout already set")
+	}
+	if c.Stderr != nil && !c.mergeStderr {
+		return nil, errors.New("exex: Stderr already set")
 	}
 
-	return err
+	var b bytes.Buffer
+	c.Stdout = &b
+	c.combined = &b
+	c.mergeStderr = true
+	err := c.Run()
+
+	return c.captured(b.Bytes()), err
 }
 
-// Output runs the command and returns its standard output. Any
-// returned error will usually be of type *ExitError. If c.Stderr was
-// nil, Output populates ExitError.Stderr.
-func (c *Cmd) Output() ([]byte, error) { return (*exec.Cmd)(c).Output() }
+// before prepares the command right before it is started.
+func (c *Cmd) before() error {
+	if err := c.checkPolicy(); err != nil {
+		return err
+	}
+	if errs := c.checkSanitize(); len(errs) > 0 {
+		return errs[0]
+	}
+	if err := c.resolveSysroot(); err != nil {
+		return err
+	}
+	if err := c.checkRunOnce(); err != nil {
+		return err
+	}
+	if err := c.applyRedirects(); err != nil {
+		return err
+	}
+	if c.snapshot != nil {
+		if err := c.snapshot.take(c.Dir); err != nil {
+			return err
+		}
+	}
+	if c.watch != nil {
+		if err := c.watch.start(c.Dir); err != nil {
+			return fmt.Errorf("exex: watch: %w", err)
+		}
+	}
+	if c.orphans != nil {
+		if err := c.degrade(c.setProcessGroup()); err != nil {
+			return err
+		}
+	}
+	if c.group {
+		if err := c.degrade(c.setNewGroup()); err != nil {
+			return err
+		}
+	}
+	if len(c.rlimits) > 0 {
+		if err := c.degrade(checkRLimits(c.rlimits)); err != nil {
+			return err
+		}
+	}
+	if c.nice != nil {
+		if err := c.degrade(c.setPriorityClass()); err != nil {
+			return err
+		}
+	}
+	if c.ioPriority != nil {
+		if err := c.degrade(checkIOPriority(*c.ioPriority)); err != nil {
+			return err
+		}
+	}
+	if c.credential != nil {
+		if err := c.degrade(c.setCredential()); err != nil {
+			return err
+		}
+	}
+	if c.pty != nil {
+		if err := c.degrade(c.pty.setup(c)); err != nil {
+			return err
+		}
+	}
+	if c.job != nil {
+		if err := c.degrade(c.job.create()); err != nil {
+			c.closePTY()
+			return err
+		}
+	}
+	if c.tempDir != nil {
+		if err := c.tempDir.setup(c); err != nil {
+			c.closePTY()
+			c.closeJob()
+			return err
+		}
+	}
+	if err := c.startStdinCmd(); err != nil {
+		if c.tempDir != nil {
+			c.tempDir.cleanup(nil)
+		}
+		c.closePTY()
+		c.closeJob()
+		return err
+	}
+	c.done = make(chan struct{})
+	c.started = time.Now()
+	return nil
+}
 
-// CombinedOutput runs the command and returns its combined standard
-// output and standard error.
-func (c *Cmd) CombinedOutput() ([]byte, error) { return (*exec.Cmd)(c).CombinedOutput() }
+// after finishes the execution of the command given the error
+// returned by starting or waiting for it, and returns the error that
+// must be reported to the caller.
+func (c *Cmd) after(err error) error {
+	if c.done != nil {
+		c.doneOnce.Do(func() { close(c.done) })
+	}
 
-// StderrPipe returns a pipe that will be connected to the command's
-// standard error when the command starts.
-func (c *Cmd) StderrPipe() (io.ReadCloser, error) { return (*exec.Cmd)(c).StderrPipe() }
+	c.stopIdleTimer()
+	c.untrack()
+	c.closePTY()
+	c.closeJob()
 
-// StdinPipe returns a pipe that will be connected to the command's
-// standard input when the command starts.
-func (c *Cmd) StdinPipe() (io.WriteCloser, error) { return (*exec.Cmd)(c).StdinPipe() }
+	held := c.stdioHeld(err)
+	if held {
+		err = nil
+	}
 
-// StdoutPipe returns a pipe that will be connected to the command's
-// standard output when the command starts.
-func (c *Cmd) StdoutPipe() (io.ReadCloser, error) { return (*exec.Cmd)(c).StdoutPipe() }
+	if rerr := c.setResult(err); err == nil {
+		err = rerr
+	}
+	if held && c.result != nil {
+		c.result.StdioHeld = true
+	}
+	if c.tempDir != nil {
+		if terr := c.tempDir.cleanup(c.result); err == nil {
+			err = terr
+		}
+	}
+	if rerr := c.recordingErr(); err == nil {
+		err = rerr
+	}
+	err = c.checkOrphans(err)
+	err = c.checkOutputLimit(err)
+	if err == nil {
+		err = c.markRan()
+	}
+
+	// Inspect the error of the command before wrapping it with the
+	// abort reason, which can be the error of another command.
+	var (
+		ec    exitCoder
+		exErr *exec.ExitError
+	)
+	exited := errors.As(err, &ec)
+	if exited {
+		errors.As(err, &exErr)
+	}
+
+	c.mu.Lock()
+	if c.abortErr != nil && err != nil {
+		err = fmt.Errorf("%w (%w)", c.abortErr, err)
+	}
+	c.mu.Unlock()
+
+	if exited {
+		var stderr []byte
+		if c.stderr != nil {
+			stderr = c.redact(c.captured(c.stderr.Bytes()))
+		} else if c.combined != nil {
+			stderr = c.redact(c.captured(bytes.Clone(c.combined.Bytes())))
+		}
+		if exErr != nil {
+			exErr.Stderr = stderr
+		}
+
+		err = &CmdError{
+			Path:     c.Path,
+			Args:     RedactArgs(c.Args),
+			Dir:      c.Dir,
+			Duration: c.Duration(),
+			ExitCode: ec.ExitCode(),
+			Stderr:   stderr,
+			Err:      err,
+			redact:   c.redact,
+		}
+		if c.elevation != "" && passwordRequired(stderr) {
+			err = &PasswordRequiredError{Tool: c.elevation, Err: err}
+		}
+	}
+
+	err = c.waitStdinCmd(err)
+	if err == nil && c.stderr != nil && c.stderr.pooled {
+		c.stderr.release()
+		c.stderr = nil
+	}
+	c.traceEnd(err)
+	c.logFinish(err)
 
-// String returns a human-readable description of c
-func (c *Cmd) String() string { return (*exec.Cmd)(c).String() }
+	return err
+}
+
+// abort kills the command, making Wait report reason as the cause of
+// its failure. Only the first reason is kept.
+func (c *Cmd) abort(reason error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.abortErr == nil {
+		c.abortErr = reason
+		c.kill()
+	}
+}
 
-// RunCommand wraps an *exec.Cmd into a Cmd and returns the result of
-// calling *Cmd.Run.
-func RunCommand(cmd *exec.Cmd) error {
-	return (*Cmd)(cmd).Run()
+// RunCommand wraps an *exec.Cmd into a Cmd, applies the given
+// options and returns the result of calling *Cmd.Run.
+func RunCommand(cmd *exec.Cmd, opts ...Option) error {
+	return (&Cmd{Cmd: cmd}).Apply(opts...).Run()
 }
 
 // Run creates a Cmd and returns the result of executing *Cmd.Run.
@@ -1,14 +1,22 @@
 package exex_test
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/inkel/exex"
 )
@@ -16,7 +24,136 @@ import (
 const stderrMessage = "Yup, I'm broken"
 
 func TestMain(m *testing.M) {
-	if o := os.Getenv("TEST_MAIN"); o != "" {
+	switch os.Getenv("TEST_MAIN") {
+	case "":
+	case "version":
+		fmt.Println("exex-test version 1.2.3")
+		os.Exit(0)
+	case "echo":
+		fmt.Println(strings.Join(os.Args[1:], " "))
+		os.Exit(0)
+	case "yes":
+		for {
+			fmt.Println("y")
+		}
+	case "exit":
+		code, _ := strconv.Atoi(os.Args[1])
+		fmt.Fprint(os.Stderr, strings.Join(os.Args[2:], " "))
+		os.Exit(code)
+	case "print":
+		s, _ := strconv.Unquote(os.Args[1])
+		fmt.Print(s)
+		os.Exit(0)
+	case "streams":
+		fmt.Print(os.Args[1])
+		fmt.Fprint(os.Stderr, os.Args[2])
+		code, _ := strconv.Atoi(os.Args[3])
+		os.Exit(code)
+	case "trap":
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGTERM)
+		fmt.Println("ready")
+		<-sigs
+		fmt.Fprint(os.Stderr, "terminated")
+		os.Exit(3)
+	case "tick":
+		for i := 0; i < 5; i++ {
+			fmt.Println("tick")
+			time.Sleep(20 * time.Millisecond)
+		}
+		os.Exit(0)
+	case "hang":
+		signal.Ignore(syscall.SIGTERM)
+		fmt.Println("ready")
+		fmt.Fprint(os.Stderr, strings.Join(os.Args[1:], " "))
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	case "fd3":
+		os.NewFile(3, "fd3").WriteString(strings.Join(os.Args[1:], " "))
+		os.Exit(0)
+	case "cat":
+		io.Copy(os.Stdout, os.Stdin)
+		os.Exit(0)
+	case "pwd":
+		wd, _ := os.Getwd()
+		fmt.Print(wd)
+		os.Exit(0)
+	case "files":
+		// Each argument is either name=contents, to write a file,
+		// -name, to remove it, or @duration, to sleep.
+		for _, arg := range os.Args[1:] {
+			if d, ok := strings.CutPrefix(arg, "@"); ok {
+				d, _ := time.ParseDuration(d)
+				time.Sleep(d)
+			} else if name, ok := strings.CutPrefix(arg, "-"); ok {
+				os.Remove(name)
+			} else {
+				name, contents, _ := strings.Cut(arg, "=")
+				os.WriteFile(name, []byte(contents), 0o644)
+			}
+		}
+		os.Exit(0)
+	case "temp":
+		// Write each name=contents argument to a file in the
+		// temporary directory.
+		for _, arg := range os.Args[1:] {
+			name, contents, _ := strings.Cut(arg, "=")
+			name = filepath.Join(os.TempDir(), name)
+			os.MkdirAll(filepath.Dir(name), 0o755)
+			os.WriteFile(name, []byte(contents), 0o644)
+		}
+		fmt.Print(os.TempDir())
+		os.Exit(0)
+	case "plugin":
+		// Echo the messages of the host, after negotiating one of
+		// the comma-separated versions in the first argument, or
+		// fail after the handshake.
+		var versions []int
+		for _, v := range strings.Split(os.Args[1], ",") {
+			n, _ := strconv.Atoi(v)
+			versions = append(versions, n)
+		}
+		p, err := exex.ServePlugin(os.Stdin, os.Stdout, versions...)
+		if err != nil {
+			fmt.Fprint(os.Stderr, err)
+			os.Exit(3)
+		}
+		if len(os.Args) > 2 {
+			fmt.Fprint(os.Stderr, os.Args[2])
+			os.Exit(2)
+		}
+		for {
+			var v any
+			if err := p.Receive(&v); err != nil {
+				break
+			}
+			p.Send(map[string]any{"version": p.Version, "echo": v})
+		}
+		os.Exit(0)
+	case "plugin-hang":
+		// Complete the handshake, unless there are arguments, and
+		// stop answering.
+		bufio.NewReader(os.Stdin).ReadString('\n')
+		if len(os.Args) == 1 {
+			fmt.Println(`{"type":"hello","version":1}`)
+		}
+		time.Sleep(time.Hour)
+	case "daemon":
+		// Leave a process holding the standard streams, printing its
+		// PID so that it can be killed.
+		cmd := exec.Command(os.Args[0])
+		cmd.Env = []string{"TEST_MAIN=hang"}
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Start()
+		fmt.Println(cmd.Process.Pid)
+		os.Exit(0)
+	case "reaper":
+		os.Exit(testReaper())
+	case "getenv":
+		fmt.Print(os.Getenv(os.Args[1]))
+		os.Exit(0)
+	default:
 		fmt.Fprint(os.Stderr, "error:")
 		for _, m := range os.Args[1:] {
 			fmt.Fprint(os.Stderr, " ", m)
@@ -108,8 +245,8 @@ func TestRunCommand(t *testing.T) {
 			t.Fatal("expecting error")
 		}
 
-		exErr, ok := err.(*exec.ExitError)
-		if !ok {
+		var exErr *exec.ExitError
+		if !errors.As(err, &exErr) {
 			t.Fatalf("expecting *exec.ExitError, got %T", err)
 		}
 		if exErr.Stderr != nil {
@@ -145,7 +282,7 @@ func benchmarkCaptureStderrExex(b *testing.B) {
 		cmd := exec.Command(os.Args[0])
 		cmd.Env = []string{"TEST_MAIN=error"}
 		err := exex.RunCommand(cmd)
-		exErr = err.(*exec.ExitError)
+		errors.As(err, &exErr)
 	}
 
 	Stderr = exErr.Stderr
@@ -167,7 +304,7 @@ func BenchmarkRun(b *testing.B) {
 	var exErr *exec.ExitError
 
 	for i := 0; i < b.N; i++ {
-		exErr = exex.Run(os.Args[0]).(*exec.ExitError)
+		errors.As(exex.Run(os.Args[0]), &exErr)
 	}
 
 	Stderr = exErr.Stderr
@@ -179,7 +316,7 @@ func BenchmarkRunContext(b *testing.B) {
 	var exErr *exec.ExitError
 
 	for i := 0; i < b.N; i++ {
-		exErr = exex.RunContext(ctx, os.Args[0]).(*exec.ExitError)
+		errors.As(exex.RunContext(ctx, os.Args[0]), &exErr)
 	}
 
 	Stderr = exErr.Stderr
@@ -189,7 +326,7 @@ func BenchmarkRunCommand(b *testing.B) {
 	var exErr *exec.ExitError
 
 	for i := 0; i < b.N; i++ {
-		exErr = exex.RunCommand(exec.Command(os.Args[0])).(*exec.ExitError)
+		errors.As(exex.RunCommand(exec.Command(os.Args[0])), &exErr)
 	}
 
 	Stderr = exErr.Stderr
@@ -210,8 +347,8 @@ func TestCmd_Run(t *testing.T) {
 			t.Fatal("expecting error")
 		}
 
-		exErr, ok := err.(*exec.ExitError)
-		if !ok {
+		var exErr *exec.ExitError
+		if !errors.As(err, &exErr) {
 			t.Fatalf("expecting *exec.ExitError, got %T", err)
 		}
 		if exErr.Stderr != nil {
@@ -225,6 +362,32 @@ func TestCmd_Run(t *testing.T) {
 	})
 }
 
+func TestCmd_Output(t *testing.T) {
+	// exec.Cmd.Output keeps only the first and last 32KB of stderr.
+	msg := strings.Repeat("x", 100<<10)
+
+	out, err := exex.Command(os.Args[0], "2", msg).Apply(exex.WithEnv("TEST_MAIN=exit")).Output()
+	assertErr(t, err, msg)
+
+	if len(out) != 0 {
+		t.Fatalf("expecting no output, got %q", out)
+	}
+}
+
+func TestCmd_CombinedOutput(t *testing.T) {
+	out, err := exex.Command(os.Args[0], "combined").CombinedOutput()
+	assertErr(t, err, "error: combined")
+
+	if exp := "error: combined"; string(out) != exp {
+		t.Fatalf("expecting %q, got %q", exp, out)
+	}
+
+	var cmdErr *exex.CmdError
+	if !errors.As(err, &cmdErr) || string(cmdErr.Stderr) != "error: combined" {
+		t.Fatalf("expecting the combined output in CmdError.Stderr, got %v", err)
+	}
+}
+
 func TestLookPathNotFound(t *testing.T) {
 	nonExistingPath := "foobarbazquux"
 
@@ -0,0 +1,255 @@
+// Package exextest provides utilities for testing code that executes
+// commands using exex.
+//
+// FakeRunner is an exex.Runner that, instead of executing commands,
+// serves canned results for the invocations expected by the test:
+//
+//	f := &exextest.FakeRunner{}
+//	f.Expect("git", "rev-parse", "HEAD").Stdout("0123abcd\n")
+//	f.Expect("git", "push").Stderr("permission denied").ExitCode(128)
+//
+//	exex.DefaultRunner = f // or exex.WithRunner(f)
+//
+//	// exercise the code under test
+//
+//	if err := f.Verify(); err != nil {
+//		t.Fatal(err)
+//	}
+package exextest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/inkel/exex"
+)
+
+// FakeRunner is an exex.Runner that serves canned results for the
+// expected invocations instead of executing them. Invocations that
+// do not match any expectation fail to start with an error wrapping
+// ErrUnexpected.
+//
+// The zero value is ready to use, and it is safe for concurrent use.
+type FakeRunner struct {
+	// Lenient, if true, matches invocations against expectations in
+	// any order and allows each expectation to be used any number of
+	// times. Otherwise, invocations must happen in the order they
+	// were expected, each expectation being used exactly once.
+	Lenient bool
+
+	mu       sync.Mutex
+	expected []*Expectation
+	next     int
+	calls    []Call
+	running  map[*exex.Cmd]*Expectation
+}
+
+// ErrUnexpected is the error wrapped by the errors returned when a
+// FakeRunner is asked to run a command that was not expected.
+var ErrUnexpected = errors.New("exextest: unexpected command")
+
+// Call records an invocation received by a FakeRunner.
+type Call struct {
+	// Args holds the command line arguments, including the command
+	// as Args[0].
+	Args []string
+
+	// Dir is the working directory of the command.
+	Dir string
+
+	// Env is the environment of the command.
+	Env []string
+
+	// Stdin holds what the command read from its standard input.
+	Stdin []byte
+}
+
+// Expectation is an invocation expected by a FakeRunner along with
+// the result it produces. Its methods return the Expectation to allow
+// chaining them.
+type Expectation struct {
+	args     []string
+	stdout   string
+	stderr   string
+	exitCode int
+	err      error
+	uses     int
+}
+
+// ExitError is the error returned by FakeRunner for commands expected
+// to exit with a non-zero status. exex reports it as a *exex.CmdError
+// with the same exit code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string { return "exit status " + strconv.Itoa(e.Code) }
+
+// ExitCode returns the exit code of the command.
+func (e *ExitError) ExitCode() int { return e.Code }
+
+// Expect registers an expected invocation of the named command with
+// the given arguments. By default the command succeeds without any
+// output.
+func (f *FakeRunner) Expect(name string, args ...string) *Expectation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e := &Expectation{args: append([]string{name}, args...)}
+	f.expected = append(f.expected, e)
+
+	return e
+}
+
+// Stdout sets what the command writes to its standard output.
+func (e *Expectation) Stdout(s string) *Expectation {
+	e.stdout = s
+	return e
+}
+
+// Stderr sets what the command writes to its standard error.
+func (e *Expectation) Stderr(s string) *Expectation {
+	e.stderr = s
+	return e
+}
+
+// ExitCode sets the exit code of the command.
+func (e *Expectation) ExitCode(code int) *Expectation {
+	e.exitCode = code
+	return e
+}
+
+// StartError makes the command fail to start with err, e.g.
+// exex.ErrNotFound.
+func (e *Expectation) StartError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+func (e *Expectation) matches(args []string) bool {
+	return equalArgs(args, e.args)
+}
+
+func (e *Expectation) String() string { return strings.Join(e.args, " ") }
+
+// Start implements exex.Runner.
+func (f *FakeRunner) Start(c *exex.Cmd) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e := f.match(c.Args)
+	if e == nil {
+		return fmt.Errorf("%w: %s", ErrUnexpected, strings.Join(c.Args, " "))
+	}
+	e.uses++
+
+	if e.err != nil {
+		return e.err
+	}
+
+	if f.running == nil {
+		f.running = make(map[*exex.Cmd]*Expectation)
+	}
+	f.running[c] = e
+
+	return nil
+}
+
+// match returns the expectation matching args, if any.
+func (f *FakeRunner) match(args []string) *Expectation {
+	if !f.Lenient {
+		if f.next < len(f.expected) && f.expected[f.next].matches(args) {
+			f.next++
+			return f.expected[f.next-1]
+		}
+		return nil
+	}
+
+	for _, e := range f.expected {
+		if e.matches(args) {
+			return e
+		}
+	}
+	return nil
+}
+
+// Wait implements exex.Runner. It consumes the standard input of the
+// command and writes the canned output.
+func (f *FakeRunner) Wait(c *exex.Cmd) error {
+	f.mu.Lock()
+	e, ok := f.running[c]
+	delete(f.running, c)
+	f.mu.Unlock()
+
+	if !ok {
+		return errors.New("exextest: command not started")
+	}
+
+	call := Call{
+		Args: append([]string(nil), c.Args...),
+		Dir:  c.Dir,
+		Env:  append([]string(nil), c.Env...),
+	}
+
+	if c.Stdin != nil {
+		stdin, err := io.ReadAll(c.Stdin)
+		if err != nil {
+			return err
+		}
+		call.Stdin = stdin
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	f.mu.Unlock()
+
+	if c.Stdout != nil && e.stdout != "" {
+		if _, err := io.WriteString(c.Stdout, e.stdout); err != nil {
+			return err
+		}
+	}
+	if c.Stderr != nil && e.stderr != "" {
+		if _, err := io.WriteString(c.Stderr, e.stderr); err != nil {
+			return err
+		}
+	}
+
+	if e.exitCode != 0 {
+		return &ExitError{Code: e.exitCode}
+	}
+
+	return nil
+}
+
+// Calls returns the invocations the runner has received, in the
+// order they finished.
+func (f *FakeRunner) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Call(nil), f.calls...)
+}
+
+// Verify returns an error listing the expected invocations that were
+// not received.
+func (f *FakeRunner) Verify() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var missing []string
+	for _, e := range f.expected {
+		if e.uses == 0 {
+			missing = append(missing, strconv.Quote(e.String()))
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("exextest: expected commands not run: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
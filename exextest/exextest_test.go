@@ -0,0 +1,133 @@
+package exextest_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+	"github.com/inkel/exex/exextest"
+)
+
+func TestFakeRunner(t *testing.T) {
+	f := &exextest.FakeRunner{}
+	f.Expect("git", "rev-parse", "HEAD").Stdout("0123abcd\n")
+	f.Expect("git", "push").Stderr("permission denied").ExitCode(128)
+
+	out, err := exex.Command("git", "rev-parse", "HEAD").Apply(exex.WithRunner(f)).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "0123abcd\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+
+	err = exex.Command("git", "push").Apply(exex.WithRunner(f)).Run()
+
+	var cmdErr *exex.CmdError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("expecting *exex.CmdError, got %v", err)
+	}
+	if cmdErr.ExitCode != 128 {
+		t.Errorf("expecting exit code 128, got %d", cmdErr.ExitCode)
+	}
+	if string(cmdErr.Stderr) != "permission denied" {
+		t.Errorf("unexpected stderr %q", cmdErr.Stderr)
+	}
+	if !errors.Is(err, exex.ExitCodeError(128)) {
+		t.Errorf("expecting error to match exit code 128")
+	}
+
+	if err := f.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFakeRunnerStrict(t *testing.T) {
+	f := &exextest.FakeRunner{}
+	f.Expect("first")
+	f.Expect("second")
+
+	err := exex.Command("second").Apply(exex.WithRunner(f)).Run()
+	if !errors.Is(err, exextest.ErrUnexpected) {
+		t.Fatalf("expecting exextest.ErrUnexpected, got %v", err)
+	}
+
+	if err := exex.Command("first").Apply(exex.WithRunner(f)).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := exex.Command("first").Apply(exex.WithRunner(f)).Run(); !errors.Is(err, exextest.ErrUnexpected) {
+		t.Fatalf("expecting exextest.ErrUnexpected, got %v", err)
+	}
+
+	err = f.Verify()
+	if err == nil || !strings.Contains(err.Error(), `"second"`) {
+		t.Fatalf("expecting missing second command, got %v", err)
+	}
+}
+
+func TestFakeRunnerLenient(t *testing.T) {
+	f := &exextest.FakeRunner{Lenient: true}
+	f.Expect("first")
+	f.Expect("second")
+
+	for _, name := range []string{"second", "first", "second"} {
+		if err := exex.Command(name).Apply(exex.WithRunner(f)).Run(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	if err := f.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(f.Calls()); n != 3 {
+		t.Fatalf("expecting 3 calls, got %d", n)
+	}
+}
+
+func TestFakeRunnerStartError(t *testing.T) {
+	f := &exextest.FakeRunner{}
+	f.Expect("missing").StartError(exex.ErrNotFound)
+
+	err := exex.Command("missing").Apply(exex.WithRunner(f)).Run()
+	if !errors.Is(err, exex.ErrNotFound) {
+		t.Fatalf("expecting exex.ErrNotFound, got %v", err)
+	}
+}
+
+func TestFakeRunnerCalls(t *testing.T) {
+	f := &exextest.FakeRunner{}
+	f.Expect("cat").Stdout("out ").Stderr("err").ExitCode(1)
+
+	out, err := exex.Command("cat").Apply(
+		exex.WithRunner(f),
+		exex.WithDir("/tmp"),
+		exex.WithStdin(strings.NewReader("input")),
+	).CombinedOutput()
+	if code, ok := exex.ExitCode(err); !ok || code != 1 {
+		t.Fatalf("expecting exit code 1, got %v", err)
+	}
+	if string(out) != "out err" {
+		t.Fatalf("unexpected combined output %q", out)
+	}
+
+	calls := f.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("expecting a single call, got %d", len(calls))
+	}
+	if c := calls[0]; c.Dir != "/tmp" || string(c.Stdin) != "input" {
+		t.Fatalf("unexpected call %+v", c)
+	}
+}
+
+func TestFakeRunnerResult(t *testing.T) {
+	f := &exextest.FakeRunner{}
+	f.Expect("false").ExitCode(1)
+
+	cmd := exex.Command("false").Apply(exex.WithRunner(f))
+	cmd.Run()
+
+	if r := cmd.Result(); r == nil || r.ExitCode != 1 {
+		t.Fatalf("unexpected result %+v", r)
+	}
+}
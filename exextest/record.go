@@ -0,0 +1,340 @@
+package exextest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/inkel/exex"
+)
+
+// Invocation is a command execution recorded by a Recorder and
+// served back by a Replayer.
+type Invocation struct {
+	// Args holds the command line arguments, including the command
+	// as Args[0].
+	Args []string `json:"args"`
+
+	// Env holds the values of the environment variables selected by
+	// Recorder.Env, keyed by name. Variables not set are omitted.
+	Env map[string]string `json:"env,omitempty"`
+
+	// StdinSHA256 is the hex encoded SHA-256 hash of the standard
+	// input read by the command, or empty if it had none.
+	StdinSHA256 string `json:"stdin_sha256,omitempty"`
+
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// fixture is the format of the files written by Recorder.Save.
+type fixture struct {
+	Invocations []Invocation `json:"invocations"`
+}
+
+// Recorder is an exex.Runner that records every invocation executed
+// by another Runner, so they can be saved to a fixture file and later
+// served by a Replayer.
+//
+// It is safe for concurrent use.
+type Recorder struct {
+	// Runner executes the commands. If nil, exex.LocalRunner is used.
+	Runner exex.Runner
+
+	// Env lists the names of the environment variables that are
+	// recorded, and that must match when replaying. Other variables
+	// are ignored, so that fixtures do not depend on the machine
+	// where they were recorded nor store secrets.
+	Env []string
+
+	mu          sync.Mutex
+	invocations []Invocation
+	running     map[*exex.Cmd]*recording
+}
+
+type recording struct {
+	stdout, stderr bytes.Buffer
+	stdin          hash.Hash
+}
+
+// Start implements exex.Runner.
+func (r *Recorder) Start(c *exex.Cmd) error {
+	rec := &recording{}
+
+	if c.Stdin != nil {
+		rec.stdin = sha256.New()
+		c.Stdin = io.TeeReader(c.Stdin, rec.stdin)
+	}
+	if sameWriter(c.Stdout, c.Stderr) {
+		// Keep sharing the writer, as the command can write to both
+		// streams concurrently. Both are recorded as its output.
+		c.Stdout = teeWriter(c.Stdout, &rec.stdout)
+		c.Stderr = c.Stdout
+	} else {
+		c.Stdout = teeWriter(c.Stdout, &rec.stdout)
+		c.Stderr = teeWriter(c.Stderr, &rec.stderr)
+	}
+
+	if err := r.runner().Start(c); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	if r.running == nil {
+		r.running = make(map[*exex.Cmd]*recording)
+	}
+	r.running[c] = rec
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Wait implements exex.Runner.
+func (r *Recorder) Wait(c *exex.Cmd) error {
+	err := r.runner().Wait(c)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.running[c]
+	if !ok {
+		return err
+	}
+	delete(r.running, c)
+
+	inv := Invocation{
+		Args:   append([]string(nil), c.Args...),
+		Env:    selectEnv(c.Env, r.Env),
+		Stdout: rec.stdout.String(),
+		Stderr: rec.stderr.String(),
+	}
+	if rec.stdin != nil {
+		inv.StdinSHA256 = hex.EncodeToString(rec.stdin.Sum(nil))
+	}
+	if err != nil {
+		inv.ExitCode = -1
+		if code, ok := exex.ExitCode(err); ok {
+			inv.ExitCode = code
+		}
+	}
+	r.invocations = append(r.invocations, inv)
+
+	return err
+}
+
+func (r *Recorder) runner() exex.Runner {
+	if r.Runner != nil {
+		return r.Runner
+	}
+	return exex.LocalRunner{}
+}
+
+// Invocations returns the invocations recorded so far, in the order
+// they finished.
+func (r *Recorder) Invocations() []Invocation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Invocation(nil), r.invocations...)
+}
+
+// Save writes the recorded invocations to the named fixture file.
+func (r *Recorder) Save(name string) error {
+	b, err := json.MarshalIndent(fixture{Invocations: r.Invocations()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(b, '\n'), 0o644)
+}
+
+// Replayer is an exex.Runner that serves the invocations recorded in
+// a fixture file without executing anything. Each recorded invocation
+// is served once, in the order they were recorded when several match
+// the same command. Commands that do not match any unused recorded
+// invocation fail to start with an error wrapping ErrUnexpected.
+//
+// It is safe for concurrent use.
+type Replayer struct {
+	mu          sync.Mutex
+	invocations []Invocation
+	used        []bool
+	running     map[*exex.Cmd]*Invocation
+}
+
+// LoadReplayer returns a Replayer serving the invocations recorded in
+// the named fixture file.
+func LoadReplayer(name string) (*Replayer, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var f fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("exextest: parsing %s: %w", name, err)
+	}
+
+	return NewReplayer(f.Invocations), nil
+}
+
+// NewReplayer returns a Replayer serving the given invocations.
+func NewReplayer(invocations []Invocation) *Replayer {
+	return &Replayer{
+		invocations: invocations,
+		used:        make([]bool, len(invocations)),
+		running:     make(map[*exex.Cmd]*Invocation),
+	}
+}
+
+// Start implements exex.Runner.
+func (r *Replayer) Start(c *exex.Cmd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.invocations {
+		inv := &r.invocations[i]
+		if r.used[i] || !equalArgs(inv.Args, c.Args) || !matchEnv(c.Env, inv.Env) {
+			continue
+		}
+		r.used[i] = true
+		r.running[c] = inv
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnexpected, strings.Join(c.Args, " "))
+}
+
+// Wait implements exex.Runner. It consumes the standard input of the
+// command, checking it matches the recorded one, and writes the
+// recorded output.
+func (r *Replayer) Wait(c *exex.Cmd) error {
+	r.mu.Lock()
+	inv, ok := r.running[c]
+	delete(r.running, c)
+	r.mu.Unlock()
+
+	if !ok {
+		return errors.New("exextest: command not started")
+	}
+
+	var sum string
+	if c.Stdin != nil {
+		h := sha256.New()
+		if _, err := io.Copy(h, c.Stdin); err != nil {
+			return err
+		}
+		sum = hex.EncodeToString(h.Sum(nil))
+	}
+	if sum != inv.StdinSHA256 {
+		return fmt.Errorf("%w: %s: standard input does not match the recorded one", ErrUnexpected, strings.Join(c.Args, " "))
+	}
+
+	if c.Stdout != nil {
+		if _, err := io.WriteString(c.Stdout, inv.Stdout); err != nil {
+			return err
+		}
+	}
+	if c.Stderr != nil {
+		if _, err := io.WriteString(c.Stderr, inv.Stderr); err != nil {
+			return err
+		}
+	}
+
+	if inv.ExitCode != 0 {
+		return &ExitError{Code: inv.ExitCode}
+	}
+
+	return nil
+}
+
+// teeWriter returns a writer duplicating its writes to w, if not nil,
+// and rec.
+func teeWriter(w io.Writer, rec io.Writer) io.Writer {
+	if w == nil {
+		return rec
+	}
+	return io.MultiWriter(w, rec)
+}
+
+// sameWriter reports whether a and b are the same non-nil writer,
+// guarding against writers whose type is not comparable.
+func sameWriter(a, b io.Writer) (same bool) {
+	if a == nil || b == nil {
+		return false
+	}
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
+
+// selectEnv returns the values of the named variables in env, or in
+// the environment of the current process if env is nil, as the
+// command would see them.
+func selectEnv(env []string, names []string) map[string]string {
+	if len(names) == 0 {
+		return nil
+	}
+	if env == nil {
+		env = os.Environ()
+	}
+
+	m := make(map[string]string)
+	for _, name := range names {
+		// Later values take precedence, as in exec.Cmd.
+		for _, kv := range env {
+			if k, v, ok := strings.Cut(kv, "="); ok && k == name {
+				m[name] = v
+			}
+		}
+	}
+	if len(m) == 0 {
+		return nil
+	}
+
+	return m
+}
+
+// matchEnv reports whether the given environment has the recorded
+// values for the recorded variables.
+func matchEnv(env []string, recorded map[string]string) bool {
+	if len(recorded) == 0 {
+		return true
+	}
+
+	names := make([]string, 0, len(recorded))
+	for name := range recorded {
+		names = append(names, name)
+	}
+	got := selectEnv(env, names)
+
+	for name, v := range recorded {
+		if got[name] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func equalArgs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package exextest_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+	"github.com/inkel/exex/exextest"
+)
+
+func TestRecordReplay(t *testing.T) {
+	fake := &exextest.FakeRunner{}
+	fake.Expect("tool", "build").Stdout("built\n")
+	fake.Expect("tool", "lint").Stderr("bad style").ExitCode(2)
+
+	rec := &exextest.Recorder{Runner: fake, Env: []string{"MODE"}}
+
+	out, err := exex.Command("tool", "build").Apply(
+		exex.WithRunner(rec),
+		exex.WithEnv("MODE=release", "SECRET=hunter2"),
+		exex.WithStdin(strings.NewReader("input")),
+	).Output()
+	if err != nil || string(out) != "built\n" {
+		t.Fatalf("unexpected result %q, %v", out, err)
+	}
+
+	err = exex.Command("tool", "lint").Apply(exex.WithRunner(rec)).Run()
+	if code, _ := exex.ExitCode(err); code != 2 {
+		t.Fatalf("expecting exit code 2, got %v", err)
+	}
+
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+	if err := rec.Save(fixture); err != nil {
+		t.Fatal(err)
+	}
+
+	invs := rec.Invocations()
+	if len(invs) != 2 {
+		t.Fatalf("expecting 2 invocations, got %d", len(invs))
+	}
+	if env := invs[0].Env; len(env) != 1 || env["MODE"] != "release" {
+		t.Errorf("unexpected recorded environment %v", env)
+	}
+
+	rep, err := exextest.LoadReplayer(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = exex.Command("tool", "build").Apply(
+		exex.WithRunner(rep),
+		exex.WithEnv("MODE=release", "SECRET=other"),
+		exex.WithStdin(strings.NewReader("input")),
+	).Output()
+	if err != nil || string(out) != "built\n" {
+		t.Fatalf("unexpected replayed result %q, %v", out, err)
+	}
+
+	err = exex.Command("tool", "lint").Apply(exex.WithRunner(rep)).Run()
+	var cmdErr *exex.CmdError
+	if !errors.As(err, &cmdErr) || cmdErr.ExitCode != 2 || string(cmdErr.Stderr) != "bad style" {
+		t.Fatalf("unexpected replayed error %v", err)
+	}
+
+	// Each invocation is served once.
+	err = exex.Command("tool", "lint").Apply(exex.WithRunner(rep)).Run()
+	if !errors.Is(err, exextest.ErrUnexpected) {
+		t.Fatalf("expecting exextest.ErrUnexpected, got %v", err)
+	}
+}
+
+func TestReplayerMismatch(t *testing.T) {
+	rep := exextest.NewReplayer([]exextest.Invocation{
+		{Args: []string{"tool"}, Env: map[string]string{"MODE": "release"}},
+		{Args: []string{"cat"}, StdinSHA256: strings.Repeat("0", 64)},
+	})
+
+	err := exex.Command("tool").Apply(exex.WithRunner(rep), exex.WithEnv("MODE=debug")).Run()
+	if !errors.Is(err, exextest.ErrUnexpected) {
+		t.Fatalf("expecting exextest.ErrUnexpected for environment, got %v", err)
+	}
+
+	err = exex.Command("cat").Apply(exex.WithRunner(rep), exex.WithStdin(strings.NewReader("x"))).Run()
+	if !errors.Is(err, exextest.ErrUnexpected) {
+		t.Fatalf("expecting exextest.ErrUnexpected for stdin, got %v", err)
+	}
+}
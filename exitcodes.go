@@ -0,0 +1,89 @@
+package exex
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ExitCodeDescriptions maps the name of well-known tools to the
+// meaning of their exit codes. When a command fails, the description
+// of its exit code, if any, is included in the message of its
+// CmdError.
+//
+// Entries can be added for other tools, or the whole map set to nil
+// to disable descriptions, but only before running any command, as
+// the map is not safe for concurrent modification.
+var ExitCodeDescriptions = map[string]map[int]string{
+	"grep": {
+		1: "no lines were selected",
+		2: "an error occurred",
+	},
+	"diff": {
+		1: "inputs differ",
+		2: "trouble",
+	},
+	"cmp": {
+		1: "inputs differ",
+		2: "trouble",
+	},
+	"rsync": {
+		1:  "syntax or usage error",
+		2:  "protocol incompatibility",
+		3:  "errors selecting input/output files or directories",
+		5:  "error starting client-server protocol",
+		10: "error in socket I/O",
+		11: "error in file I/O",
+		12: "error in rsync protocol data stream",
+		20: "received SIGUSR1 or SIGINT",
+		23: "partial transfer due to error",
+		24: "partial transfer due to vanished source files",
+		30: "timeout in data send/receive",
+		35: "timeout waiting for daemon connection",
+	},
+	"curl": {
+		6:  "could not resolve host",
+		7:  "failed to connect to host",
+		22: "HTTP error returned",
+		28: "operation timed out",
+		35: "SSL connect error",
+		60: "peer certificate cannot be authenticated",
+	},
+	"wget": {
+		4: "network failure",
+		5: "SSL verification failure",
+		6: "authentication failure",
+		8: "server issued an error response",
+	},
+	"ssh": {
+		255: "connection or protocol error",
+	},
+	"git": {
+		128: "fatal error",
+		129: "invalid usage",
+	},
+	"systemctl": {
+		3: "unit is not active",
+		4: "no such unit",
+	},
+	"timeout": {
+		124: "command timed out",
+		125: "timeout failed",
+		126: "command found but cannot be invoked",
+		127: "command not found",
+	},
+	"sh": {
+		126: "command found but cannot be invoked",
+		127: "command not found",
+	},
+	"bash": {
+		126: "command found but cannot be invoked",
+		127: "command not found",
+	},
+}
+
+// describeExitCode returns the description in ExitCodeDescriptions
+// of the exit code of the tool at path.
+func describeExitCode(path string, code int) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".exe")
+	return ExitCodeDescriptions[name][code]
+}
@@ -0,0 +1,193 @@
+package exex
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ErrExpectTimeout is the cause of the failure of an expectation that
+// was not met before the timeout of the Session.
+var ErrExpectTimeout = errors.New("exex: expect timeout")
+
+// ExpectError is returned by Session.Expect when the output of the
+// command does not match the expected pattern.
+type ExpectError struct {
+	// Pattern is the expected pattern.
+	Pattern string
+
+	// Output is the output of the command not consumed yet, redacted
+	// as described in RedactSecrets.
+	Output []byte
+
+	// Err is ErrExpectTimeout or io.EOF, if the command finished.
+	Err error
+}
+
+func (e *ExpectError) Error() string {
+	return fmt.Sprintf("exex: expecting %q: %v", e.Pattern, e.Err)
+}
+
+func (e *ExpectError) Unwrap() error { return e.Err }
+
+// Session interacts with a command started by Interact, answering the
+// prompts in its output, like expect(1):
+//
+//	s, err := exex.Command("installer").Apply(exex.WithPTY(24, 80)).Interact()
+//	if err != nil {
+//		return err
+//	}
+//	if _, err := s.Expect(regexp.MustCompile(`Continue\? \[y/N\]`)); err != nil {
+//		s.Close()
+//		return err
+//	}
+//	s.SendLine("y")
+//	return s.Close()
+type Session struct {
+	// Timeout is how long Expect waits for the output of the command
+	// to match. Defaults to 10 seconds, and zero or negative means no
+	// timeout.
+	Timeout time.Duration
+
+	cmd  *Cmd
+	w    *os.File // write end of the stdin pipe of cmd
+	mu   sync.Mutex
+	cond sync.Cond
+	buf  []byte // output not consumed yet
+	done bool   // whether the command finished
+	err  error  // error waiting for the command
+}
+
+// Interact starts the command and returns a Session to interact with
+// it through its standard input and output, which must not be set.
+// The standard error is captured as described in Run, unless merged
+// using MergeStderrIntoStdout. Commands that need a terminal can be
+// run with WithPTY, in which case the output includes the echo of the
+// input sent, and closing the session does not end the input of the
+// command, which must then exit on its own, e.g. after sending "\x04".
+func (c *Cmd) Interact() (*Session, error) {
+	if c.Stdin != nil {
+		return nil, errors.New("exex: Stdin already set")
+	}
+	if c.Stdout != nil {
+		return nil, errors.New("exex: Stdout already set")
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Session{Timeout: 10 * time.Second, cmd: c, w: w}
+	s.cond.L = &s.mu
+	c.Stdin, c.Stdout = r, s
+
+	if err := c.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return nil, err
+	}
+
+	go func() {
+		err := c.Wait()
+		// The input is copied from r to the terminal with WithPTY.
+		r.Close()
+
+		s.mu.Lock()
+		s.done, s.err = true, err
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	}()
+
+	return s, nil
+}
+
+// Write adds the output of the command.
+func (s *Session) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf = append(s.buf, p...)
+	s.cond.Broadcast()
+	return len(p), nil
+}
+
+// Expect waits for the output of the command to match re, consuming
+// it up to the end of the match, and returns the match and its
+// submatches. If the output does not match before the timeout or the
+// command finishes, it returns an *ExpectError.
+func (s *Session) Expect(re *regexp.Regexp) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired := false
+	if s.Timeout > 0 {
+		t := time.AfterFunc(s.Timeout, func() {
+			s.mu.Lock()
+			expired = true
+			s.cond.Broadcast()
+			s.mu.Unlock()
+		})
+		defer t.Stop()
+	}
+
+	for {
+		if loc := re.FindSubmatchIndex(s.buf); loc != nil {
+			m := make([]string, len(loc)/2)
+			for i := range m {
+				if loc[2*i] >= 0 {
+					m[i] = string(s.buf[loc[2*i]:loc[2*i+1]])
+				}
+			}
+			s.buf = append(s.buf[:0], s.buf[loc[1]:]...)
+			return m, nil
+		}
+
+		var err error
+		switch {
+		case expired:
+			err = ErrExpectTimeout
+		case s.done:
+			err = io.EOF
+		}
+		if err != nil {
+			return nil, &ExpectError{
+				Pattern: re.String(),
+				Output:  s.cmd.redact(append([]byte(nil), s.buf...)),
+				Err:     err,
+			}
+		}
+
+		s.cond.Wait()
+	}
+}
+
+// Send writes str to the standard input of the command.
+func (s *Session) Send(str string) error {
+	_, err := io.WriteString(s.w, str)
+	return err
+}
+
+// SendLine writes str followed by a newline to the standard input of
+// the command.
+func (s *Session) SendLine(str string) error {
+	return s.Send(str + "\n")
+}
+
+// Close closes the standard input of the command, waits for it to
+// finish, and returns the error of waiting for it, as Wait does.
+func (s *Session) Close() error {
+	s.w.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for !s.done {
+		s.cond.Wait()
+	}
+	return s.err
+}
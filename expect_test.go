@@ -0,0 +1,71 @@
+package exex_test
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+func TestInteract(t *testing.T) {
+	const script = `printf 'Name? '; read n; echo "hello $n"; read n`
+
+	tests := map[string][]exex.Option{
+		"pipe": nil,
+		"pty":  {exex.WithPTY(24, 80)},
+	}
+
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			if opts != nil && runtime.GOOS != "linux" {
+				t.Skip("pseudo-terminals require Linux")
+			}
+
+			s, err := exex.Command("/bin/sh", "-c", script).Apply(opts...).Interact()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := s.Expect(regexp.MustCompile(`Name\? `)); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SendLine("bob"); err != nil {
+				t.Fatal(err)
+			}
+			m, err := s.Expect(regexp.MustCompile(`hello (\w+)`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m[1] != "bob" {
+				t.Fatalf("expecting %q, got %q", "bob", m[1])
+			}
+
+			s.Timeout = 100 * time.Millisecond
+			_, err = s.Expect(regexp.MustCompile(`never`))
+			var expErr *exex.ExpectError
+			if !errors.As(err, &expErr) || !errors.Is(err, exex.ErrExpectTimeout) {
+				t.Fatalf("expecting timeout, got %v", err)
+			}
+
+			s.SendLine("")
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Expect(regexp.MustCompile(`never`)); !errors.Is(err, io.EOF) {
+				t.Fatalf("expecting EOF, got %v", err)
+			}
+		})
+	}
+
+	t.Run("stdout", func(t *testing.T) {
+		cmd := exex.Command("/bin/sh")
+		cmd.Stdout = io.Discard
+		if _, err := cmd.Interact(); err == nil {
+			t.Fatal("expecting error")
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package exex
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Explain returns a human-readable description of how the command
+// would be executed, e.g. for the --verbose or --explain modes of
+// tools: the resolved executable, working directory, changes to the
+// environment, streams, Runner, middlewares, limits and options.
+// Arguments and environment variables that look like secrets are
+// redacted as described in RedactArgs.
+//
+// The description is meant for humans and its format can change.
+func (c *Cmd) Explain() string {
+	var sb strings.Builder
+	c.explain(&sb, "")
+	return sb.String()
+}
+
+// ExplainPipeline returns a human-readable description of how
+// Pipeline would execute the given commands, as Explain does for
+// each of them.
+func ExplainPipeline(cmds ...*Cmd) string {
+	var sb strings.Builder
+	for i, c := range cmds {
+		fmt.Fprintf(&sb, "stage %d:\n", i+1)
+		c.explain(&sb, "  ")
+	}
+	return sb.String()
+}
+
+func (c *Cmd) explain(w io.Writer, indent string) {
+	line := func(format string, args ...any) {
+		fmt.Fprintf(w, indent+format+"\n", args...)
+	}
+
+	args := RedactArgs(c.Args)
+	for i, arg := range args {
+		args[i] = quoteArg(arg)
+	}
+	line("command: %s", strings.Join(args, " "))
+
+	if c.Err != nil {
+		line("path: %s (%v)", c.Path, c.Err)
+	} else {
+		line("path: %s", c.Path)
+	}
+
+	if c.Dir != "" {
+		line("dir: %s", c.Dir)
+	} else {
+		line("dir: current directory")
+	}
+
+	if c.Env == nil {
+		line("env: inherited")
+	} else if diff := envDiff(os.Environ(), c.Env); len(diff) == 0 {
+		line("env: unchanged")
+	} else {
+		line("env:")
+		for _, d := range diff {
+			line("  %s", d)
+		}
+	}
+
+	switch {
+	case c.stdinCmd != nil:
+		line("stdin: output of %s", strings.Join(RedactArgs(c.stdinCmd.Args), " "))
+	default:
+		line("stdin: %s", describeStream(c.Stdin, "null device"))
+	}
+	line("stdout: %s", describeStream(c.Stdout, "discarded"))
+	switch {
+	case c.mergeStderr:
+		line("stderr: merged into stdout")
+	case c.Stderr == nil && c.stderr == nil:
+		limit := c.stderrLimit
+		if limit == nil {
+			limit = &captureLimit{n: StderrLimit, mode: StderrMode}
+		}
+		if limit.n > 0 {
+			mode := "head"
+			if limit.mode == KeepTail {
+				mode = "tail"
+			}
+			line("stderr: captured, keeping the %s %d bytes", mode, limit.n)
+		} else {
+			line("stderr: captured")
+		}
+	default:
+		line("stderr: %s", describeStream(c.Stderr, "discarded"))
+	}
+	for _, r := range c.redirects {
+		line("fd %d: %s", r.fd, describeStream(r.f, "closed"))
+	}
+
+	switch r := c.runnerOrDefault().(type) {
+	case *Workspace:
+		line("runner: workspace at %s", r.Root)
+	default:
+		line("runner: %T", r)
+	}
+
+	for _, mw := range append(packageMiddlewares(), c.middlewares...) {
+		line("middleware: %s", funcName(mw))
+	}
+
+	if c.ctx != nil {
+		if d, ok := c.ctx.Deadline(); ok {
+			line("deadline: %s", d.Format(time.RFC3339))
+		}
+	}
+	if c.idle != nil {
+		line("idle timeout: %v", c.idle.timeout)
+	}
+	if c.stopSignal != nil {
+		line("graceful stop: %v, waiting %v", c.stopSignal, c.WaitDelay)
+	} else if c.WaitDelay > 0 {
+		line("wait delay: %v", c.WaitDelay)
+	}
+	if c.snapshot != nil {
+		line("dir snapshot: %s", strings.Join(c.snapshot.patterns, " "))
+	}
+	if c.watch != nil {
+		line("watch: %s", strings.Join(c.watch.paths, " "))
+	}
+	if c.artifacts != nil {
+		if c.artifacts.destDir != "" {
+			line("artifacts: %s, copied to %s", c.artifacts.pattern, c.artifacts.destDir)
+		} else {
+			line("artifacts: %s", c.artifacts.pattern)
+		}
+	}
+	if c.runOnce != "" {
+		line("run once: %s", c.runOnce)
+	}
+	if c.quiet {
+		line("quiet: true")
+	}
+	if len(c.auditTags) > 0 {
+		line("audit tags: %s", strings.Join(c.auditTags, " "))
+	}
+}
+
+// envDiff returns the variables of env added, changed or removed
+// compared to base, sorted by name, as "+ KEY=value", "~ KEY=value"
+// or "- KEY" respectively. Values that look like secrets are
+// redacted.
+func envDiff(base, env []string) []string {
+	toMap := func(kvs []string) map[string]string {
+		m := make(map[string]string, len(kvs))
+		for _, kv := range RedactArgs(append([]string{""}, kvs...))[1:] {
+			k, v, _ := strings.Cut(kv, "=")
+			m[k] = v
+		}
+		return m
+	}
+	before, after := toMap(base), toMap(env)
+
+	var diff []string
+	for k, v := range after {
+		old, ok := before[k]
+		switch {
+		case !ok:
+			diff = append(diff, "+ "+k+"="+v)
+		case old != v:
+			diff = append(diff, "~ "+k+"="+v)
+		}
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			diff = append(diff, "- "+k)
+		}
+	}
+
+	sort.Slice(diff, func(i, j int) bool { return diff[i][2:] < diff[j][2:] })
+
+	return diff
+}
+
+// describeStream describes where a standard stream of a command is
+// connected to, using none if it is not set.
+func describeStream(s any, none string) string {
+	switch s := s.(type) {
+	case nil:
+		return none
+	case *os.File:
+		if s == nil {
+			return none
+		}
+		return s.Name()
+	default:
+		if v := reflect.ValueOf(s); v.Kind() == reflect.Pointer && v.IsNil() {
+			return none
+		}
+		return fmt.Sprintf("%T", s)
+	}
+}
+
+// funcName returns the name of the function f, e.g. the function
+// returning a Middleware.
+func funcName(f any) string {
+	if fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return fmt.Sprintf("%T", f)
+}
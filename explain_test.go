@@ -0,0 +1,57 @@
+package exex_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+func TestCmd_Explain(t *testing.T) {
+	cmd := exex.Command("/bin/echo", "--token", "s3cr3t", "hello world").Apply(
+		exex.WithDir("/tmp"),
+		exex.WithEnv("FOO=bar", "API_TOKEN=s3cr3t"),
+		exex.MergeStderrIntoStdout(),
+		exex.WithIdleTimeout(time.Second),
+		exex.WithMiddleware(exex.Hooks{}.Middleware()),
+		exex.WithAuditTag("deploy"),
+	)
+
+	got := cmd.Explain()
+	if strings.Contains(got, "s3cr3t") {
+		t.Errorf("expecting secrets to be redacted, got:\n%s", got)
+	}
+
+	for _, exp := range []string{
+		`command: /bin/echo --token xxxxx "hello world"` + "\n",
+		"path: /bin/echo\n",
+		"dir: /tmp\n",
+		"  + API_TOKEN=xxxxx\n",
+		"  + FOO=bar\n",
+		"stdin: null device\n",
+		"stdout: discarded\n",
+		"stderr: merged into stdout\n",
+		"runner: exex.LocalRunner\n",
+		"middleware: github.com/inkel/exex.Hooks.Middleware.func1\n",
+		"idle timeout: 1s\n",
+		"audit tags: deploy\n",
+	} {
+		if !strings.Contains(got, exp) {
+			t.Errorf("expecting %q in:\n%s", exp, got)
+		}
+	}
+}
+
+func TestExplainPipeline(t *testing.T) {
+	got := exex.ExplainPipeline(exex.Command("/bin/ls"), exex.Command("/bin/wc", "-l"))
+
+	for _, exp := range []string{
+		"stage 1:\n  command: /bin/ls\n",
+		"stage 2:\n  command: /bin/wc -l\n",
+	} {
+		if !strings.Contains(got, exp) {
+			t.Errorf("expecting %q in:\n%s", exp, got)
+		}
+	}
+}
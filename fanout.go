@@ -0,0 +1,150 @@
+package exex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Host is a target of FanOut: a name, used to identify it in the
+// results and output, and the Runner executing commands on it, e.g. a
+// remote Runner.
+type Host struct {
+	Name   string
+	Runner Runner
+}
+
+// FanOutOption configures FanOut.
+type FanOutOption func(*fanOutConfig)
+
+type fanOutConfig struct {
+	limit  int
+	output io.Writer
+}
+
+// FanOutLimit runs the command on up to n hosts concurrently. Zero or
+// negative n, the default, means no limit.
+func FanOutLimit(n int) FanOutOption {
+	return func(f *fanOutConfig) { f.limit = n }
+}
+
+// FanOutOutput writes each line of the standard output and standard
+// error of every host to w, prefixed with the name of the host, as
+// soon as it is written. Lines of different hosts are not mixed.
+func FanOutOutput(w io.Writer) FanOutOption {
+	return func(f *fanOutConfig) { f.output = w }
+}
+
+// HostResult is the result of running a command on a Host with
+// FanOut.
+type HostResult struct {
+	// Host is the name of the host.
+	Host string
+
+	// Output is the standard output of the command.
+	Output []byte
+
+	// Result is the result of the command, or nil if it could not be
+	// started.
+	Result *Result
+
+	// Err is the error returned by running the command.
+	Err error
+}
+
+// FanOut runs the command on every host concurrently and returns their
+// results in the order of hosts. cmd is used as a template and is not
+// run itself: each host runs a copy of it associated with ctx, as
+// Bench does, using the Runner of the host. Its standard input, if
+// any, is read once and given to every host.
+//
+// The returned error joins the errors of the hosts that failed, each
+// one prefixed by the name of the host; the results are always
+// returned.
+func FanOut(ctx context.Context, hosts []Host, cmd *Cmd, opts ...FanOutOption) ([]HostResult, error) {
+	res := make([]HostResult, len(hosts))
+	err := fanOut(ctx, hosts, cmd, opts, func(i int, r HostResult) { res[i] = r })
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, r := range res {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Host, r.Err))
+		}
+	}
+
+	return res, errors.Join(errs...)
+}
+
+// fanOut runs the command on the hosts as FanOut does, calling done
+// with the index and result of each host as soon as it finishes,
+// possibly concurrently. It returns once all the hosts finished.
+func fanOut(ctx context.Context, hosts []Host, cmd *Cmd, opts []FanOutOption, done func(int, HostResult)) error {
+	var cfg fanOutConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.limit <= 0 || cfg.limit > len(hosts) {
+		cfg.limit = len(hosts)
+	}
+
+	var stdin []byte
+	if cmd.Stdin != nil {
+		var err error
+		if stdin, err = io.ReadAll(cmd.Stdin); err != nil {
+			return err
+		}
+	}
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, cfg.limit)
+		mux = NewLineMux(cfg.output)
+	)
+
+	for i, h := range hosts {
+		wg.Add(1)
+		go func(i int, h Host) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			c := cmd.clone(ctx).Apply(WithRunner(h.Runner))
+			if cmd.Stdin != nil {
+				c.Stdin = bytes.NewReader(stdin)
+			}
+
+			var stdout bytes.Buffer
+			c.Stdout = &stdout
+
+			var flush []*MuxWriter
+			if cfg.output != nil {
+				out, errOut := mux.Writer(h.Name+": "), mux.Writer(h.Name+": ")
+				c.Stdout = io.MultiWriter(&stdout, out)
+				c.TeeStderr(errOut)
+				flush = append(flush, out, errOut)
+			}
+
+			err := c.Run()
+			for _, lw := range flush {
+				lw.Flush()
+			}
+
+			done(i, HostResult{
+				Host:   h.Name,
+				Output: stdout.Bytes(),
+				Result: c.Result(),
+				Err:    err,
+			})
+		}(i, h)
+	}
+	wg.Wait()
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package exex_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestFanOut(t *testing.T) {
+	hosts := []exex.Host{
+		{Name: "one", Runner: exex.LocalRunner{}},
+		{Name: "refused", Runner: refusingRunner{}},
+		{Name: "two", Runner: exex.LocalRunner{}},
+	}
+
+	var out bytes.Buffer
+	cmd := exex.Command(os.Args[0], "hello").Apply(exex.WithEnv("TEST_MAIN=echo"))
+
+	res, err := exex.FanOut(context.Background(), hosts, cmd,
+		exex.FanOutLimit(2),
+		exex.FanOutOutput(&out),
+	)
+	if !errors.Is(err, errRefused) || !strings.Contains(err.Error(), "refused: ") {
+		t.Fatalf("expecting the error of the refused host, got %v", err)
+	}
+
+	if len(res) != len(hosts) {
+		t.Fatalf("expecting %d results, got %d", len(hosts), len(res))
+	}
+	for i, h := range hosts {
+		r := res[i]
+		if r.Host != h.Name {
+			t.Errorf("expecting host %q, got %q", h.Name, r.Host)
+		}
+		if h.Name == "refused" {
+			if r.Err == nil || r.Result != nil {
+				t.Errorf("expecting an error and no result, got %v and %v", r.Err, r.Result)
+			}
+			continue
+		}
+		if r.Err != nil || string(r.Output) != "hello\n" || r.Result.ExitCode != 0 {
+			t.Errorf("unexpected result for %s: %+v", h.Name, r)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	sort.Strings(lines)
+	if exp := []string{"one: hello", "two: hello"}; strings.Join(lines, ",") != strings.Join(exp, ",") {
+		t.Fatalf("expecting output %q, got %q", exp, lines)
+	}
+}
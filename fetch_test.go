@@ -0,0 +1,70 @@
+package exex_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func serveTestBinary(t *testing.T) (*httptest.Server, string, *int32) {
+	t.Helper()
+
+	bin, err := os.ReadFile(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(bin)
+
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write(bin)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, hex.EncodeToString(sum[:]), &hits
+}
+
+func TestFetchAndRun(t *testing.T) {
+	srv, sum, hits := serveTestBinary(t)
+	exex.FetchCacheDir = t.TempDir()
+	defer func() { exex.FetchCacheDir = "" }()
+
+	ctx := context.Background()
+
+	err := exex.FetchAndRun(ctx, srv.URL+"/tool", sum, "fetched")
+	assertErr(t, err, "error: fetched")
+
+	err = exex.FetchAndRun(ctx, srv.URL+"/tool", "sha256:"+sum, "cached")
+	assertErr(t, err, "error: cached")
+
+	if n := atomic.LoadInt32(hits); n != 1 {
+		t.Fatalf("expecting a single download, got %d", n)
+	}
+}
+
+func TestFetchAndRunChecksumMismatch(t *testing.T) {
+	srv, _, _ := serveTestBinary(t)
+	exex.FetchCacheDir = t.TempDir()
+	defer func() { exex.FetchCacheDir = "" }()
+
+	err := exex.FetchAndRun(context.Background(), srv.URL+"/tool", strings.Repeat("0", 64))
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expecting checksum mismatch error, got %v", err)
+	}
+}
+
+func TestFetchAndRunInvalidChecksum(t *testing.T) {
+	err := exex.FetchAndRun(context.Background(), "http://localhost/tool", "foo")
+	if err == nil || !strings.Contains(err.Error(), "invalid SHA-256 checksum") {
+		t.Fatalf("expecting invalid checksum error, got %v", err)
+	}
+}
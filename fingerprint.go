@@ -0,0 +1,43 @@
+package exex
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+)
+
+// EnvFingerprint returns the environment the command runs with, keyed
+// by variable name, with each value replaced by its hex encoded
+// HMAC-SHA256 using salt as the key. It allows recording, e.g. in
+// audit records, the configuration a command ran with without storing
+// secrets: the same value produces the same hash for a given salt, so
+// two fingerprints can be compared to tell which variables changed.
+//
+// If the Env field is nil the environment of the current process is
+// used, as the command would. Like exec.Cmd, when a variable appears
+// more than once the last value is the one used.
+func (c *Cmd) EnvFingerprint(salt []byte) map[string]string {
+	env := c.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	return envFingerprint(env, salt)
+}
+
+func envFingerprint(env []string, salt []byte) map[string]string {
+	fp := make(map[string]string, len(env))
+
+	for _, kv := range env {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		h := hmac.New(sha256.New, salt)
+		h.Write([]byte(value))
+		fp[name] = hex.EncodeToString(h.Sum(nil))
+	}
+
+	return fp
+}
@@ -0,0 +1,46 @@
+package exex_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestEnvFingerprint(t *testing.T) {
+	salt := []byte("salt")
+
+	cmd := exex.Command(os.Args[0])
+	cmd.Env = []string{"TOKEN=hunter2", "MODE=debug", "MODE=release"}
+	fp := cmd.EnvFingerprint(salt)
+
+	if len(fp) != 2 {
+		t.Fatalf("expecting 2 variables, got %v", fp)
+	}
+	for name, hash := range fp {
+		if strings.Contains(hash, "hunter2") || strings.Contains(hash, "release") {
+			t.Errorf("%s: value not hashed: %q", name, hash)
+		}
+	}
+
+	other := exex.Command(os.Args[0])
+	other.Env = []string{"MODE=release", "TOKEN=other"}
+	ofp := other.EnvFingerprint(salt)
+
+	if fp["MODE"] != ofp["MODE"] {
+		t.Errorf("expecting same hash for same value")
+	}
+	if fp["TOKEN"] == ofp["TOKEN"] {
+		t.Errorf("expecting different hashes for different values")
+	}
+
+	if fp["MODE"] == cmd.EnvFingerprint([]byte("pepper"))["MODE"] {
+		t.Errorf("expecting different hashes for different salts")
+	}
+
+	// Without Env the process environment is used.
+	if fp := exex.Command(os.Args[0]).EnvFingerprint(salt); fp["TEST_MAIN"] == "" {
+		t.Errorf("expecting TEST_MAIN in fingerprint, got %v", fp)
+	}
+}
@@ -0,0 +1,125 @@
+package exex
+
+import (
+	"errors"
+	"sync"
+)
+
+// Group runs commands concurrently, up to Limit at a time, and
+// collects their results. The zero value is ready to use and has no
+// limit.
+//
+//	var g exex.Group
+//	g.Limit = runtime.NumCPU()
+//	for _, f := range files {
+//		g.Go(exex.Command("convert", f, f+".png"))
+//	}
+//	results, err := g.Wait()
+//
+// A Group must not be copied after first use.
+type Group struct {
+	// Limit is the maximum number of commands running at the same
+	// time. Zero or negative means no limit. It must not be modified
+	// after calling Go.
+	Limit int
+
+	wg        sync.WaitGroup
+	once      sync.Once
+	sem       chan struct{}
+	mu        sync.Mutex
+	cond      sync.Cond
+	results   []*GroupResult
+	completed []CommandID // in order of completion
+}
+
+// CommandID identifies a command run by a Group, being the order in
+// which it was added, starting at 0.
+type CommandID int
+
+// GroupResult is the result of a command run by a Group.
+type GroupResult struct {
+	// Cmd is the command.
+	Cmd *Cmd
+
+	// Result is the result of the command, or nil if it could not be
+	// started.
+	Result *Result
+
+	// Stderr is the standard error of the command if it failed, as
+	// captured and reported in its *CmdError.
+	Stderr []byte
+
+	// Err is the error returned by running the command.
+	Err error
+}
+
+// Go runs the command in a new goroutine and returns its CommandID.
+// If Limit commands are already running, it blocks until one of them
+// finishes.
+func (g *Group) Go(c *Cmd) CommandID {
+	g.init()
+
+	r := &GroupResult{Cmd: c}
+	g.mu.Lock()
+	id := CommandID(len(g.results))
+	g.results = append(g.results, r)
+	g.mu.Unlock()
+
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+
+		r.Err = c.Run()
+		r.Result = c.Result()
+
+		var cmdErr *CmdError
+		if errors.As(r.Err, &cmdErr) {
+			r.Stderr = cmdErr.Stderr
+		}
+
+		g.mu.Lock()
+		g.completed = append(g.completed, id)
+		g.cond.Broadcast()
+		g.mu.Unlock()
+	}()
+
+	return id
+}
+
+func (g *Group) init() {
+	g.once.Do(func() {
+		if g.Limit > 0 {
+			g.sem = make(chan struct{}, g.Limit)
+		}
+		g.cond.L = &g.mu
+	})
+}
+
+// Wait waits for all the commands run with Go to finish, and returns
+// their results in the order they were added. The returned error
+// joins the errors of the commands that failed; the results are
+// always returned.
+func (g *Group) Wait() ([]GroupResult, error) {
+	g.wg.Wait()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	res := make([]GroupResult, len(g.results))
+	var errs []error
+	for i, r := range g.results {
+		res[i] = *r
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+
+	return res, errors.Join(errs...)
+}
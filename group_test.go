@@ -0,0 +1,84 @@
+package exex_test
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+// countingRunner records the maximum number of commands running at
+// the same time.
+type countingRunner struct {
+	running, max atomic.Int32
+}
+
+func (r *countingRunner) Start(c *exex.Cmd) error {
+	n := r.running.Add(1)
+	for {
+		m := r.max.Load()
+		if n <= m || r.max.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	return exex.LocalRunner{}.Start(c)
+}
+
+func (r *countingRunner) Wait(c *exex.Cmd) error {
+	defer r.running.Add(-1)
+	return exex.LocalRunner{}.Wait(c)
+}
+
+func TestGroup(t *testing.T) {
+	var (
+		g      = exex.Group{Limit: 2}
+		runner countingRunner
+	)
+	for i := 0; i < 6; i++ {
+		g.Go(exex.Command(os.Args[0], strconv.Itoa(i%2), "failed "+strconv.Itoa(i)).Apply(
+			exex.WithEnv("TEST_MAIN=exit"),
+			exex.WithRunner(&runner),
+		))
+	}
+
+	res, err := g.Wait()
+	if err == nil {
+		t.Fatal("expecting an error")
+	}
+	if max := runner.max.Load(); max > 2 {
+		t.Fatalf("expecting at most 2 commands running, got %d", max)
+	}
+
+	if len(res) != 6 {
+		t.Fatalf("expecting 6 results, got %d", len(res))
+	}
+	for i, r := range res {
+		if r.Result == nil || r.Result.ExitCode != i%2 {
+			t.Fatalf("%d: unexpected result %+v", i, r.Result)
+		}
+		if i%2 == 0 {
+			if r.Err != nil || r.Stderr != nil {
+				t.Fatalf("%d: unexpected error %v", i, r.Err)
+			}
+			continue
+		}
+
+		if exp := "failed " + strconv.Itoa(i); string(r.Stderr) != exp {
+			t.Fatalf("%d: expecting stderr %q, got %q", i, exp, r.Stderr)
+		}
+		if !errors.Is(err, r.Err) {
+			t.Fatalf("%d: expecting %v to be joined in %v", i, r.Err, err)
+		}
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		var g exex.Group
+		res, err := g.Wait()
+		if len(res) != 0 || err != nil {
+			t.Fatalf("unexpected results %v, %v", res, err)
+		}
+	})
+}
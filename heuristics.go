@@ -0,0 +1,94 @@
+package exex
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// StderrOutputWarning is reported in Result.Warnings by
+// WithHeuristics when a command exited successfully writing nothing
+// to its standard output but text to its standard error. This is
+// usually a tool printing its output, e.g. its version or usage, to
+// the wrong stream, so reading its standard output yields nothing.
+type StderrOutputWarning struct {
+	// Stderr is the number of bytes written to the standard error.
+	Stderr int64
+}
+
+func (w *StderrOutputWarning) Error() string {
+	return fmt.Sprintf("exex: command wrote nothing to stdout but %d bytes to stderr", w.Stderr)
+}
+
+// WithHeuristics enables the detection of common mistakes in the use
+// of a command that do not make it fail, which are reported in
+// Result.Warnings. Currently it reports a *StderrOutputWarning when
+// the output of the command went to the standard error instead of the
+// standard output.
+//
+// The streams are only inspected when they are neither nil nor an
+// *os.File, and not merged with MergeStderrIntoStdout.
+func WithHeuristics() Option {
+	return func(c *Cmd) { c.heuristics = &heuristics{} }
+}
+
+// heuristics counts the output written by a command.
+type heuristics struct {
+	stdout, stderr *countWriter
+}
+
+// watchHeuristics wraps the output streams of the command to count
+// what it writes to them.
+func (c *Cmd) watchHeuristics() {
+	h := c.heuristics
+	if h == nil || c.mergeStderr || !countable(c.Stdout) || !countable(c.Stderr) {
+		return
+	}
+	if sameWriter(c.Stdout, c.Stderr) {
+		return
+	}
+
+	h.stdout = &countWriter{w: c.Stdout}
+	h.stderr = &countWriter{w: c.Stderr}
+	c.Stdout, c.Stderr = h.stdout, h.stderr
+}
+
+// warnings returns the warnings detected once the command finished
+// successfully.
+func (h *heuristics) warnings() []error {
+	if h == nil || h.stdout == nil {
+		return nil
+	}
+
+	var ws []error
+	if h.stdout.n.Load() == 0 && h.stderr.text.Load() {
+		ws = append(ws, &StderrOutputWarning{Stderr: h.stderr.n.Load()})
+	}
+	return ws
+}
+
+func countable(w io.Writer) bool {
+	if w == nil {
+		return false
+	}
+	_, ok := w.(*os.File)
+	return !ok
+}
+
+// countWriter counts the bytes written to w and whether they
+// include anything other than white space.
+type countWriter struct {
+	w    io.Writer
+	n    atomic.Int64
+	text atomic.Bool
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n.Add(int64(len(p)))
+	if len(bytes.TrimSpace(p)) > 0 {
+		w.text.Store(true)
+	}
+	return w.w.Write(p)
+}
@@ -0,0 +1,61 @@
+package exex_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithHeuristics(t *testing.T) {
+	tests := map[string]struct {
+		mode string
+		args []string
+		warn bool
+	}{
+		"stderr only":  {mode: "exit", args: []string{"0", "exex-test version 1.2.3"}, warn: true},
+		"blank stderr": {mode: "exit", args: []string{"0", " "}},
+		"stdout":       {mode: "version"},
+		"failed":       {mode: "exit", args: []string{"1", "usage"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmd := exex.Command(os.Args[0], tt.args...).Apply(
+				exex.WithEnv("TEST_MAIN="+tt.mode),
+				exex.WithHeuristics(),
+			)
+			cmd.Output()
+
+			ws := cmd.Result().Warnings
+			if !tt.warn {
+				if len(ws) > 0 {
+					t.Fatalf("unexpected warnings: %v", ws)
+				}
+				return
+			}
+
+			if len(ws) != 1 {
+				t.Fatalf("expecting 1 warning, got %v", ws)
+			}
+			var w *exex.StderrOutputWarning
+			if !errors.As(ws[0], &w) {
+				t.Fatalf("expecting *StderrOutputWarning, got %T", ws[0])
+			}
+			if w.Stderr != 23 {
+				t.Errorf("expecting 23 bytes of stderr, got %d", w.Stderr)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		cmd := exex.Command(os.Args[0], "0", "oops").Apply(exex.WithEnv("TEST_MAIN=exit"))
+		if _, err := cmd.Output(); err != nil {
+			t.Fatal(err)
+		}
+		if ws := cmd.Result().Warnings; ws != nil {
+			t.Fatalf("unexpected warnings: %v", ws)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package exex
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// ErrIdleTimeout is the error reported when a command is killed
+// because of WithIdleTimeout.
+var ErrIdleTimeout = errors.New("exex: idle timeout")
+
+// WithIdleTimeout kills the command if it does not write anything to
+// its standard output nor standard error for d. The error returned in
+// that case matches ErrIdleTimeout when using errors.Is.
+//
+// Both streams are observed even when they are not set, in which case
+// their output is discarded.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(c *Cmd) { c.idle = &idleWatch{timeout: d} }
+}
+
+type idleWatch struct {
+	timeout time.Duration
+	last    atomic.Int64 // UnixNano of the last write
+	timer   *time.Timer
+	stopped bool
+}
+
+// activityWriter records the time of each write on its idleWatch.
+type activityWriter struct {
+	w    io.Writer
+	idle *idleWatch
+}
+
+func (w *activityWriter) Write(p []byte) (int, error) {
+	w.idle.last.Store(time.Now().UnixNano())
+	return w.w.Write(p)
+}
+
+// watchIdle wraps the output streams of the command to record its
+// activity.
+func (c *Cmd) watchIdle() {
+	if c.idle == nil {
+		return
+	}
+
+	wrap := func(w io.Writer) io.Writer {
+		if w == nil {
+			w = io.Discard
+		}
+		return &activityWriter{w: w, idle: c.idle}
+	}
+
+	// Keep both streams sharing the same writer, as exec.Cmd then
+	// uses a single pipe for them.
+	if c.mergeStderr || sameWriter(c.Stdout, c.Stderr) {
+		c.Stdout = wrap(c.Stdout)
+		c.Stderr = c.Stdout
+		return
+	}
+
+	c.Stdout = wrap(c.Stdout)
+	c.Stderr = wrap(c.Stderr)
+}
+
+func (c *Cmd) startIdleTimer() {
+	w := c.idle
+	if w == nil {
+		return
+	}
+
+	w.last.Store(time.Now().UnixNano())
+
+	var check func()
+	check = func() {
+		idle := time.Since(time.Unix(0, w.last.Load()))
+		if idle >= w.timeout {
+			c.abort(ErrIdleTimeout)
+			return
+		}
+
+		c.mu.Lock()
+		if !w.stopped {
+			w.timer.Reset(w.timeout - idle)
+		}
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	w.timer = time.AfterFunc(w.timeout, check)
+	c.mu.Unlock()
+}
+
+func (c *Cmd) stopIdleTimer() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.idle != nil && c.idle.timer != nil {
+		c.idle.stopped = true
+		c.idle.timer.Stop()
+	}
+}
+
+// sameWriter reports whether a and b are the same non-nil writer,
+// guarding against writers whose type is not comparable.
+func sameWriter(a, b io.Writer) (same bool) {
+	if a == nil || b == nil {
+		return false
+	}
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
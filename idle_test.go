@@ -0,0 +1,58 @@
+package exex_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithIdleTimeout(t *testing.T) {
+	t.Run("idle", func(t *testing.T) {
+		cmd := exex.Command(os.Args[0]).Apply(
+			exex.WithEnv("TEST_MAIN=hang"),
+			exex.WithIdleTimeout(100*time.Millisecond),
+		)
+
+		start := time.Now()
+		err := cmd.Run()
+		if !errors.Is(err, exex.ErrIdleTimeout) {
+			t.Fatalf("expecting exex.ErrIdleTimeout, got %v", err)
+		}
+		if d := time.Since(start); d > 10*time.Second {
+			t.Fatalf("command took too long to be killed: %v", d)
+		}
+
+		var exErr *exex.ExitError
+		if !errors.As(err, &exErr) {
+			t.Fatalf("expecting *exec.ExitError, got %T: %[1]v", err)
+		}
+	})
+
+	t.Run("active", func(t *testing.T) {
+		out, err := exex.Command(os.Args[0]).Apply(
+			exex.WithEnv("TEST_MAIN=tick"),
+			exex.WithIdleTimeout(5*time.Second),
+		).Output()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if exp := "tick\ntick\ntick\ntick\ntick\n"; string(out) != exp {
+			t.Fatalf("expecting %q, got %q", exp, out)
+		}
+	})
+
+	t.Run("combined", func(t *testing.T) {
+		out, err := exex.Command(os.Args[0], "combined").Apply(
+			exex.WithIdleTimeout(5 * time.Second),
+		).CombinedOutput()
+		if err == nil || errors.Is(err, exex.ErrIdleTimeout) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if exp := "error: combined"; string(out) != exp {
+			t.Fatalf("expecting %q, got %q", exp, out)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package exex
+
+import (
+	"fmt"
+	"syscall"
+)
+
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+)
+
+// checkIOPriority reports whether p is a valid I/O priority.
+func checkIOPriority(p ioPriority) error {
+	if p.class < IOClassRealtime || p.class > IOClassIdle || p.level < 0 || p.level > 7 {
+		return fmt.Errorf("exex: invalid I/O priority: class %d, level %d", p.class, p.level)
+	}
+	return nil
+}
+
+// setIOPriority sets the I/O scheduling class and priority level of
+// the process pid.
+func setIOPriority(pid int, p ioPriority) error {
+	prio := int(p.class)<<ioprioClassShift | p.level
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(pid), uintptr(prio)); errno != 0 {
+		return fmt.Errorf("exex: I/O priority: %w", errno)
+	}
+	return nil
+}
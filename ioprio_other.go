@@ -0,0 +1,9 @@
+//go:build !linux
+
+package exex
+
+func checkIOPriority(p ioPriority) error {
+	return unsupported("I/O priority")
+}
+
+func setIOPriority(pid int, p ioPriority) error { return nil }
@@ -0,0 +1,79 @@
+//go:build go1.23
+
+package exex
+
+import (
+	"context"
+	"iter"
+)
+
+// Results returns an iterator over the results of the commands of the
+// Group in the order they finish, as soon as they do, along with
+// their CommandID. It stops once all the commands added so far
+// finished. Breaking out of it does not affect the commands.
+//
+//	for id, r := range g.Results() {
+//		if r.Err != nil {
+//			log.Printf("command %d: %v", id, r.Err)
+//		}
+//	}
+func (g *Group) Results() iter.Seq2[CommandID, GroupResult] {
+	return func(yield func(CommandID, GroupResult) bool) {
+		g.init()
+
+		for n := 0; ; n++ {
+			g.mu.Lock()
+			for n == len(g.completed) && n < len(g.results) {
+				g.cond.Wait()
+			}
+			if n == len(g.completed) {
+				g.mu.Unlock()
+				return
+			}
+			id := g.completed[n]
+			r := *g.results[id]
+			g.mu.Unlock()
+
+			if !yield(id, r) {
+				return
+			}
+		}
+	}
+}
+
+// FanOutSeq is like FanOut but returns an iterator over the results of
+// the hosts in the order they finish, as soon as they do, along with
+// the index of the host. Breaking out of it kills the commands still
+// running.
+//
+// If the standard input of cmd cannot be read, the error is reported
+// for every host.
+func FanOutSeq(ctx context.Context, hosts []Host, cmd *Cmd, opts ...FanOutOption) iter.Seq2[int, HostResult] {
+	return func(yield func(int, HostResult) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		type hostResult struct {
+			i int
+			r HostResult
+		}
+		results := make(chan hostResult, len(hosts))
+		go func() {
+			err := fanOut(ctx, hosts, cmd, opts, func(i int, r HostResult) {
+				results <- hostResult{i, r}
+			})
+			if err != nil {
+				for i, h := range hosts {
+					results <- hostResult{i, HostResult{Host: h.Name, Err: err}}
+				}
+			}
+		}()
+
+		for range hosts {
+			hr := <-results
+			if !yield(hr.i, hr.r) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+//go:build go1.23
+
+package exex_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestGroup_Results(t *testing.T) {
+	var g exex.Group
+	slow := g.Go(exex.Command(os.Args[0], "@300ms").Apply(exex.WithEnv("TEST_MAIN=files")))
+	fast := g.Go(exex.Command(os.Args[0], "1", "failed").Apply(exex.WithEnv("TEST_MAIN=exit")))
+
+	var ids []exex.CommandID
+	for id, r := range g.Results() {
+		ids = append(ids, id)
+		if id == fast {
+			assertErr(t, r.Err, "failed")
+		} else if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+	}
+	if len(ids) != 2 || ids[0] != fast || ids[1] != slow {
+		t.Fatalf("expecting completion order %v, got %v", []exex.CommandID{fast, slow}, ids)
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		var g exex.Group
+		for id := range g.Results() {
+			t.Fatalf("unexpected result %d", id)
+		}
+	})
+}
+
+func TestFanOutSeq(t *testing.T) {
+	hosts := []exex.Host{
+		{Name: "local", Runner: exex.LocalRunner{}},
+		{Name: "refused", Runner: refusingRunner{}},
+	}
+
+	cmd := exex.Command(os.Args[0], "hello").Apply(exex.WithEnv("TEST_MAIN=echo"))
+
+	var order []int
+	for i, r := range exex.FanOutSeq(context.Background(), hosts, cmd) {
+		order = append(order, i)
+		if r.Host != hosts[i].Name {
+			t.Fatalf("expecting host %s, got %s", hosts[i].Name, r.Host)
+		}
+		switch i {
+		case 0:
+			if r.Err != nil || string(r.Output) != "hello\n" {
+				t.Fatalf("unexpected result %+v", r)
+			}
+		case 1:
+			if !errors.Is(r.Err, errRefused) {
+				t.Fatalf("expecting the error of the refused host, got %v", r.Err)
+			}
+		}
+	}
+	if len(order) != 2 || order[0] != 1 {
+		t.Fatalf("expecting the refused host to finish first, got %v", order)
+	}
+
+	t.Run("break", func(t *testing.T) {
+		hang := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=hang"))
+		for i := range exex.FanOutSeq(context.Background(), hosts, hang) {
+			if i != 1 {
+				t.Fatalf("expecting the refused host first, got %d", i)
+			}
+			break
+		}
+	})
+}
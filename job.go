@@ -0,0 +1,56 @@
+package exex
+
+// JobLimits are the resource limits of the job object of a command
+// set with WithJobObject. The zero value sets no limits.
+type JobLimits struct {
+	// Memory is the maximum memory, in bytes, committed by all the
+	// processes of the job together. Allocations beyond it fail.
+	Memory uint64
+
+	// CPUPercent is the maximum percentage, between 1 and 100, of
+	// the CPU time of all the processors used by the processes of
+	// the job together.
+	CPUPercent int
+}
+
+// WithJobObject runs the command in a new Windows job object with the
+// given limits, so that its descendants, e.g. conhost.exe or the
+// children of a script, are assigned to the job too, and are killed
+// together with the command when killing it, because its context is
+// done, it is aborted or terminated with Terminate, and once it
+// finished and was waited for.
+//
+// The command is assigned to the job right after being started, so
+// processes it starts before are not part of it. Elsewhere starting
+// the command fails with an *UnsupportedFeatureError, unless set
+// otherwise with WithDegradation; WithProcessGroup or KillTree can be
+// used instead.
+func WithJobObject(limits JobLimits) Option {
+	return func(c *Cmd) {
+		c.job = &jobObject{limits: limits}
+		if c.ctx != nil && c.stopSignal == nil {
+			c.Cancel = c.kill
+		}
+	}
+}
+
+// startJob assigns the started command to its job object, killing it
+// if that fails.
+func (c *Cmd) startJob() error {
+	if c.Process == nil {
+		return nil
+	}
+	if err := c.job.assign(c.Process); err != nil {
+		c.Process.Kill()
+		return err
+	}
+	return nil
+}
+
+// closeJob closes the job object of the command, if any, killing the
+// processes left in it.
+func (c *Cmd) closeJob() {
+	if c.job != nil {
+		c.job.close()
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package exex
+
+import "os"
+
+type jobObject struct {
+	limits JobLimits
+}
+
+func (j *jobObject) create() error {
+	return unsupported("job object")
+}
+
+func (j *jobObject) assign(p *os.Process) error { return nil }
+
+func (j *jobObject) terminate() error { return os.ErrProcessDone }
+
+func (j *jobObject) close() {}
@@ -0,0 +1,44 @@
+package exex_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithJobObject(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		err := exex.Command("/bin/sh", "-c", "true").Apply(exex.WithJobObject(exex.JobLimits{})).Run()
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Fatalf("expecting unsupported error, got %v", err)
+		}
+
+		cmd := exex.Command("/bin/sh", "-c", "true").Apply(
+			exex.WithJobObject(exex.JobLimits{}),
+			exex.WithDegradation(exex.DegradeIgnore),
+		)
+		if err := cmd.Run(); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	// The environment of the tests has no PATH.
+	const cmdExe = `C:\Windows\System32\cmd.exe`
+
+	limits := exex.JobLimits{Memory: 512 << 20, CPUPercent: 50}
+	if err := exex.Command(cmdExe, "/c", "exit 0").Apply(exex.WithJobObject(limits)).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := exex.CommandContext(ctx, cmdExe, "/c", `C:\Windows\System32\ping.exe -n 60 127.0.0.1`).Apply(exex.WithJobObject(limits)).Run()
+	if err == nil {
+		t.Fatal("expecting error")
+	}
+}
@@ -0,0 +1,139 @@
+package exex
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procCreateJobObjectW         = kernel32.NewProc("CreateJobObjectW")
+	procSetInformationJobObject  = kernel32.NewProc("SetInformationJobObject")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+	procTerminateJobObject       = kernel32.NewProc("TerminateJobObject")
+)
+
+const (
+	jobObjectExtendedLimitInformation  = 9
+	jobObjectCPURateControlInformation = 15
+
+	jobObjectLimitJobMemory       = 0x00000200
+	jobObjectLimitKillOnJobClose  = 0x00002000
+	jobObjectCPURateControlEnable = 0x1
+	jobObjectCPURateControlCap    = 0x4
+
+	processSetQuota  = 0x0100
+	processTerminate = 0x0001
+)
+
+type jobBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+type jobExtendedLimitInformation struct {
+	BasicLimitInformation jobBasicLimitInformation
+	IoInfo                [6]uint64
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+type jobCPURateControlInformation struct {
+	ControlFlags uint32
+	CPURate      uint32
+}
+
+type jobObject struct {
+	limits JobLimits
+	mu     sync.Mutex // guards handle, closed while it can be killed
+	handle syscall.Handle
+}
+
+// create creates the job object, set to kill its processes once
+// closed.
+func (j *jobObject) create() error {
+	h, _, err := procCreateJobObjectW.Call(0, 0)
+	if h == 0 {
+		return fmt.Errorf("exex: job object: %w", err)
+	}
+	j.handle = syscall.Handle(h)
+
+	info := jobExtendedLimitInformation{}
+	info.BasicLimitInformation.LimitFlags = jobObjectLimitKillOnJobClose
+	if j.limits.Memory > 0 {
+		info.BasicLimitInformation.LimitFlags |= jobObjectLimitJobMemory
+		info.JobMemoryLimit = uintptr(j.limits.Memory)
+	}
+	err = j.set(jobObjectExtendedLimitInformation, unsafe.Pointer(&info), unsafe.Sizeof(info))
+	if err == nil && j.limits.CPUPercent > 0 {
+		rate := jobCPURateControlInformation{
+			ControlFlags: jobObjectCPURateControlEnable | jobObjectCPURateControlCap,
+			// The rate is expressed in hundredths of a percent.
+			CPURate: uint32(min(j.limits.CPUPercent, 100) * 100),
+		}
+		err = j.set(jobObjectCPURateControlInformation, unsafe.Pointer(&rate), unsafe.Sizeof(rate))
+	}
+	if err != nil {
+		j.close()
+		return fmt.Errorf("exex: job object: %w", err)
+	}
+	return nil
+}
+
+func (j *jobObject) set(class uintptr, info unsafe.Pointer, size uintptr) error {
+	if r, _, err := procSetInformationJobObject.Call(uintptr(j.handle), class, uintptr(info), size); r == 0 {
+		return err
+	}
+	return nil
+}
+
+// assign assigns the process p to the job object.
+func (j *jobObject) assign(p *os.Process) error {
+	h, err := syscall.OpenProcess(processSetQuota|processTerminate, false, uint32(p.Pid))
+	if err != nil {
+		return fmt.Errorf("exex: job object: %w", err)
+	}
+	defer syscall.CloseHandle(h)
+
+	if r, _, err := procAssignProcessToJobObject.Call(uintptr(j.handle), uintptr(h)); r == 0 {
+		return fmt.Errorf("exex: job object: %w", err)
+	}
+	return nil
+}
+
+// terminate kills all the processes of the job object.
+func (j *jobObject) terminate() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.handle == 0 {
+		return os.ErrProcessDone
+	}
+	if r, _, err := procTerminateJobObject.Call(uintptr(j.handle), 1); r == 0 {
+		return err
+	}
+	return nil
+}
+
+// close closes the job object, killing the processes left in it.
+func (j *jobObject) close() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.handle != 0 {
+		syscall.CloseHandle(j.handle)
+		j.handle = 0
+	}
+}
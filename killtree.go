@@ -0,0 +1,35 @@
+package exex
+
+import (
+	"errors"
+	"os"
+)
+
+// KillTree kills the command along with all its descendants, e.g. the
+// processes started by a build tool, even if they left its process
+// group. The tree is stopped before it is walked, so that processes
+// cannot escape it by forking meanwhile.
+//
+// Descendants are found through their parent PID, using /proc on
+// Linux and ps(1) on other Unix systems, so processes whose parent
+// already exited, like daemons forking twice, are no longer part of
+// the tree unless the current process is their subreaper, see
+// EnableReaper. On Windows the tree is killed with taskkill.
+//
+// KillTree does not wait for the command: Wait must still be called
+// to release its resources.
+func (c *Cmd) KillTree() error {
+	if c.Process == nil || c.done == nil {
+		return errors.New("exex: not started")
+	}
+	select {
+	case <-c.done:
+		return nil
+	default:
+	}
+
+	if err := killTree(c.Process); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !unix && !windows
+
+package exex
+
+import "os"
+
+func killTree(p *os.Process) error {
+	return unsupported("process tree")
+}
@@ -0,0 +1,68 @@
+//go:build unix
+
+package exex_test
+
+import (
+	"bufio"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+func TestKillTree(t *testing.T) {
+	t.Run("not started", func(t *testing.T) {
+		if err := exex.Command("/bin/sh").KillTree(); err == nil {
+			t.Fatal("expecting error")
+		}
+	})
+
+	setsid, err := exec.LookPath("/usr/bin/setsid")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// The grandchild runs in its own session, so it would be left
+	// behind when killing the group of the command.
+	r, w := io.Pipe()
+	cmd := exex.Command("/bin/sh", "-c", setsid+" sh -c 'sleep 1000 & echo $!; wait' & wait").Apply(
+		exex.WithEnv("PATH=/usr/bin:/bin"),
+		exex.WithProcessGroup(),
+	)
+	cmd.Stdout = w
+	cmd.WaitDelay = 100 * time.Millisecond
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	line, _ := bufio.NewReader(r).ReadString('\n')
+	pid, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		t.Fatalf("unexpected output %q", line)
+	}
+	t.Cleanup(func() { syscall.Kill(pid, syscall.SIGKILL) })
+	go io.Copy(io.Discard, r)
+
+	if err := cmd.KillTree(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Wait(); err == nil {
+		t.Fatal("expecting error")
+	}
+
+	for i := 0; alive(pid); i++ {
+		if i == 50 {
+			t.Fatalf("expecting process %d to be killed", pid)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if err := cmd.KillTree(); err != nil {
+		t.Fatalf("expecting no error after exit, got %v", err)
+	}
+}
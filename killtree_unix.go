@@ -0,0 +1,147 @@
+//go:build unix
+
+package exex
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// procInfo is the information about a process found by processes.
+type procInfo struct {
+	pid, ppid, pgid int
+	zombie          bool
+}
+
+// processes lists the processes of the system using /proc or, if it
+// is not available, ps.
+func processes() ([]procInfo, error) {
+	if procs, ok := procFS(); ok {
+		return procs, nil
+	}
+
+	out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,pgid=,stat=").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var procs []procInfo
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		f := bytes.Fields(s.Bytes())
+		if len(f) < 4 {
+			continue
+		}
+		var p procInfo
+		p.pid, _ = strconv.Atoi(string(f[0]))
+		p.ppid, _ = strconv.Atoi(string(f[1]))
+		p.pgid, _ = strconv.Atoi(string(f[2]))
+		p.zombie = f[3][0] == 'Z'
+		procs = append(procs, p)
+	}
+	return procs, nil
+}
+
+// procFS lists the processes of the system using /proc, reporting
+// false if it is not available.
+func procFS() ([]procInfo, bool) {
+	stats, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil || len(stats) == 0 {
+		return nil, false
+	}
+
+	var procs []procInfo
+	for _, name := range stats {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			continue
+		}
+
+		// The fields after the command name, which is enclosed in
+		// parens, are the state, the parent PID and the group ID.
+		i := bytes.LastIndexByte(b, ')')
+		if i < 0 {
+			continue
+		}
+		f := bytes.Fields(b[i+1:])
+		if len(f) < 3 {
+			continue
+		}
+
+		p := procInfo{zombie: string(f[0]) == "Z"}
+		p.pid, err = strconv.Atoi(filepath.Base(filepath.Dir(name)))
+		if err != nil {
+			continue
+		}
+		p.ppid, _ = strconv.Atoi(string(f[1]))
+		p.pgid, _ = strconv.Atoi(string(f[2]))
+		procs = append(procs, p)
+	}
+
+	return procs, true
+}
+
+// descendants returns the PIDs of the live descendants of pid.
+func descendants(pid int) ([]int, error) {
+	procs, err := processes()
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]int)
+	for _, p := range procs {
+		if !p.zombie {
+			children[p.ppid] = append(children[p.ppid], p.pid)
+		}
+	}
+
+	var pids []int
+	queue := children[pid]
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		pids = append(pids, p)
+		queue = append(queue, children[p]...)
+	}
+	return pids, nil
+}
+
+// killTree stops the process p and its descendants, and kills them.
+func killTree(p *os.Process) error {
+	if err := p.Signal(syscall.SIGSTOP); err != nil {
+		return err
+	}
+
+	// Stop the descendants until no new ones are found, as they may
+	// have forked before being stopped.
+	stopped := make(map[int]bool)
+	for {
+		pids, err := descendants(p.Pid)
+		if err != nil {
+			p.Kill()
+			return err
+		}
+
+		found := false
+		for _, pid := range pids {
+			if !stopped[pid] {
+				syscall.Kill(pid, syscall.SIGSTOP)
+				stopped[pid] = true
+				found = true
+			}
+		}
+		if !found {
+			break
+		}
+	}
+
+	for pid := range stopped {
+		syscall.Kill(pid, syscall.SIGKILL)
+	}
+	return p.Kill()
+}
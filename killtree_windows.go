@@ -0,0 +1,8 @@
+package exex
+
+import "os"
+
+// killTree kills the process p and its descendants.
+func killTree(p *os.Process) error {
+	return signalGroup(p, os.Kill)
+}
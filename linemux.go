@@ -0,0 +1,98 @@
+package exex
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// LineMux writes to the same io.Writer the lines written to several
+// writers, e.g. the standard output and standard error of commands,
+// guaranteeing that each line is written whole, so lines written
+// concurrently are never interleaved. It is useful for deterministic
+// assertions in tests.
+//
+//	mux := exex.NewLineMux(&buf)
+//	stdout, stderr := mux.Writer(""), mux.Writer("stderr: ")
+//	cmd.Stdout, cmd.Stderr = stdout, stderr
+//	err := cmd.Run()
+//	stdout.Flush()
+//	stderr.Flush()
+type LineMux struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewLineMux returns a LineMux writing to w.
+func NewLineMux(w io.Writer) *LineMux {
+	return &LineMux{w: w}
+}
+
+// Writer returns a new writer of the LineMux that writes each line
+// prefixed with prefix.
+func (m *LineMux) Writer(prefix string) *MuxWriter {
+	return &MuxWriter{mux: m, prefix: []byte(prefix)}
+}
+
+// MuxWriter is a writer of a LineMux. Partial lines are buffered until
+// their end is written or Flush is called.
+type MuxWriter struct {
+	mux    *LineMux
+	prefix []byte
+	mu     sync.Mutex
+	buf    []byte
+}
+
+// Write writes the complete lines in p, along with the partial line
+// buffered from previous writes, to the writer of the LineMux.
+func (w *MuxWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	i := bytes.LastIndexByte(w.buf, '\n')
+	if i < 0 {
+		return len(p), nil
+	}
+
+	err := w.write(w.buf[:i+1])
+	w.buf = append(w.buf[:0], w.buf[i+1:]...)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes the buffered partial line, if any, terminated with a
+// newline.
+func (w *MuxWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.write(append(w.buf, '\n'))
+	w.buf = w.buf[:0]
+	return err
+}
+
+// write writes the given complete lines, adding the prefix to each.
+func (w *MuxWriter) write(lines []byte) error {
+	if len(w.prefix) > 0 {
+		var b bytes.Buffer
+		for len(lines) > 0 {
+			i := bytes.IndexByte(lines, '\n')
+			b.Write(w.prefix)
+			b.Write(lines[:i+1])
+			lines = lines[i+1:]
+		}
+		lines = b.Bytes()
+	}
+
+	w.mux.mu.Lock()
+	defer w.mux.mu.Unlock()
+
+	_, err := w.mux.w.Write(lines)
+	return err
+}
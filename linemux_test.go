@@ -0,0 +1,72 @@
+package exex_test
+
+import (
+	"bytes"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestLineMux(t *testing.T) {
+	var buf bytes.Buffer
+	mux := exex.NewLineMux(&buf)
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"a", "b"} {
+		w := mux.Writer(name + ": ")
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				// Each line is written in several pieces.
+				w.Write([]byte("li"))
+				w.Write([]byte("ne " + name + "\nli"))
+				w.Write([]byte("ne " + name + "\n"))
+			}
+			w.Write([]byte("last " + name))
+			w.Flush()
+		}(name)
+	}
+	wg.Wait()
+
+	counts := map[string]int{}
+	for _, l := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		counts[l]++
+	}
+	exp := map[string]int{
+		"a: line a": 200,
+		"b: line b": 200,
+		"a: last a": 1,
+		"b: last b": 1,
+	}
+	if len(counts) != len(exp) {
+		t.Fatalf("unexpected lines %v", counts)
+	}
+	for l, n := range exp {
+		if counts[l] != n {
+			t.Errorf("expecting %d lines %q, got %d", n, l, counts[l])
+		}
+	}
+}
+
+func TestLineMux_Cmd(t *testing.T) {
+	var buf bytes.Buffer
+	mux := exex.NewLineMux(&buf)
+	stdout, stderr := mux.Writer(""), mux.Writer("stderr: ")
+
+	cmd := exex.Command(os.Args[0], "2", "first\nsecond").Apply(exex.WithEnv("TEST_MAIN=exit"))
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	cmd.Run()
+	stdout.Flush()
+	stderr.Flush()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	sort.Strings(lines)
+	if got := strings.Join(lines, "|"); got != "stderr: first|stderr: second" {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+}
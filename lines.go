@@ -0,0 +1,133 @@
+package exex
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// OutputStream identifies the stream a command wrote an OutputLine to.
+type OutputStream int
+
+const (
+	// OutputStdout is the standard output stream.
+	OutputStdout OutputStream = iota + 1
+
+	// OutputStderr is the standard error stream.
+	OutputStderr
+)
+
+func (s OutputStream) String() string {
+	switch s {
+	case OutputStdout:
+		return "stdout"
+	case OutputStderr:
+		return "stderr"
+	}
+	return "unknown"
+}
+
+// OutputLine is a line written by a command, as sent by Lines.
+type OutputLine struct {
+	Stream OutputStream
+	Bytes  []byte    // contents without the line terminator
+	Time   time.Time // when the line was written
+}
+
+// Lines starts the command and sends each line it writes to its
+// standard output and standard error to the returned lines channel,
+// which is closed once the command finishes. The error returned by
+// Wait, or by Start, is then sent to the error channel, which is
+// closed afterwards. The last line of each stream is sent even if it
+// is not terminated. Lines are split as set with WithSplit.
+//
+// The command is blocked writing its output until its lines are
+// received, unless a different policy is set with WithBackpressure.
+// If ctx is done before the command finishes, it is killed and the
+// error reports ctx.Err() as its cause.
+//
+// The standard error is still captured and reported as described in
+// Run. If the standard error is merged with MergeStderrIntoStdout,
+// all lines are sent as OutputStdout.
+func (c *Cmd) Lines(ctx context.Context) (<-chan OutputLine, <-chan error) {
+	lines := make(chan OutputLine)
+	errc := make(chan error, 1)
+
+	fail := func(err error) (<-chan OutputLine, <-chan error) {
+		close(lines)
+		errc <- err
+		close(errc)
+		return lines, errc
+	}
+
+	if c.Stdout != nil {
+		return fail(errors.New("exex: Stdout already set"))
+	}
+	if c.Stderr != nil && !c.mergeStderr {
+		return fail(errors.New("exex: Stderr already set"))
+	}
+
+	var finishers []func()
+	send := func(stream OutputStream) (*lineWriter, error) {
+		deliver, finish, err := c.pace(func(line string) {
+			select {
+			case lines <- OutputLine{Stream: stream, Bytes: []byte(line), Time: time.Now()}:
+			case <-ctx.Done():
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		finishers = append(finishers, finish)
+		return &lineWriter{fn: deliver, split: c.split}, nil
+	}
+	finish := func() {
+		for _, f := range finishers {
+			f()
+		}
+	}
+
+	stdout, err := send(OutputStdout)
+	if err != nil {
+		return fail(err)
+	}
+	c.Stdout = stdout
+
+	var stderr *lineWriter
+	if !c.mergeStderr {
+		if stderr, err = send(OutputStderr); err != nil {
+			finish()
+			return fail(err)
+		}
+		c.captureStderr(stderr)
+	}
+
+	if err := c.Start(); err != nil {
+		finish()
+		return fail(err)
+	}
+
+	go func() {
+		stop := context.AfterFunc(ctx, func() { c.abort(ctx.Err()) })
+		err := c.Wait()
+		stop()
+
+		stdout.Flush()
+		if stderr != nil {
+			stderr.Flush()
+		}
+		finish()
+		if err == nil {
+			err = stdout.Err()
+		}
+		if err == nil && stderr != nil {
+			err = stderr.Err()
+		}
+
+		close(lines)
+		errc <- err
+		close(errc)
+	}()
+
+	return lines, errc
+}
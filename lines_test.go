@@ -0,0 +1,87 @@
+package exex_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestCmd_Lines(t *testing.T) {
+	lines, errc := exex.Command(os.Args[0], strconv.Quote("one\ntwo\n\nthree")).Apply(
+		exex.WithEnv("TEST_MAIN=print"),
+	).Lines(context.Background())
+
+	var got []string
+	for l := range lines {
+		if l.Stream != exex.OutputStdout {
+			t.Errorf("expecting %v, got %v", exex.OutputStdout, l.Stream)
+		}
+		if l.Time.IsZero() {
+			t.Error("expecting a timestamp")
+		}
+		got = append(got, string(l.Bytes))
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{"one", "two", "", "three"}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expecting %q, got %q", exp, got)
+	}
+}
+
+func TestCmd_LinesStderr(t *testing.T) {
+	lines, errc := exex.Command(os.Args[0], "2", "first\nsecond\n").Apply(
+		exex.WithEnv("TEST_MAIN=exit"),
+	).Lines(context.Background())
+
+	var got []string
+	for l := range lines {
+		if l.Stream != exex.OutputStderr {
+			t.Errorf("expecting %v, got %v", exex.OutputStderr, l.Stream)
+		}
+		got = append(got, string(l.Bytes))
+	}
+	assertErr(t, <-errc, "first\nsecond\n")
+
+	exp := []string{"first", "second"}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expecting %q, got %q", exp, got)
+	}
+}
+
+func TestCmd_LinesCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lines, errc := exex.Command(os.Args[0]).Apply(
+		exex.WithEnv("TEST_MAIN=hang"),
+	).Lines(ctx)
+
+	for l := range lines {
+		if l.Stream == exex.OutputStdout && string(l.Bytes) == "ready" {
+			cancel()
+		}
+	}
+
+	err := <-errc
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expecting context.Canceled, got %v", err)
+	}
+}
+
+func TestCmd_LinesStartError(t *testing.T) {
+	lines, errc := exex.Command("non-existing-command").Lines(context.Background())
+	if _, ok := <-lines; ok {
+		t.Error("expecting no lines")
+	}
+	if err := <-errc; err == nil {
+		t.Fatal("expecting an error")
+	}
+}
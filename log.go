@@ -0,0 +1,101 @@
+package exex
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"time"
+)
+
+// DefaultLogger is the logger used by commands without one set with
+// WithLogger. If nil, commands are not logged.
+var DefaultLogger *slog.Logger
+
+// LogLevels are the levels at which the events of commands are
+// logged.
+var LogLevels = struct {
+	// Start is the level of the message logged when a command starts.
+	Start slog.Level
+
+	// Success is the level of the message logged when a command
+	// finishes successfully.
+	Success slog.Level
+
+	// Failure is the level of the message logged when a command fails
+	// to start or finishes with an error.
+	Failure slog.Level
+}{
+	Start:   slog.LevelDebug,
+	Success: slog.LevelInfo,
+	Failure: slog.LevelError,
+}
+
+// WithLogger sets the logger used to log when the command starts and
+// finishes, instead of DefaultLogger. The messages include the
+// binary, its redacted arguments, working directory and, once
+// finished, its duration and exit code, and are logged at the levels
+// set in LogLevels. Commands marked with WithQuiet are not logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Cmd) { c.logger = l }
+}
+
+func (c *Cmd) log() *slog.Logger {
+	if c.quiet {
+		return nil
+	}
+	if c.logger != nil {
+		return c.logger
+	}
+	return DefaultLogger
+}
+
+func (c *Cmd) logAttrs() []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("binary", filepath.Base(c.Path)),
+		slog.Any("args", RedactArgs(c.Args)),
+	}
+	if c.Dir != "" {
+		attrs = append(attrs, slog.String("dir", c.Dir))
+	}
+	return attrs
+}
+
+func (c *Cmd) logStart(err error) {
+	l := c.log()
+	if l == nil {
+		return
+	}
+
+	attrs := c.logAttrs()
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+		l.LogAttrs(c.Context(), LogLevels.Failure, "command failed to start", attrs...)
+		return
+	}
+
+	if c.Process != nil {
+		attrs = append(attrs, slog.Int("pid", c.Process.Pid))
+	}
+	l.LogAttrs(c.Context(), LogLevels.Start, "command started", attrs...)
+}
+
+func (c *Cmd) logFinish(err error) {
+	l := c.log()
+	if l == nil || !c.running {
+		return
+	}
+
+	attrs := append(c.logAttrs(), slog.Duration("duration", time.Since(c.started)))
+	level := LogLevels.Success
+	if r := c.result; r != nil {
+		attrs = append(attrs, slog.Int("exit_code", r.ExitCode))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+		level = LogLevels.Failure
+	}
+
+	// Use a context that is not done, as the one of the command may
+	// be the reason why it finished.
+	l.LogAttrs(context.WithoutCancel(c.Context()), level, "command finished", attrs...)
+}
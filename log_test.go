@@ -0,0 +1,85 @@
+package exex_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var e map[string]any
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	err := exex.Command(os.Args[0], "--token", "s3cr3t").Apply(exex.WithLogger(logger)).Run()
+	assertErr(t, err, "error: --token s3cr3t")
+
+	if strings.Contains(buf.String(), "s3cr3t") {
+		t.Fatalf("expecting arguments to be redacted:\n%s", &buf)
+	}
+
+	entries := logEntries(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("expecting 2 log entries, got %d:\n%s", len(entries), &buf)
+	}
+
+	start, finish := entries[0], entries[1]
+	if start["msg"] != "command started" || start["level"] != "DEBUG" {
+		t.Errorf("unexpected start entry %v", start)
+	}
+	if start["binary"] != filepath.Base(os.Args[0]) || start["pid"] == nil {
+		t.Errorf("unexpected start entry %v", start)
+	}
+	if finish["msg"] != "command finished" || finish["level"] != "ERROR" {
+		t.Errorf("unexpected finish entry %v", finish)
+	}
+	if finish["exit_code"] != 1.0 || finish["duration"] == nil || finish["error"] == nil {
+		t.Errorf("unexpected finish entry %v", finish)
+	}
+}
+
+func TestDefaultLogger(t *testing.T) {
+	var buf bytes.Buffer
+	exex.DefaultLogger = slog.New(slog.NewJSONHandler(&buf, nil))
+	defer func() { exex.DefaultLogger = nil }()
+
+	err := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=version")).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exex.Command(os.Args[0]).Apply(exex.WithQuiet()).Run()
+	exex.Command("/nonexistent/tool").Run()
+
+	entries := logEntries(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("expecting 2 log entries, got %d:\n%s", len(entries), &buf)
+	}
+	if e := entries[0]; e["msg"] != "command finished" || e["level"] != "INFO" || e["exit_code"] != 0.0 {
+		t.Errorf("unexpected entry %v", e)
+	}
+	if e := entries[1]; e["msg"] != "command failed to start" || e["level"] != "ERROR" {
+		t.Errorf("unexpected entry %v", e)
+	}
+}
@@ -0,0 +1,60 @@
+package exex
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// LookPathAll is like LookPath, but returns every executable named
+// file in the directories named by the PATH environment variable, in
+// order, instead of only the first one, e.g. to detect executables
+// shadowed by others. Relative directories are ignored, and so is
+// PATH if file contains a path separator, as LookPath does. The error
+// is an *Error wrapping ErrNotFound if no executable is found.
+func LookPathAll(file string) ([]string, error) {
+	if hasSeparator(file) {
+		p, err := LookPath(file)
+		if err != nil {
+			return nil, err
+		}
+		return []string{p}, nil
+	}
+
+	paths := lookPathIn(file, filepath.SplitList(os.Getenv("PATH")), false)
+	if len(paths) == 0 {
+		return nil, &Error{Name: file, Err: ErrNotFound}
+	}
+	return paths, nil
+}
+
+// lookPathIn returns the executables named file in the absolute
+// directories dirs, in order, or only the first one if first is true.
+func lookPathIn(file string, dirs []string, first bool) []string {
+	var (
+		paths []string
+		seen  = make(map[string]bool)
+	)
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			continue
+		}
+		p, err := exec.LookPath(filepath.Join(dir, file))
+		if err != nil || seen[p] {
+			continue
+		}
+		if first {
+			return []string{p}
+		}
+		seen[p] = true
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// hasSeparator reports whether file contains a path separator, in
+// which case it is not searched in PATH.
+func hasSeparator(file string) bool {
+	return strings.ContainsAny(file, `/`+string(filepath.Separator))
+}
@@ -0,0 +1,55 @@
+package exex_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestLookPathAll(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires executable scripts")
+	}
+
+	var dirs []string
+	for _, name := range []string{"a", "b", "c", "d"} {
+		dir := filepath.Join(t.TempDir(), name)
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		dirs = append(dirs, dir)
+	}
+	for i, perm := range []os.FileMode{0o755, 0o644, 0, 0o755} {
+		if perm == 0 {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dirs[i], "tool"), []byte("#!/bin/sh\n"), perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", strings.Join([]string{dirs[0], "relative", dirs[1], dirs[2], dirs[0], dirs[3]}, string(filepath.ListSeparator)))
+
+	got, err := exex.LookPathAll("tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dirs[0], "tool"), filepath.Join(dirs[3], "tool")}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expecting %q, got %q", want, got)
+	}
+
+	got, err = exex.LookPathAll(want[1])
+	if err != nil || !slices.Equal(got, want[1:]) {
+		t.Fatalf("expecting %q, got %q, %v", want[1:], got, err)
+	}
+
+	if _, err := exex.LookPathAll("missing"); !errors.Is(err, exex.ErrNotFound) {
+		t.Fatalf("expecting ErrNotFound, got %v", err)
+	}
+}
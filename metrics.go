@@ -0,0 +1,296 @@
+package exex
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsHook receives metrics about the execution of commands from a
+// MetricsRunner. It allows exporting them to any metrics system;
+// Collector is an implementation exposing them in the Prometheus text
+// format.
+//
+// Implementations must be safe for concurrent use.
+type MetricsHook interface {
+	// Started is called right before starting a command.
+	Started(binary string)
+
+	// Finished is called once a command reported to Started
+	// finished, or failed to start.
+	Finished(m CommandMetrics)
+}
+
+// CommandMetrics holds the metrics of a finished command.
+type CommandMetrics struct {
+	// Binary is the base name of the executable.
+	Binary string
+
+	// ExitCode is the exit code of the command, or -1 if it failed to
+	// start or was terminated by a signal.
+	ExitCode int
+
+	// Duration is the time elapsed since the command was started.
+	Duration time.Duration
+
+	// Err is the error returned by the command, if any.
+	Err error
+}
+
+// MetricsRunner is a Runner reporting the execution of commands to
+// Hook. Commands marked with WithQuiet are not reported.
+type MetricsRunner struct {
+	// Runner executes the commands. If nil, LocalRunner is used.
+	Runner Runner
+
+	// Hook receives the metrics.
+	Hook MetricsHook
+
+	mu      sync.Mutex
+	started map[*Cmd]time.Time
+}
+
+// Start implements Runner.
+func (r *MetricsRunner) Start(c *Cmd) error {
+	if c.Quiet() {
+		return r.runner().Start(c)
+	}
+
+	binary := filepath.Base(c.Path)
+	r.Hook.Started(binary)
+
+	start := time.Now()
+	if err := r.runner().Start(c); err != nil {
+		r.Hook.Finished(CommandMetrics{Binary: binary, ExitCode: -1, Duration: time.Since(start), Err: err})
+		return err
+	}
+
+	r.mu.Lock()
+	if r.started == nil {
+		r.started = make(map[*Cmd]time.Time)
+	}
+	r.started[c] = start
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Wait implements Runner.
+func (r *MetricsRunner) Wait(c *Cmd) error {
+	err := r.runner().Wait(c)
+
+	r.mu.Lock()
+	start, ok := r.started[c]
+	delete(r.started, c)
+	r.mu.Unlock()
+
+	if ok {
+		m := CommandMetrics{
+			Binary:   filepath.Base(c.Path),
+			ExitCode: -1,
+			Duration: time.Since(start),
+			Err:      err,
+		}
+		switch {
+		case c.ProcessState != nil:
+			m.ExitCode = c.ProcessState.ExitCode()
+		case err == nil:
+			m.ExitCode = 0
+		default:
+			if code, ok := ExitCode(err); ok {
+				m.ExitCode = code
+			}
+		}
+		r.Hook.Finished(m)
+	}
+
+	return err
+}
+
+func (r *MetricsRunner) runner() Runner {
+	if r.Runner != nil {
+		return r.Runner
+	}
+	return LocalRunner{}
+}
+
+// DefaultBuckets are the default upper bounds, in seconds, of the
+// buckets of the duration histogram of a Collector.
+var DefaultBuckets = []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900}
+
+// Collector is a MetricsHook that aggregates the metrics of the
+// commands and exposes them in the Prometheus text exposition format,
+// through WriteTo or as an http.Handler:
+//
+//   - exex_command_runs_total, a counter of the commands run, by
+//     binary and exit code.
+//   - exex_command_duration_seconds, a histogram of the duration of
+//     the commands, by binary.
+//   - exex_commands_running, a gauge of the commands currently
+//     running, by binary.
+//
+// The zero value is ready to use.
+type Collector struct {
+	// Buckets are the upper bounds, in seconds and in increasing
+	// order, of the buckets of the duration histogram. Defaults to
+	// DefaultBuckets. It must not be changed once in use.
+	Buckets []float64
+
+	mu        sync.Mutex
+	runs      map[runKey]uint64
+	durations map[string]*histogram
+	running   map[string]int64
+}
+
+type runKey struct {
+	binary   string
+	exitCode int
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Started implements MetricsHook.
+func (c *Collector) Started(binary string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.init()
+	c.running[binary]++
+}
+
+// Finished implements MetricsHook.
+func (c *Collector) Finished(m CommandMetrics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.init()
+	c.running[m.Binary]--
+	c.runs[runKey{m.Binary, m.ExitCode}]++
+
+	buckets := c.buckets()
+	h := c.durations[m.Binary]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(buckets))}
+		c.durations[m.Binary] = h
+	}
+
+	secs := m.Duration.Seconds()
+	h.count++
+	h.sum += secs
+	if i := sort.SearchFloat64s(buckets, secs); i < len(buckets) {
+		h.counts[i]++
+	}
+}
+
+func (c *Collector) init() {
+	if c.runs == nil {
+		c.runs = make(map[runKey]uint64)
+		c.durations = make(map[string]*histogram)
+		c.running = make(map[string]int64)
+	}
+}
+
+func (c *Collector) buckets() []float64 {
+	if c.Buckets != nil {
+		return c.Buckets
+	}
+	return DefaultBuckets
+}
+
+// WriteTo writes the metrics to w in the Prometheus text exposition
+// format.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	bw.WriteString("# HELP exex_command_runs_total Number of commands run.\n")
+	bw.WriteString("# TYPE exex_command_runs_total counter\n")
+	keys := make([]runKey, 0, len(c.runs))
+	for k := range c.runs {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].binary != keys[j].binary {
+			return keys[i].binary < keys[j].binary
+		}
+		return keys[i].exitCode < keys[j].exitCode
+	})
+	for _, k := range keys {
+		bw.WriteString(`exex_command_runs_total{binary="` + escapeLabel(k.binary) + `",exit_code="` + strconv.Itoa(k.exitCode) + `"} `)
+		bw.WriteString(strconv.FormatUint(c.runs[k], 10) + "\n")
+	}
+
+	bw.WriteString("# HELP exex_command_duration_seconds Duration of the commands run.\n")
+	bw.WriteString("# TYPE exex_command_duration_seconds histogram\n")
+	buckets := c.buckets()
+	for _, binary := range sortedKeys(c.durations) {
+		h := c.durations[binary]
+		label := `binary="` + escapeLabel(binary) + `"`
+
+		var cum uint64
+		for i, le := range buckets {
+			cum += h.counts[i]
+			bw.WriteString("exex_command_duration_seconds_bucket{" + label + `,le="` + formatFloat(le) + `"} `)
+			bw.WriteString(strconv.FormatUint(cum, 10) + "\n")
+		}
+		bw.WriteString("exex_command_duration_seconds_bucket{" + label + `,le="+Inf"} ` + strconv.FormatUint(h.count, 10) + "\n")
+		bw.WriteString("exex_command_duration_seconds_sum{" + label + "} " + formatFloat(h.sum) + "\n")
+		bw.WriteString("exex_command_duration_seconds_count{" + label + "} " + strconv.FormatUint(h.count, 10) + "\n")
+	}
+
+	bw.WriteString("# HELP exex_commands_running Number of commands currently running.\n")
+	bw.WriteString("# TYPE exex_commands_running gauge\n")
+	for _, binary := range sortedKeys(c.running) {
+		bw.WriteString(`exex_commands_running{binary="` + escapeLabel(binary) + `"} `)
+		bw.WriteString(strconv.FormatInt(c.running[binary], 10) + "\n")
+	}
+
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition
+// format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func formatFloat(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) }
@@ -0,0 +1,55 @@
+package exex_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestMetricsRunner(t *testing.T) {
+	col := &exex.Collector{Buckets: []float64{0.001, 60}}
+	r := &exex.MetricsRunner{Hook: col}
+	bin := filepath.Base(os.Args[0])
+
+	exex.Command(os.Args[0], "fail").Apply(exex.WithRunner(r)).Run()
+	exex.Command(os.Args[0]).Apply(exex.WithRunner(r), exex.WithEnv("TEST_MAIN=version")).Run()
+	exex.Command(os.Args[0]).Apply(exex.WithRunner(r), exex.WithEnv("TEST_MAIN=version")).Run()
+	exex.Command(os.Args[0]).Apply(exex.WithRunner(r), exex.WithQuiet()).Run()
+	exex.Command("/nonexistent/tool").Apply(exex.WithRunner(r)).Run()
+
+	var buf bytes.Buffer
+	n, err := col.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("expecting %d bytes written, got %d", buf.Len(), n)
+	}
+
+	out := buf.String()
+	for _, exp := range []string{
+		`exex_command_runs_total{binary="` + bin + `",exit_code="0"} 2`,
+		`exex_command_runs_total{binary="` + bin + `",exit_code="1"} 1`,
+		`exex_command_runs_total{binary="tool",exit_code="-1"} 1`,
+		`exex_command_duration_seconds_bucket{binary="` + bin + `",le="60"} 3`,
+		`exex_command_duration_seconds_bucket{binary="` + bin + `",le="+Inf"} 3`,
+		`exex_command_duration_seconds_count{binary="` + bin + `"} 3`,
+		`exex_commands_running{binary="` + bin + `"} 0`,
+		"# TYPE exex_command_duration_seconds histogram",
+	} {
+		if !strings.Contains(out, exp+"\n") {
+			t.Errorf("expecting %q in:\n%s", exp, out)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	col.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Body.String() != out {
+		t.Errorf("unexpected HTTP response:\n%s", rec.Body)
+	}
+}
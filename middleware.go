@@ -0,0 +1,133 @@
+package exex
+
+import "sync"
+
+// Middleware wraps a Runner to add behavior around the execution of
+// commands, such as logging, metrics, policy checks or redaction.
+// The returned Runner should delegate to next to execute the command.
+type Middleware func(next Runner) Runner
+
+var (
+	middlewaresMu sync.Mutex
+	middlewares   []*used
+)
+
+// used holds the middlewares added by a call to Use.
+type used struct {
+	mws []Middleware
+}
+
+// Use adds middlewares applied to every command started from then on,
+// regardless of its Runner. The first middleware added is the
+// outermost one, i.e. the first to see the command when starting it.
+// The returned function removes them, so that they do not apply to
+// the commands started afterwards.
+func Use(mw ...Middleware) (remove func()) {
+	u := &used{mws: mw}
+
+	middlewaresMu.Lock()
+	defer middlewaresMu.Unlock()
+
+	middlewares = append(middlewares[:len(middlewares):len(middlewares)], u)
+
+	return func() {
+		middlewaresMu.Lock()
+		defer middlewaresMu.Unlock()
+
+		for i, x := range middlewares {
+			if x == u {
+				middlewares = append(middlewares[:i:i], middlewares[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// WithMiddleware adds middlewares applied only to the command, inside
+// the ones added with Use.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *Cmd) { c.middlewares = append(c.middlewares, mw...) }
+}
+
+// packageMiddlewares returns the middlewares added with Use, in order.
+func packageMiddlewares() []Middleware {
+	middlewaresMu.Lock()
+	defer middlewaresMu.Unlock()
+
+	var mws []Middleware
+	for _, u := range middlewares {
+		mws = append(mws, u.mws...)
+	}
+	return mws
+}
+
+// chain returns the Runner of the command wrapped with the package
+// and command middlewares.
+func (c *Cmd) chain() Runner {
+	mws := packageMiddlewares()
+
+	r := c.runnerOrDefault()
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		r = c.middlewares[i](r)
+	}
+	for i := len(mws) - 1; i >= 0; i-- {
+		r = mws[i](r)
+	}
+
+	return r
+}
+
+// Hooks is a Middleware calling functions before starting commands and
+// after they finished:
+//
+//	exex.Use(exex.Hooks{
+//		After: func(c *exex.Cmd, err error) error {
+//			log.Printf("%s: %v", c, err)
+//			return err
+//		},
+//	}.Middleware())
+type Hooks struct {
+	// Before, if not nil, is called right before starting the
+	// command. If it returns an error, the command is not started and
+	// Start returns it.
+	Before func(c *Cmd) error
+
+	// After, if not nil, is called once the command finished, or
+	// failed to start, with the resulting error, and returns the
+	// error to report instead. At this point the ProcessState field
+	// of the command is set if it was executed as a local process.
+	After func(c *Cmd, err error) error
+}
+
+// Middleware returns the Middleware calling the hooks.
+func (h Hooks) Middleware() Middleware {
+	return func(next Runner) Runner { return &hooksRunner{next: next, hooks: h} }
+}
+
+type hooksRunner struct {
+	next  Runner
+	hooks Hooks
+}
+
+func (r *hooksRunner) Start(c *Cmd) error {
+	if r.hooks.Before != nil {
+		if err := r.hooks.Before(c); err != nil {
+			return err
+		}
+	}
+	if err := r.next.Start(c); err != nil {
+		return r.after(c, err)
+	}
+	return nil
+}
+
+func (r *hooksRunner) Wait(c *Cmd) error {
+	return r.after(c, r.next.Wait(c))
+}
+
+func (r *hooksRunner) after(c *Cmd, err error) error {
+	if r.hooks.After == nil {
+		return err
+	}
+	return r.hooks.After(c, err)
+}
@@ -0,0 +1,102 @@
+package exex_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+// tracer returns a middleware appending name to calls when starting
+// and waiting for commands.
+func tracer(name string, calls *[]string) exex.Middleware {
+	return exex.Hooks{
+		Before: func(c *exex.Cmd) error {
+			*calls = append(*calls, "before "+name)
+			return nil
+		},
+		After: func(c *exex.Cmd, err error) error {
+			*calls = append(*calls, "after "+name)
+			return err
+		},
+	}.Middleware()
+}
+
+func TestMiddleware(t *testing.T) {
+	var calls []string
+
+	// Package middlewares apply to every command run until removed,
+	// so only record the ones of this test.
+	remove := exex.Use(func(next exex.Runner) exex.Runner {
+		return exex.Hooks{
+			Before: func(c *exex.Cmd) error {
+				if len(c.Args) > 1 && c.Args[1] == "middleware" {
+					calls = append(calls, "before package")
+				}
+				return nil
+			},
+		}.Middleware()(next)
+	})
+	t.Cleanup(remove)
+
+	err := exex.Command(os.Args[0], "middleware").Apply(
+		exex.WithMiddleware(tracer("outer", &calls), tracer("inner", &calls)),
+	).Run()
+	assertErr(t, err, "error: middleware")
+
+	exp := []string{"before package", "before outer", "before inner", "after inner", "after outer"}
+	if len(calls) != len(exp) {
+		t.Fatalf("expecting %q, got %q", exp, calls)
+	}
+	for i := range exp {
+		if calls[i] != exp[i] {
+			t.Fatalf("expecting %q, got %q", exp, calls)
+		}
+	}
+
+	remove()
+	calls = nil
+	exex.Command(os.Args[0], "middleware").Run()
+	if len(calls) != 0 {
+		t.Fatalf("expecting removed middleware not to be called, got %q", calls)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	errPolicy := errors.New("not allowed")
+
+	t.Run("before", func(t *testing.T) {
+		r := &recordingRunner{}
+		err := exex.Command(os.Args[0], "denied").Apply(
+			exex.WithMiddleware(exex.Hooks{
+				Before: func(*exex.Cmd) error { return errPolicy },
+			}.Middleware()),
+			exex.WithRunner(r),
+		).Run()
+		if !errors.Is(err, errPolicy) {
+			t.Fatalf("expecting policy error, got %v", err)
+		}
+		if len(r.started) > 0 {
+			t.Fatal("not expecting command to start")
+		}
+	})
+
+	t.Run("after", func(t *testing.T) {
+		var state *os.ProcessState
+		err := exex.Command(os.Args[0], "ignored").Apply(
+			exex.WithMiddleware(exex.Hooks{
+				After: func(c *exex.Cmd, err error) error {
+					state = c.ProcessState
+					return nil
+				},
+			}.Middleware()),
+		).Run()
+		if err != nil {
+			t.Fatalf("expecting error to be replaced, got %v", err)
+		}
+		if state == nil || state.ExitCode() != 1 {
+			t.Fatalf("expecting process state with exit code 1, got %v", state)
+		}
+	})
+}
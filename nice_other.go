@@ -0,0 +1,9 @@
+//go:build !unix && !windows
+
+package exex
+
+func (c *Cmd) setPriorityClass() error {
+	return unsupported("nice")
+}
+
+func setNice(pid, n int) error { return nil }
@@ -0,0 +1,18 @@
+//go:build unix
+
+package exex
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func (c *Cmd) setPriorityClass() error { return nil }
+
+// setNice sets the niceness of the process pid.
+func setNice(pid, n int) error {
+	if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, n); err != nil {
+		return fmt.Errorf("exex: nice: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package exex
+
+import "syscall"
+
+// Process creation flags of the priority classes.
+const (
+	idlePriorityClass        = 0x00000040
+	belowNormalPriorityClass = 0x00004000
+	aboveNormalPriorityClass = 0x00008000
+	highPriorityClass        = 0x00000080
+)
+
+// setPriorityClass makes the command start with the priority class
+// closest to its niceness.
+func (c *Cmd) setPriorityClass() error {
+	var class uint32
+	switch n := *c.nice; {
+	case n >= 10:
+		class = idlePriorityClass
+	case n > 0:
+		class = belowNormalPriorityClass
+	case n <= -10:
+		class = highPriorityClass
+	case n < 0:
+		class = aboveNormalPriorityClass
+	default:
+		return nil
+	}
+
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.CreationFlags |= class
+	return nil
+}
+
+// setNice does nothing, as the priority class is set when starting
+// the command.
+func setNice(pid, n int) error { return nil }
@@ -0,0 +1,121 @@
+package exex
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Option configures a Cmd before it is executed.
+type Option func(*Cmd)
+
+// Apply applies the given options to c and returns it, allowing
+// one-liners such as:
+//
+//	exex.Command("git", "status").Apply(exex.WithDir("/repo")).Run()
+func (c *Cmd) Apply(opts ...Option) *Cmd {
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithDir sets the working directory of the command.
+func WithDir(dir string) Option {
+	return func(c *Cmd) { c.Dir = dir }
+}
+
+// WithEnv adds the given environment variables, in the form
+// "key=value", to the environment of the command. If the command
+// environment was not set, the variables are added to the environment
+// of the current process.
+func WithEnv(env ...string) Option {
+	return func(c *Cmd) {
+		if c.Env == nil {
+			c.Env = os.Environ()
+		}
+		c.Env = append(c.Env, env...)
+	}
+}
+
+// WithPath sets the PATH of the command to dirs, independently of the
+// PATH of the current process, and resolves its executable in them,
+// e.g. to run the tools installed in a node_modules/.bin directory.
+// Relative directories are relative to the working directory of the
+// command when the option is applied, or the current one if not set.
+func WithPath(dirs ...string) Option {
+	return func(c *Cmd) {
+		abs := make([]string, 0, len(dirs))
+		for _, d := range dirs {
+			if !filepath.IsAbs(d) {
+				if a, err := filepath.Abs(filepath.Join(c.Dir, d)); err == nil {
+					d = a
+				}
+			}
+			abs = append(abs, d)
+		}
+		WithEnv("PATH=" + strings.Join(abs, string(filepath.ListSeparator)))(c)
+
+		if len(c.Args) == 0 || hasSeparator(c.Args[0]) {
+			return
+		}
+		if paths := lookPathIn(c.Args[0], abs, true); len(paths) > 0 {
+			c.Path, c.Err = paths[0], nil
+		} else {
+			c.Path, c.Err = c.Args[0], &Error{Name: c.Args[0], Err: ErrNotFound}
+		}
+	}
+}
+
+// WithCleanEnv replaces the environment of the command with only the
+// variables in allowlist taken from the environment of the current
+// process, e.g. "PATH", "HOME" or "LANG", so that it does not inherit
+// other variables by accident. Variables added afterwards with
+// WithEnv are kept.
+//
+// Note that on Windows os/exec always adds SYSTEMROOT, as it is
+// required to run most programs.
+func WithCleanEnv(allowlist ...string) Option {
+	return func(c *Cmd) {
+		var env Env
+		if len(allowlist) > 0 {
+			env.Inherit(allowlist...)
+		}
+		c.Env = env.Environ()
+	}
+}
+
+// WithStdin sets the standard input of the command.
+func WithStdin(r io.Reader) Option {
+	return func(c *Cmd) { c.Stdin = r }
+}
+
+// WithStdout sets the standard output of the command.
+func WithStdout(w io.Writer) Option {
+	return func(c *Cmd) { c.Stdout = w }
+}
+
+// WithQuiet marks the command as quiet: Runners and hooks that
+// record commands, e.g. for auditing, metrics or logging, should not
+// record it. Useful for noisy high-frequency probes.
+func WithQuiet() Option {
+	return func(c *Cmd) { c.quiet = true }
+}
+
+// WithAuditTag adds tag to the audit tags of the command, which
+// Runners and hooks that record commands should attach to their
+// records, e.g. to tell apart maintenance tasks.
+func WithAuditTag(tag string) Option {
+	return func(c *Cmd) { c.auditTags = append(c.auditTags, tag) }
+}
+
+// Quiet reports whether the command was marked with WithQuiet.
+func (c *Cmd) Quiet() bool {
+	return c.quiet
+}
+
+// AuditTags returns the tags added to the command with WithAuditTag.
+func (c *Cmd) AuditTags() []string {
+	return c.auditTags
+}
@@ -0,0 +1,140 @@
+package exex_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestOptions(t *testing.T) {
+	t.Run("stdin and stdout", func(t *testing.T) {
+		var stdout bytes.Buffer
+		err := exex.Command(os.Args[0]).Apply(
+			exex.WithEnv("TEST_MAIN=cat"),
+			exex.WithStdin(strings.NewReader("hello")),
+			exex.WithStdout(&stdout),
+		).Run()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := stdout.String(); got != "hello" {
+			t.Fatalf("expecting %q, got %q", "hello", got)
+		}
+	})
+
+	t.Run("dir", func(t *testing.T) {
+		dir, err := filepath.EvalSymlinks(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var stdout bytes.Buffer
+		err = exex.Command(os.Args[0]).Apply(
+			exex.WithEnv("TEST_MAIN=pwd"),
+			exex.WithDir(dir),
+			exex.WithStdout(&stdout),
+		).Run()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := stdout.String(); got != dir {
+			t.Fatalf("expecting %q, got %q", dir, got)
+		}
+	})
+
+	t.Run("env", func(t *testing.T) {
+		var stdout bytes.Buffer
+		err := exex.RunCommand(exec.Command(os.Args[0], "FOO"),
+			exex.WithEnv("TEST_MAIN=getenv"),
+			exex.WithEnv("FOO=bar"),
+			exex.WithStdout(&stdout),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := stdout.String(); got != "bar" {
+			t.Fatalf("expecting %q, got %q", "bar", got)
+		}
+	})
+
+	t.Run("clean env", func(t *testing.T) {
+		t.Setenv("EXEX_ALLOWED", "yes")
+		t.Setenv("EXEX_SECRET", "s3cr3t")
+
+		for name, exp := range map[string]string{"EXEX_ALLOWED": "yes", "EXEX_SECRET": ""} {
+			var stdout bytes.Buffer
+			err := exex.Command(os.Args[0], name).Apply(
+				exex.WithCleanEnv("EXEX_ALLOWED", "EXEX_MISSING"),
+				exex.WithEnv("TEST_MAIN=getenv"),
+				exex.WithStdout(&stdout),
+			).Run()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := stdout.String(); got != exp {
+				t.Fatalf("expecting %s=%q, got %q", name, exp, got)
+			}
+		}
+	})
+
+	t.Run("capture", func(t *testing.T) {
+		err := exex.RunCommand(exec.Command(os.Args[0]), exex.WithEnv("FOO=bar"))
+		assertErr(t, err, "error:")
+	})
+}
+
+func TestWithPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires executable scripts")
+	}
+
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "node_modules", ".bin")
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bin, "tool"), []byte("#!/bin/sh\necho \"$PATH\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exex.Command("tool").Apply(exex.WithDir(dir), exex.WithPath("node_modules/.bin", "/usr/bin", "/bin"))
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := bin + ":/usr/bin:/bin\n"; string(out) != exp {
+		t.Fatalf("expecting %q, got %q", exp, out)
+	}
+	if cmd.Path != filepath.Join(bin, "tool") {
+		t.Fatalf("unexpected path %q", cmd.Path)
+	}
+
+	err = exex.Command("sh", "-c", "true").Apply(exex.WithPath(bin)).Run()
+	if !errors.Is(err, exex.ErrNotFound) {
+		t.Fatalf("expecting ErrNotFound, got %v", err)
+	}
+}
+
+func TestWithQuiet(t *testing.T) {
+	cmd := exex.Command(os.Args[0])
+	if cmd.Quiet() {
+		t.Fatal("not expecting command to be quiet")
+	}
+	if !cmd.Apply(exex.WithQuiet()).Quiet() {
+		t.Fatal("expecting command to be quiet")
+	}
+}
+
+func TestWithAuditTag(t *testing.T) {
+	cmd := exex.Command(os.Args[0]).Apply(exex.WithAuditTag("maintenance"), exex.WithAuditTag("nightly"))
+	if tags := cmd.AuditTags(); len(tags) != 2 || tags[0] != "maintenance" || tags[1] != "nightly" {
+		t.Fatalf("unexpected audit tags %q", tags)
+	}
+}
@@ -0,0 +1,73 @@
+package exex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WithOrphanCheck runs the command in its own process group and,
+// once it finished, looks for processes left running in that group,
+// such as background children still writing to its output. They are
+// reported in Result.Orphans and, if kill is true, killed. Otherwise,
+// if the command succeeded, Wait returns an *OrphanError.
+//
+// Processes that leave the process group, e.g. daemons calling
+// setsid, are not found. The PIDs of the orphans can only be listed
+// on systems with /proc, like Linux; elsewhere Result.Orphans and
+// OrphanError.PIDs are empty even if orphans are found. Starting the
+// command fails with an *UnsupportedFeatureError on systems without
+// process groups, like Windows, unless set otherwise with
+// WithDegradation.
+func WithOrphanCheck(kill bool) Option {
+	return func(c *Cmd) { c.orphans = &orphanCheck{kill: kill} }
+}
+
+type orphanCheck struct {
+	kill bool
+}
+
+// OrphanError is the error returned by Wait when processes started
+// by a command set with WithOrphanCheck are still running after it
+// finished.
+type OrphanError struct {
+	// PIDs are the process IDs of the orphans, if known.
+	PIDs []int
+}
+
+func (e *OrphanError) Error() string {
+	if len(e.PIDs) == 0 {
+		return "exex: orphaned processes still running"
+	}
+
+	pids := make([]string, len(e.PIDs))
+	for i, pid := range e.PIDs {
+		pids[i] = strconv.Itoa(pid)
+	}
+	return fmt.Sprintf("exex: orphaned processes still running: %s", strings.Join(pids, ", "))
+}
+
+// checkOrphans looks for the orphans of the finished command, reports
+// them in its Result and returns the error that Wait must return.
+func (c *Cmd) checkOrphans(err error) error {
+	if c.orphans == nil || c.Process == nil {
+		return err
+	}
+
+	pids, found := findOrphans(c.Process.Pid)
+	if !found {
+		return err
+	}
+	if c.result != nil {
+		c.result.Orphans = pids
+	}
+
+	if c.orphans.kill {
+		killGroup(c.Process.Pid)
+		return err
+	}
+	if err == nil {
+		err = &OrphanError{PIDs: pids}
+	}
+	return err
+}
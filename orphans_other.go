@@ -0,0 +1,11 @@
+//go:build !unix
+
+package exex
+
+func (c *Cmd) setProcessGroup() error {
+	return unsupported("orphan check")
+}
+
+func findOrphans(pgid int) ([]int, bool) { return nil, false }
+
+func killGroup(pgid int) {}
@@ -0,0 +1,80 @@
+//go:build unix
+
+package exex_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+func runDaemon(t *testing.T, kill bool) (*exex.Cmd, int, error) {
+	var stdout bytes.Buffer
+	cmd := exex.Command(os.Args[0]).Apply(
+		exex.WithEnv("TEST_MAIN=daemon"),
+		exex.WithStdout(&stdout),
+		exex.WithOrphanCheck(kill),
+	)
+	cmd.WaitDelay = 100 * time.Millisecond
+
+	err := cmd.Run()
+
+	pid, perr := strconv.Atoi(strings.SplitN(stdout.String(), "\n", 2)[0])
+	if perr != nil {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+	t.Cleanup(func() {
+		if p, err := os.FindProcess(pid); err == nil {
+			p.Kill()
+		}
+	})
+
+	return cmd, pid, err
+}
+
+func TestWithOrphanCheck(t *testing.T) {
+	t.Run("report", func(t *testing.T) {
+		cmd, pid, err := runDaemon(t, false)
+
+		var orphanErr *exex.OrphanError
+		if !errors.As(err, &orphanErr) {
+			t.Fatalf("expecting *exex.OrphanError, got %v", err)
+		}
+		if _, err := os.Stat("/proc"); err == nil {
+			if len(orphanErr.PIDs) != 1 || orphanErr.PIDs[0] != pid {
+				t.Fatalf("expecting orphan %d, got %v", pid, orphanErr.PIDs)
+			}
+			if got := cmd.Result().Orphans; len(got) != 1 || got[0] != pid {
+				t.Fatalf("expecting orphan %d in Result, got %v", pid, got)
+			}
+		}
+	})
+
+	t.Run("kill", func(t *testing.T) {
+		_, pid, err := runDaemon(t, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		if b, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat"); err == nil && !bytes.Contains(b, []byte(") Z ")) {
+			t.Fatalf("expecting orphan %d to be killed", pid)
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		err := exex.Command(os.Args[0], "0").Apply(
+			exex.WithEnv("TEST_MAIN=exit"),
+			exex.WithOrphanCheck(false),
+		).Run()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+//go:build unix
+
+package exex
+
+import (
+	"sort"
+	"syscall"
+)
+
+// setProcessGroup makes the command run in its own process group.
+func (c *Cmd) setProcessGroup() error {
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.Setpgid = true
+	c.SysProcAttr.Pgid = 0
+	return nil
+}
+
+// findOrphans reports whether there are processes in the process
+// group pgid, listing them if possible.
+func findOrphans(pgid int) ([]int, bool) {
+	if pids, ok := procGroup(pgid); ok {
+		return pids, len(pids) > 0
+	}
+	return nil, syscall.Kill(-pgid, 0) == nil
+}
+
+// procGroup lists the processes of the process group pgid that are
+// not zombies using /proc, reporting false if it is not available.
+func procGroup(pgid int) ([]int, bool) {
+	procs, ok := procFS()
+	if !ok {
+		return nil, false
+	}
+
+	var pids []int
+	for _, p := range procs {
+		if !p.zombie && p.pgid == pgid {
+			pids = append(pids, p.pid)
+		}
+	}
+	sort.Ints(pids)
+
+	return pids, true
+}
+
+func killGroup(pgid int) {
+	syscall.Kill(-pgid, syscall.SIGKILL)
+}
@@ -0,0 +1,163 @@
+// Package otelexex provides OpenTelemetry tracing for the commands
+// executed with exex.
+//
+// Runner wraps another exex.Runner and records a span for each
+// command, from Start until Wait, as a child of the span in the
+// context of the command, if any:
+//
+//	exex.DefaultRunner = &otelexex.Runner{}
+//
+//	err := exex.CommandContext(ctx, "git", "fetch").Run()
+package otelexex
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/inkel/exex"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/inkel/exex/otelexex"
+
+// Runner is an exex.Runner that traces the execution of commands.
+//
+// Spans are named after the executed binary and have the following
+// attributes: process.executable.path, process.command_args,
+// process.exit.code and, if the command was terminated by a signal,
+// process.exit.signal. The span status is set to error if the command
+// fails to start or exits with a non-zero status.
+//
+// Commands marked with exex.WithQuiet are not traced, and the tags
+// added with exex.WithAuditTag are recorded in the exex.audit_tags
+// attribute.
+//
+// The trace context is propagated to the command through its
+// environment, e.g. in the TRACEPARENT variable, so commands that
+// are also instrumented can continue the trace.
+type Runner struct {
+	// Runner executes the commands. If nil, exex.LocalRunner is used.
+	Runner exex.Runner
+
+	// TracerProvider creates the tracer used. If nil, the global
+	// provider is used.
+	TracerProvider trace.TracerProvider
+
+	// Propagator injects the trace context into the environment of
+	// the commands. If nil, the global propagator is used.
+	Propagator propagation.TextMapPropagator
+
+	// Args, if not nil, is called to get the arguments recorded in
+	// process.command_args. Defaults to exex.RedactArgs. Return nil
+	// to not record the arguments.
+	Args func(args []string) []string
+
+	mu    sync.Mutex
+	spans map[*exex.Cmd]trace.Span
+}
+
+// Start implements exex.Runner.
+func (r *Runner) Start(c *exex.Cmd) error {
+	if c.Quiet() {
+		return r.runner().Start(c)
+	}
+
+	tp := r.TracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	args := exex.RedactArgs
+	if r.Args != nil {
+		args = r.Args
+	}
+
+	attrs := []attribute.KeyValue{attribute.String("process.executable.path", c.Path)}
+	if a := args(c.Args); a != nil {
+		attrs = append(attrs, attribute.StringSlice("process.command_args", a))
+	}
+	if tags := c.AuditTags(); len(tags) > 0 {
+		attrs = append(attrs, attribute.StringSlice("exex.audit_tags", tags))
+	}
+
+	ctx, span := tp.Tracer(instrumentationName).Start(c.Context(), "exec "+filepath.Base(c.Path),
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attrs...),
+	)
+
+	prop := r.Propagator
+	if prop == nil {
+		prop = otel.GetTextMapPropagator()
+	}
+	carrier := propagation.MapCarrier{}
+	prop.Inject(ctx, carrier)
+	if len(carrier) > 0 {
+		if c.Env == nil {
+			c.Env = os.Environ()
+		}
+		for k, v := range carrier {
+			c.Env = append(c.Env, strings.ToUpper(k)+"="+v)
+		}
+	}
+
+	if err := r.runner().Start(c); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		return err
+	}
+
+	r.mu.Lock()
+	if r.spans == nil {
+		r.spans = make(map[*exex.Cmd]trace.Span)
+	}
+	r.spans[c] = span
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Wait implements exex.Runner.
+func (r *Runner) Wait(c *exex.Cmd) error {
+	err := r.runner().Wait(c)
+
+	r.mu.Lock()
+	span, ok := r.spans[c]
+	delete(r.spans, c)
+	r.mu.Unlock()
+
+	if !ok {
+		return err
+	}
+	defer span.End()
+
+	if ps := c.ProcessState; ps != nil {
+		span.SetAttributes(attribute.Int("process.exit.code", ps.ExitCode()))
+		if ws, ok := ps.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			span.SetAttributes(attribute.String("process.exit.signal", ws.Signal().String()))
+		}
+	} else if code, ok := exex.ExitCode(err); ok {
+		span.SetAttributes(attribute.Int("process.exit.code", code))
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
+
+func (r *Runner) runner() exex.Runner {
+	if r.Runner != nil {
+		return r.Runner
+	}
+	return exex.LocalRunner{}
+}
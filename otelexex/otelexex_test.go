@@ -0,0 +1,111 @@
+package otelexex_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+	"github.com/inkel/exex/exextest"
+	"github.com/inkel/exex/otelexex"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRunner(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	fake := &exextest.FakeRunner{}
+	fake.Expect("git", "push", "--token", "s3cr3t").ExitCode(128)
+
+	r := &otelexex.Runner{
+		Runner:         fake,
+		TracerProvider: tp,
+		Propagator:     propagation.TraceContext{},
+	}
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	err := exex.CommandContext(ctx, "git", "push", "--token", "s3cr3t").Apply(exex.WithRunner(r)).Run()
+	parent.End()
+	if code, _ := exex.ExitCode(err); code != 128 {
+		t.Fatalf("expecting exit code 128, got %v", err)
+	}
+
+	spans := sr.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expecting 2 spans, got %d", len(spans))
+	}
+	span := spans[0]
+
+	if span.Name() != "exec git" {
+		t.Errorf("unexpected span name %q", span.Name())
+	}
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("expecting span to be a child of the context span")
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("expecting error status, got %v", span.Status())
+	}
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if v := attrs["process.exit.code"]; v.AsInt64() != 128 {
+		t.Errorf("unexpected exit code attribute %v", v.Emit())
+	}
+	if v := attrs["process.command_args"].Emit(); strings.Contains(v, "s3cr3t") {
+		t.Errorf("expecting redacted arguments, got %s", v)
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("expecting a single call, got %d", len(calls))
+	}
+	var traceparent string
+	for _, kv := range calls[0].Env {
+		if v, ok := strings.CutPrefix(kv, "TRACEPARENT="); ok {
+			traceparent = v
+		}
+	}
+	if !strings.Contains(traceparent, span.SpanContext().SpanID().String()) {
+		t.Errorf("expecting TRACEPARENT with the command span, got %q", traceparent)
+	}
+}
+
+func TestRunnerQuiet(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	fake := &exextest.FakeRunner{}
+	fake.Expect("probe")
+	fake.Expect("backup")
+
+	r := &otelexex.Runner{Runner: fake, TracerProvider: tp}
+
+	if err := exex.Command("probe").Apply(exex.WithRunner(r), exex.WithQuiet()).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := exex.Command("backup").Apply(exex.WithRunner(r), exex.WithAuditTag("maintenance")).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := sr.Ended()
+	if len(spans) != 1 || spans[0].Name() != "exec backup" {
+		t.Fatalf("expecting only the backup span, got %d spans", len(spans))
+	}
+
+	var tags []string
+	for _, kv := range spans[0].Attributes() {
+		if kv.Key == "exex.audit_tags" {
+			tags = kv.Value.AsStringSlice()
+		}
+	}
+	if len(tags) != 1 || tags[0] != "maintenance" {
+		t.Fatalf("unexpected audit tags %q", tags)
+	}
+}
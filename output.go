@@ -0,0 +1,118 @@
+package exex
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// OutputRecords runs the command and returns its standard output
+// split in records terminated by sep, e.g. the NUL byte for the
+// output of find -print0 or git ls-files -z. A trailing separator does
+// not produce an empty record.
+//
+// Errors are reported the same way as Output does.
+func (c *Cmd) OutputRecords(sep byte) ([]string, error) {
+	out, err := c.Output()
+	return splitRecords(out, sep), err
+}
+
+func splitRecords(b []byte, sep byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+
+	b = bytes.TrimSuffix(b, []byte{sep})
+
+	recs := make([]string, 0, bytes.Count(b, []byte{sep})+1)
+	for {
+		i := bytes.IndexByte(b, sep)
+		if i < 0 {
+			return append(recs, string(b))
+		}
+		recs = append(recs, string(b[:i]))
+		b = b[i+1:]
+	}
+}
+
+// OutputLines runs the command and returns the lines of its standard
+// output, without their "\n" or "\r\n" terminators. A trailing line
+// terminator does not produce an empty line.
+//
+// Errors are reported the same way as Output does.
+func (c *Cmd) OutputLines() ([]string, error) {
+	out, err := c.Output()
+	lines := splitRecords(out, '\n')
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+	return lines, err
+}
+
+// CSVOptions configures how OutputCSV parses the output of a
+// command. The zero value parses comma separated values. Refer to the
+// documentation of csv.Reader for the meaning of each field.
+type CSVOptions struct {
+	// Comma is the field delimiter. It defaults to ','; use '\t' to
+	// parse tab separated values.
+	Comma rune
+
+	Comment          rune
+	FieldsPerRecord  int
+	LazyQuotes       bool
+	TrimLeadingSpace bool
+}
+
+// OutputCSV runs the command and returns its standard output parsed
+// as CSV records using opts, which can be nil.
+//
+// If the command fails its error is returned the same way as Output
+// does, and no parsing is attempted.
+func (c *Cmd) OutputCSV(opts *CSVOptions) ([][]string, error) {
+	out, err := c.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	r := csv.NewReader(bytes.NewReader(out))
+	if opts != nil {
+		if opts.Comma != 0 {
+			r.Comma = opts.Comma
+		}
+		r.Comment = opts.Comment
+		r.FieldsPerRecord = opts.FieldsPerRecord
+		r.LazyQuotes = opts.LazyQuotes
+		r.TrimLeadingSpace = opts.TrimLeadingSpace
+	}
+
+	return r.ReadAll()
+}
+
+// OutputJSON runs the command and decodes its standard output as JSON
+// into v, as json.Unmarshal does.
+//
+// If the command fails its error is returned the same way as Output
+// does, and no decoding is attempted. If decoding fails, the error
+// wraps the decoding error; it is not a *CmdError, as the command
+// succeeded.
+func (c *Cmd) OutputJSON(v any) error {
+	out, err := c.Output()
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(out, v); err != nil {
+		return fmt.Errorf("exex: %s: decoding JSON output: %w", c.Path, err)
+	}
+
+	return nil
+}
+
+// OutputJSON creates a Cmd with the given context and decodes its
+// standard output as JSON into v, as *Cmd.OutputJSON does.
+func OutputJSON(ctx context.Context, v any, name string, args ...string) error {
+	return CommandContext(ctx, name, args...).OutputJSON(v)
+}
@@ -0,0 +1,155 @@
+package exex_test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestCmd_OutputRecords(t *testing.T) {
+	tests := map[string]struct {
+		out string
+		exp []string
+	}{
+		"empty":          {"", nil},
+		"single":         {"foo", []string{"foo"}},
+		"trailing":       {"foo\x00bar\x00", []string{"foo", "bar"}},
+		"no trailing":    {"foo\x00bar", []string{"foo", "bar"}},
+		"newlines":       {"foo\nbar\x00baz\x00", []string{"foo\nbar", "baz"}},
+		"empty records":  {"\x00\x00foo\x00", []string{"", "", "foo"}},
+		"only separator": {"\x00", []string{""}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			recs, err := exex.Command(os.Args[0], strconv.Quote(tt.out)).Apply(exex.WithEnv("TEST_MAIN=print")).OutputRecords(0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(recs, tt.exp) {
+				t.Fatalf("expecting %q, got %q", tt.exp, recs)
+			}
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		_, err := exex.Command(os.Args[0], "records").OutputRecords(0)
+		assertErr(t, err, "error: records")
+	})
+}
+
+func TestCmd_OutputCSV(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		out := "name,size\n\"foo, bar\",1\nbaz,2\n"
+		recs, err := exex.Command(os.Args[0], strconv.Quote(out)).Apply(exex.WithEnv("TEST_MAIN=print")).OutputCSV(nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		exp := [][]string{{"name", "size"}, {"foo, bar", "1"}, {"baz", "2"}}
+		if !reflect.DeepEqual(recs, exp) {
+			t.Fatalf("expecting %q, got %q", exp, recs)
+		}
+	})
+
+	t.Run("tsv", func(t *testing.T) {
+		out := "# comment\nname\tsize\nfoo\t1\n"
+		opts := &exex.CSVOptions{Comma: '\t', Comment: '#'}
+		recs, err := exex.Command(os.Args[0], strconv.Quote(out)).Apply(exex.WithEnv("TEST_MAIN=print")).OutputCSV(opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		exp := [][]string{{"name", "size"}, {"foo", "1"}}
+		if !reflect.DeepEqual(recs, exp) {
+			t.Fatalf("expecting %q, got %q", exp, recs)
+		}
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		out := "a,b\nc\n"
+		_, err := exex.Command(os.Args[0], strconv.Quote(out)).Apply(exex.WithEnv("TEST_MAIN=print")).OutputCSV(nil)
+		var pErr *csv.ParseError
+		if !errors.As(err, &pErr) {
+			t.Fatalf("expecting *csv.ParseError, got %T: %[1]v", err)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := exex.Command(os.Args[0], "csv").OutputCSV(nil)
+		assertErr(t, err, "error: csv")
+	})
+}
+
+func TestCmd_OutputLines(t *testing.T) {
+	tests := map[string]struct {
+		out string
+		exp []string
+	}{
+		"empty":       {"", nil},
+		"single":      {"foo", []string{"foo"}},
+		"trailing":    {"foo\nbar\n", []string{"foo", "bar"}},
+		"no trailing": {"foo\nbar", []string{"foo", "bar"}},
+		"crlf":        {"foo\r\nbar\r\n", []string{"foo", "bar"}},
+		"empty lines": {"\n\nfoo\n", []string{"", "", "foo"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			lines, err := exex.Command(os.Args[0], strconv.Quote(tt.out)).Apply(exex.WithEnv("TEST_MAIN=print")).OutputLines()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(lines, tt.exp) {
+				t.Fatalf("expecting %q, got %q", tt.exp, lines)
+			}
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		_, err := exex.Command(os.Args[0], "no", "lines").OutputLines()
+		assertErr(t, err, "error: no lines")
+	})
+}
+
+func TestCmd_OutputJSON(t *testing.T) {
+	var v struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+	err := exex.Command(os.Args[0], strconv.Quote(`{"name":"exex","count":3}`)).Apply(exex.WithEnv("TEST_MAIN=print")).OutputJSON(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "exex" || v.Count != 3 {
+		t.Fatalf("unexpected decoded value %+v", v)
+	}
+
+	t.Run("decode error", func(t *testing.T) {
+		var v map[string]any
+		err := exex.Command(os.Args[0], strconv.Quote("not json")).Apply(exex.WithEnv("TEST_MAIN=print")).OutputJSON(&v)
+
+		var cmdErr *exex.CmdError
+		if errors.As(err, &cmdErr) || errors.Is(err, exex.ExitCodeError(0)) {
+			t.Fatalf("expecting decode error not to be a command error, got %v", err)
+		}
+		if _, ok := exex.ExitCode(err); ok {
+			t.Fatalf("expecting no exit code, got %v", err)
+		}
+		var synErr *json.SyntaxError
+		if !errors.As(err, &synErr) {
+			t.Fatalf("expecting *json.SyntaxError, got %v", err)
+		}
+	})
+
+	t.Run("command error", func(t *testing.T) {
+		var v any
+		err := exex.OutputJSON(context.Background(), &v, os.Args[0], "no", "json")
+		assertErr(t, err, "error: no json")
+	})
+}
@@ -0,0 +1,103 @@
+package exex
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+)
+
+// ErrOutputLimitExceeded is the error reported when a command is
+// killed because of WithMaxOutput.
+var ErrOutputLimitExceeded = errors.New("exex: output limit exceeded")
+
+// WithMaxOutput kills the command once it writes more than n bytes to
+// its standard output and standard error combined, so that broken or
+// hostile commands cannot exhaust the memory of the caller. The bytes
+// beyond n are discarded. The error returned in that case matches
+// ErrOutputLimitExceeded when using errors.Is, even if the command
+// exited successfully before being killed.
+//
+// Both streams are counted even when they are not set, in which case
+// their output is discarded.
+func WithMaxOutput(n int64) Option {
+	return func(c *Cmd) { c.outputLimit = &outputLimit{max: n} }
+}
+
+// WithTruncatedOutput is like WithMaxOutput, but instead of killing
+// the command it lets it run to completion, discarding the bytes
+// beyond n, and reports it in Result.OutputTruncated.
+func WithTruncatedOutput(n int64) Option {
+	return func(c *Cmd) { c.outputLimit = &outputLimit{max: n, truncate: true} }
+}
+
+type outputLimit struct {
+	max      int64
+	truncate bool
+	written  atomic.Int64
+	exceeded atomic.Bool
+}
+
+// limitWriter counts the bytes written on its outputLimit, discarding
+// those beyond it. Writes never fail, so the command writing to it
+// does not receive errors because of the limit.
+type limitWriter struct {
+	w     io.Writer
+	limit *outputLimit
+	cmd   *Cmd
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	l := w.limit
+	n := int64(len(p))
+	total := l.written.Add(n)
+	if total <= l.max {
+		return w.w.Write(p)
+	}
+
+	if room := l.max - (total - n); room > 0 {
+		if _, err := w.w.Write(p[:room]); err != nil {
+			return 0, err
+		}
+	}
+	if l.exceeded.CompareAndSwap(false, true) && !l.truncate {
+		w.cmd.abort(ErrOutputLimitExceeded)
+	}
+	return len(p), nil
+}
+
+// watchOutputLimit wraps the output streams of the command to count
+// the bytes written to them.
+func (c *Cmd) watchOutputLimit() {
+	if c.outputLimit == nil {
+		return
+	}
+
+	wrap := func(w io.Writer) io.Writer {
+		if w == nil {
+			w = io.Discard
+		}
+		return &limitWriter{w: w, limit: c.outputLimit, cmd: c}
+	}
+
+	// Keep both streams sharing the same writer, as exec.Cmd then
+	// uses a single pipe for them.
+	if c.mergeStderr || sameWriter(c.Stdout, c.Stderr) {
+		c.Stdout = wrap(c.Stdout)
+		c.Stderr = c.Stdout
+		return
+	}
+
+	c.Stdout = wrap(c.Stdout)
+	c.Stderr = wrap(c.Stderr)
+}
+
+// checkOutputLimit returns ErrOutputLimitExceeded if the command
+// exceeded the limit set by WithMaxOutput but exited successfully
+// before being killed, as its output is incomplete.
+func (c *Cmd) checkOutputLimit(err error) error {
+	l := c.outputLimit
+	if err == nil && l != nil && !l.truncate && l.exceeded.Load() {
+		return ErrOutputLimitExceeded
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package exex_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithMaxOutput(t *testing.T) {
+	cmd := exex.Command(os.Args[0]).Apply(
+		exex.WithEnv("TEST_MAIN=yes"),
+		exex.WithMaxOutput(1000),
+	)
+
+	out, err := cmd.CombinedOutput()
+	if !errors.Is(err, exex.ErrOutputLimitExceeded) {
+		t.Fatalf("expecting ErrOutputLimitExceeded, got %v", err)
+	}
+	if len(out) != 1000 {
+		t.Fatalf("expecting 1000 bytes, got %d", len(out))
+	}
+	if !cmd.Result().OutputTruncated {
+		t.Fatal("expecting truncated output")
+	}
+}
+
+func TestWithMaxOutputUnderLimit(t *testing.T) {
+	cmd := exex.Command(os.Args[0], "hello\n", "world\n", "0").Apply(
+		exex.WithEnv("TEST_MAIN=streams"),
+		exex.WithMaxOutput(12),
+	)
+
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "hello\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+	if cmd.Result().OutputTruncated {
+		t.Fatal("expecting output not truncated")
+	}
+}
+
+func TestWithTruncatedOutput(t *testing.T) {
+	t.Run("stdout", func(t *testing.T) {
+		cmd := exex.Command(os.Args[0], strings.Repeat("x", 100000), "", "0").Apply(
+			exex.WithEnv("TEST_MAIN=streams"),
+			exex.WithTruncatedOutput(10),
+		)
+
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != "xxxxxxxxxx" {
+			t.Fatalf("unexpected output %q", out)
+		}
+		if !cmd.Result().OutputTruncated {
+			t.Fatal("expecting truncated output")
+		}
+	})
+
+	t.Run("stderr", func(t *testing.T) {
+		cmd := exex.Command(os.Args[0], "hello\n", "world\n", "0").Apply(
+			exex.WithEnv("TEST_MAIN=streams"),
+			exex.WithTruncatedOutput(11),
+		)
+
+		if _, err := cmd.Output(); err != nil {
+			t.Fatal(err)
+		}
+		if !cmd.Result().OutputTruncated {
+			t.Fatal("expecting stderr counted in the limit")
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package exex
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// PathCache memoizes the executables found by LookPath for each value
+// of the PATH environment variable, avoiding searching PATH each time
+// the same command is executed. Failed lookups are not memoized, so
+// that executables installed later are found, and cached executables
+// that no longer exist are looked up again. It is safe for concurrent
+// use.
+type PathCache struct {
+	mu    sync.Mutex
+	paths map[pathKey]string
+}
+
+type pathKey struct {
+	env  string // value of PATH
+	name string
+}
+
+// NewPathCache returns an empty PathCache.
+func NewPathCache() *PathCache {
+	return &PathCache{paths: make(map[pathKey]string)}
+}
+
+// Look is like LookPath, but returns the executable found for name
+// with the current value of PATH, if any, instead of searching PATH
+// again. Names containing path separators are not cached.
+func (pc *PathCache) Look(name string) (string, error) {
+	if hasSeparator(name) {
+		return LookPath(name)
+	}
+
+	key := pathKey{env: os.Getenv("PATH"), name: name}
+
+	pc.mu.Lock()
+	path, ok := pc.paths[key]
+	pc.mu.Unlock()
+	if ok {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	path, err := LookPath(name)
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if err != nil {
+		delete(pc.paths, key)
+		return path, err
+	}
+	pc.paths[key] = path
+	return path, nil
+}
+
+// Invalidate removes the executables cached for the given names, or
+// all of them if no names are given, e.g. after installing a new
+// version of a command.
+func (pc *PathCache) Invalidate(names ...string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if len(names) == 0 {
+		clear(pc.paths)
+		return
+	}
+	for key := range pc.paths {
+		for _, name := range names {
+			if key.name == name {
+				delete(pc.paths, key)
+			}
+		}
+	}
+}
+
+// Command is like the package level Command, but resolves name using
+// the cache.
+func (pc *PathCache) Command(name string, args ...string) *Cmd {
+	cmd := exec.Command(pc.lookup(name), args...)
+	cmd.Args[0] = name
+	return &Cmd{Cmd: cmd}
+}
+
+// CommandContext is like the package level CommandContext, but
+// resolves name using the cache.
+func (pc *PathCache) CommandContext(ctx context.Context, name string, args ...string) *Cmd {
+	cmd := exec.CommandContext(ctx, pc.lookup(name), args...)
+	cmd.Args[0] = name
+	return &Cmd{Cmd: cmd, ctx: ctx}
+}
+
+// lookup returns the executable cached for name, or name if it is not
+// found, so that exec.Command reports the error.
+func (pc *PathCache) lookup(name string) string {
+	if path, err := pc.Look(name); err == nil {
+		return path
+	}
+	return name
+}
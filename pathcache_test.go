@@ -0,0 +1,69 @@
+package exex_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestPathCache(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires executable scripts")
+	}
+
+	a, b := t.TempDir(), t.TempDir()
+	t.Setenv("PATH", strings.Join([]string{a, b, "/usr/bin:/bin"}, string(filepath.ListSeparator)))
+
+	install := func(dir, out string) string {
+		t.Helper()
+		p := filepath.Join(dir, "tool")
+		if err := os.WriteFile(p, []byte("#!/bin/sh\necho "+out+"\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	pc := exex.NewPathCache()
+	if _, err := pc.Look("tool"); !errors.Is(err, exex.ErrNotFound) {
+		t.Fatalf("expecting ErrNotFound, got %v", err)
+	}
+
+	pb := install(b, "b")
+	if got, err := pc.Look("tool"); err != nil || got != pb {
+		t.Fatalf("expecting %q, got %q, %v", pb, got, err)
+	}
+
+	pa := install(a, "a")
+	if got, _ := pc.Look("tool"); got != pb {
+		t.Fatalf("expecting cached %q, got %q", pb, got)
+	}
+	out, err := pc.Command("tool").Output()
+	if err != nil || string(out) != "b\n" {
+		t.Fatalf("expecting output of cached tool, got %q, %v", out, err)
+	}
+
+	pc.Invalidate("tool")
+	if got, _ := pc.Look("tool"); got != pa {
+		t.Fatalf("expecting %q after Invalidate, got %q", pa, got)
+	}
+
+	if err := os.Remove(pa); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := pc.Look("tool"); got != pb {
+		t.Fatalf("expecting %q after removing %q, got %q", pb, pa, got)
+	}
+
+	cmd := pc.Command("tool", "x")
+	if cmd.Path != pb || cmd.Args[0] != "tool" {
+		t.Fatalf("unexpected command %q %q", cmd.Path, cmd.Args)
+	}
+	if err := pc.Command("missing").Run(); !errors.Is(err, exex.ErrNotFound) {
+		t.Fatalf("expecting ErrNotFound, got %v", err)
+	}
+}
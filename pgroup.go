@@ -0,0 +1,55 @@
+package exex
+
+import (
+	"errors"
+	"os"
+)
+
+// WithProcessGroup runs the command in a new process group, so that
+// killing it, because its context is done, it is aborted or
+// terminated with Terminate, signals all the processes in the group,
+// e.g. the children of a shell, instead of only the command.
+//
+// On Unix systems the command is started with Setpgid, and the group
+// is signalled as a whole. On Windows it is started with
+// CREATE_NEW_PROCESS_GROUP, and killing it kills its process tree
+// with taskkill. Elsewhere starting the command fails with an
+// *UnsupportedFeatureError, unless set otherwise with
+// WithDegradation.
+//
+// Processes that leave the group, e.g. daemons calling setsid, are
+// not signalled.
+func WithProcessGroup() Option {
+	return func(c *Cmd) {
+		c.group = true
+		if c.ctx != nil && c.stopSignal == nil {
+			c.Cancel = c.kill
+		}
+	}
+}
+
+// signal sends sig to the process of the command, or to its process
+// group if set with WithProcessGroup.
+func (c *Cmd) signal(sig os.Signal) error {
+	if c.group {
+		return signalGroup(c.Process, sig)
+	}
+	return c.Process.Signal(sig)
+}
+
+// kill kills the process of the command, or its process group if set
+// with WithProcessGroup, or its job object if set with WithJobObject.
+// Commands executed by a Runner other than LocalRunner might not have
+// one.
+func (c *Cmd) kill() error {
+	if c.Process == nil {
+		return nil
+	}
+	if c.job != nil && c.job.terminate() == nil {
+		return nil
+	}
+	if err := c.signal(os.Kill); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !unix && !windows
+
+package exex
+
+import "os"
+
+func (c *Cmd) setNewGroup() error {
+	return unsupported("process group")
+}
+
+func signalGroup(p *os.Process, sig os.Signal) error {
+	return p.Signal(sig)
+}
@@ -0,0 +1,73 @@
+//go:build unix
+
+package exex_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+// alive reports whether the process pid is running and not a zombie.
+func alive(pid int) bool {
+	if b, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat"); err == nil {
+		return !bytes.Contains(b, []byte(") Z "))
+	}
+	return syscall.Kill(pid, 0) == nil
+}
+
+func TestWithProcessGroup(t *testing.T) {
+	tests := map[string]struct {
+		opts  []exex.Option
+		alive bool
+	}{
+		"group":    {opts: []exex.Option{exex.WithProcessGroup()}},
+		"graceful": {opts: []exex.Option{exex.WithGracefulStop(syscall.SIGTERM, time.Second), exex.WithProcessGroup()}},
+		"child":    {alive: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			r, w := io.Pipe()
+			cmd := exex.CommandContext(ctx, "/bin/sh", "-c", "sleep 1000 & echo $!; wait").Apply(
+				append([]exex.Option{exex.WithEnv("PATH=/usr/bin:/bin")}, tt.opts...)...,
+			)
+			cmd.Stdout = w
+			cmd.WaitDelay = 100 * time.Millisecond
+			if err := cmd.Start(); err != nil {
+				t.Fatal(err)
+			}
+
+			line, _ := bufio.NewReader(r).ReadString('\n')
+			pid, err := strconv.Atoi(strings.TrimSpace(line))
+			if err != nil {
+				t.Fatalf("unexpected output %q", line)
+			}
+			t.Cleanup(func() { syscall.Kill(pid, syscall.SIGKILL) })
+			go io.Copy(io.Discard, r)
+
+			cancel()
+			if err := cmd.Wait(); err == nil {
+				t.Fatal("expecting an error")
+			}
+			w.Close()
+
+			time.Sleep(50 * time.Millisecond)
+			if got := alive(pid); got != tt.alive {
+				t.Fatalf("expecting grandchild alive to be %t, got %t", tt.alive, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package exex
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func (c *Cmd) setNewGroup() error {
+	return c.setProcessGroup()
+}
+
+// signalGroup sends sig to the process group led by p.
+func signalGroup(p *os.Process, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return p.Signal(sig)
+	}
+	if err := syscall.Kill(-p.Pid, s); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return os.ErrProcessDone
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package exex
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+func (c *Cmd) setNewGroup() error {
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+	return nil
+}
+
+// signalGroup kills the process tree of p, as Windows cannot signal
+// process groups.
+func signalGroup(p *os.Process, sig os.Signal) error {
+	if sig != os.Kill {
+		return p.Signal(sig)
+	}
+	err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(p.Pid)).Run()
+	if err != nil {
+		// The process may have exited already, or taskkill may not
+		// be available.
+		return p.Kill()
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package exex
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PipelineError is the error returned by Pipeline when any of its
+// stages fails.
+type PipelineError struct {
+	// Errors holds the error of each stage of the pipeline, in
+	// order, being nil for the stages that succeeded.
+	Errors []error
+}
+
+func (e *PipelineError) Error() string {
+	var b strings.Builder
+	b.WriteString("exex: pipeline failed")
+	for i, err := range e.Errors {
+		if err != nil {
+			fmt.Fprintf(&b, "; stage %d: %v", i, err)
+		}
+	}
+	return b.String()
+}
+
+// Unwrap returns the non-nil errors of the stages, so errors.Is and
+// errors.As match any of them.
+func (e *PipelineError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Pipeline connects the standard output of each command to the
+// standard input of the next one, emulating the shell's "a | b | c",
+// runs all of them concurrently and waits for them to finish.
+//
+// If any stage fails the returned error is a *PipelineError holding
+// the error of every stage, each of them with its own captured
+// standard error as described in *Cmd.Run. As with the shell, a
+// stage terminated by a broken pipe because the next one stopped
+// reading its input is not considered to have failed.
+//
+// The Stdin field of all the commands but the first, and the Stdout
+// field of all the commands but the last, must be nil.
+func Pipeline(cmds ...*Cmd) error {
+	if len(cmds) == 0 {
+		return errors.New("exex: empty pipeline")
+	}
+	for i, c := range cmds {
+		if i > 0 && c.Stdin != nil {
+			return fmt.Errorf("exex: pipeline stage %d: Stdin already set", i)
+		}
+		if i < len(cmds)-1 && c.Stdout != nil {
+			return fmt.Errorf("exex: pipeline stage %d: Stdout already set", i)
+		}
+	}
+
+	errs := make([]error, len(cmds))
+	started := 0
+
+	// closeStdin closes our copy of the read end of the pipe feeding
+	// the i-th command, which it holds its own copy of once started.
+	closeStdin := func(i int) {
+		if i > 0 {
+			cmds[i].Stdin.(*os.File).Close()
+		}
+	}
+
+	for i, c := range cmds {
+		var w *os.File
+		if i < len(cmds)-1 {
+			r, pw, err := os.Pipe()
+			if err != nil {
+				errs[i] = err
+				closeStdin(i)
+				break
+			}
+			c.Stdout = pw
+			cmds[i+1].Stdin = r
+			w = pw
+		}
+
+		err := c.Start()
+		closeStdin(i)
+		if w != nil {
+			w.Close()
+		}
+		if err != nil {
+			errs[i] = err
+			if w != nil {
+				closeStdin(i + 1)
+			}
+			break
+		}
+		started++
+	}
+
+	if started < len(cmds) {
+		for _, c := range cmds[:started] {
+			c.kill()
+		}
+	}
+
+	for i, c := range cmds[:started] {
+		err := c.Wait()
+		if err != nil && i < len(cmds)-1 && brokenPipe(err) {
+			err = nil
+		}
+		errs[i] = err
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return &PipelineError{Errors: errs}
+		}
+	}
+
+	return nil
+}
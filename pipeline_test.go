@@ -0,0 +1,80 @@
+package exex_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestPipeline(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := exex.Pipeline(
+			exex.Command(os.Args[0], strconv.Quote("foo\nbar\n")).Apply(exex.WithEnv("TEST_MAIN=print")),
+			exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=cat")),
+			exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=cat"), exex.WithStdout(&out)),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, exp := out.String(), "foo\nbar\n"; got != exp {
+			t.Fatalf("expecting %q, got %q", exp, got)
+		}
+	})
+
+	t.Run("failed stage", func(t *testing.T) {
+		err := exex.Pipeline(
+			exex.Command(os.Args[0], strconv.Quote("foo")).Apply(exex.WithEnv("TEST_MAIN=print")),
+			exex.Command(os.Args[0], "middle"),
+			exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=cat")),
+		)
+
+		var pErr *exex.PipelineError
+		if !errors.As(err, &pErr) {
+			t.Fatalf("expecting *exex.PipelineError, got %v", err)
+		}
+		if len(pErr.Errors) != 3 {
+			t.Fatalf("expecting 3 errors, got %d", len(pErr.Errors))
+		}
+		if pErr.Errors[0] != nil || pErr.Errors[2] != nil {
+			t.Fatalf("expecting only the middle stage to fail, got %v", pErr.Errors)
+		}
+		assertErr(t, pErr.Errors[1], "error: middle")
+		assertErr(t, err, "error: middle")
+	})
+
+	t.Run("broken pipe", func(t *testing.T) {
+		err := exex.Pipeline(
+			exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=yes")),
+			exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=version")),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		err := exex.Pipeline(
+			exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=yes")),
+			exex.Command("foobarbazquux"),
+		)
+		if !errors.Is(err, exex.ErrNotFound) {
+			t.Fatalf("expecting exex.ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("stdout set", func(t *testing.T) {
+		err := exex.Pipeline(
+			exex.Command(os.Args[0]).Apply(exex.WithStdout(&bytes.Buffer{})),
+			exex.Command(os.Args[0]),
+		)
+		if err == nil {
+			t.Fatal("expecting an error")
+		}
+	})
+}
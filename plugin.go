@@ -0,0 +1,312 @@
+package exex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrPluginVersion is returned when the host and the plugin do
+	// not support any common protocol version.
+	ErrPluginVersion = errors.New("exex: no common plugin protocol version")
+
+	// ErrPluginTimeout is the cause of the failure of a plugin that
+	// did not complete the handshake or answer keepalives in time.
+	ErrPluginTimeout = errors.New("exex: plugin not responding")
+)
+
+// PluginConfig configures the connection to a plugin started with
+// StartPlugin.
+type PluginConfig struct {
+	// Versions are the protocol versions of the messages supported
+	// by the host, in order of preference. The plugin chooses the
+	// first one it supports. Defaults to version 1.
+	Versions []int
+
+	// HandshakeTimeout is how long to wait for the plugin to answer
+	// the handshake. Defaults to 10 seconds.
+	HandshakeTimeout time.Duration
+
+	// Keepalive, if positive, is the interval between the keepalive
+	// messages sent to the plugin. If the plugin does not send
+	// anything for three intervals, while the host is not holding its
+	// messages waiting for Receive, it is killed.
+	Keepalive time.Duration
+}
+
+// PluginConn is a connection exchanging JSON messages with a plugin,
+// a command talking a simple protocol over its standard input and
+// output, while its standard error is captured and reported as
+// described in Run. Each message is a JSON object on its own line:
+//
+//	{"type":"hello","versions":[2,1]}  handshake sent by the host
+//	{"type":"hello","version":2}       handshake answered by the plugin
+//	{"type":"data","data":...}         a message sent with Send
+//	{"type":"ping"}, {"type":"pong"}   keepalives
+//
+// The host side of the connection is returned by StartPlugin, and the
+// plugin side by ServePlugin. Send and Receive can be called
+// concurrently with each other.
+type PluginConn struct {
+	// Version is the negotiated protocol version.
+	Version int
+
+	cmd  *Cmd
+	r    io.Closer // read end of the stdout pipe of cmd
+	w    io.WriteCloser
+	dec  *json.Decoder
+	wmu  sync.Mutex
+	enc  *json.Encoder
+	msgs chan json.RawMessage
+	err  error // read error, set before msgs is closed
+	last atomic.Int64
+	busy atomic.Bool // whether a message is waiting for Receive
+	stop chan struct{}
+	once sync.Once
+	done chan struct{} // closed once the reader finished
+}
+
+type pluginMsg struct {
+	Type     string          `json:"type"`
+	Versions []int           `json:"versions,omitempty"`
+	Version  int             `json:"version,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+}
+
+// StartPlugin starts the command as a plugin and performs the
+// handshake, negotiating the protocol version. The standard input and
+// output of the command must not be set. If the handshake fails, the
+// command is killed and the error reported.
+func StartPlugin(c *Cmd, cfg PluginConfig) (*PluginConn, error) {
+	if c.Stdin != nil {
+		return nil, errors.New("exex: Stdin already set")
+	}
+	if c.Stdout != nil || c.mergeStderr {
+		return nil, errors.New("exex: Stdout already set")
+	}
+	if len(cfg.Versions) == 0 {
+		cfg.Versions = []int{1}
+	}
+	if cfg.HandshakeTimeout <= 0 {
+		cfg.HandshakeTimeout = 10 * time.Second
+	}
+
+	stdin, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	r, stdout, err := os.Pipe()
+	if err != nil {
+		stdin.Close()
+		w.Close()
+		return nil, err
+	}
+	c.Stdin, c.Stdout = stdin, stdout
+
+	err = c.Start()
+	stdin.Close()
+	stdout.Close()
+	if err != nil {
+		w.Close()
+		r.Close()
+		return nil, err
+	}
+
+	p := newPluginConn(r, w)
+	p.cmd, p.r = c, r
+
+	t := time.AfterFunc(cfg.HandshakeTimeout, func() { c.abort(ErrPluginTimeout) })
+	err = p.write(pluginMsg{Type: "hello", Versions: cfg.Versions})
+	if err == nil {
+		var m pluginMsg
+		if err = p.dec.Decode(&m); err == nil {
+			switch {
+			case m.Type != "hello":
+				err = fmt.Errorf("exex: unexpected plugin handshake %q", m.Type)
+			case m.Error != "" || !slices.Contains(cfg.Versions, m.Version):
+				err = ErrPluginVersion
+			}
+			p.Version = m.Version
+		}
+	}
+	t.Stop()
+
+	if err != nil {
+		c.kill()
+		w.Close()
+		io.Copy(io.Discard, r)
+		// Report the error of the command too, e.g. its exit status
+		// and standard error if it failed to start.
+		if werr := c.Wait(); werr != nil && !errors.Is(err, ErrPluginVersion) {
+			err = fmt.Errorf("%w (%w)", err, werr)
+		}
+		return nil, err
+	}
+
+	p.start()
+	if cfg.Keepalive > 0 {
+		go p.keepalive(cfg.Keepalive)
+	}
+
+	return p, nil
+}
+
+// ServePlugin is the plugin side of StartPlugin, usually called with
+// os.Stdin and os.Stdout: it answers the handshake choosing the first
+// version offered by the host among versions, and then keepalives.
+// If there is no common version, the host is told so and
+// ErrPluginVersion returned.
+func ServePlugin(r io.Reader, w io.Writer, versions ...int) (*PluginConn, error) {
+	wc, ok := w.(io.WriteCloser)
+	if !ok {
+		wc = nopWriteCloser{w}
+	}
+	p := newPluginConn(r, wc)
+
+	var m pluginMsg
+	if err := p.dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	if m.Type != "hello" {
+		return nil, fmt.Errorf("exex: unexpected plugin handshake %q", m.Type)
+	}
+
+	for _, v := range m.Versions {
+		if slices.Contains(versions, v) {
+			p.Version = v
+			break
+		}
+	}
+	if p.Version == 0 {
+		p.write(pluginMsg{Type: "hello", Error: ErrPluginVersion.Error()})
+		return nil, ErrPluginVersion
+	}
+	if err := p.write(pluginMsg{Type: "hello", Version: p.Version}); err != nil {
+		return nil, err
+	}
+
+	p.start()
+	return p, nil
+}
+
+func newPluginConn(r io.Reader, w io.WriteCloser) *PluginConn {
+	p := &PluginConn{
+		w:    w,
+		dec:  json.NewDecoder(r),
+		enc:  json.NewEncoder(w),
+		msgs: make(chan json.RawMessage),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	p.last.Store(time.Now().UnixNano())
+	return p
+}
+
+// Send sends v, encoded as JSON, to the other side of the connection.
+func (p *PluginConn) Send(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return p.write(pluginMsg{Type: "data", Data: data})
+}
+
+// Receive waits for the next message sent by the other side of the
+// connection and decodes it into v. It returns io.EOF once the other
+// side closed the connection.
+func (p *PluginConn) Receive(v any) error {
+	data, ok := <-p.msgs
+	if !ok {
+		return p.err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Close closes the connection. On the host side, it closes the
+// standard input of the plugin, which should exit then, discards the
+// messages not received yet, and returns the error of waiting for the
+// command, as Wait does.
+func (p *PluginConn) Close() error {
+	p.once.Do(func() { close(p.stop) })
+	err := p.w.Close()
+	if p.cmd == nil {
+		return err
+	}
+
+	<-p.done
+	p.r.Close()
+	return p.cmd.Wait()
+}
+
+func (p *PluginConn) write(m pluginMsg) error {
+	p.wmu.Lock()
+	defer p.wmu.Unlock()
+	return p.enc.Encode(m)
+}
+
+// start reads the messages of the other side in the background,
+// answering keepalives.
+func (p *PluginConn) start() {
+	go func() {
+		defer close(p.done)
+		defer close(p.msgs)
+
+		for {
+			var m pluginMsg
+			if err := p.dec.Decode(&m); err != nil {
+				p.err = err
+				return
+			}
+			p.last.Store(time.Now().UnixNano())
+
+			switch m.Type {
+			case "ping":
+				p.write(pluginMsg{Type: "pong"})
+			case "data":
+				p.busy.Store(true)
+				select {
+				case p.msgs <- m.Data:
+				case <-p.stop:
+				}
+				p.busy.Store(false)
+				p.last.Store(time.Now().UnixNano())
+			}
+		}
+	}()
+}
+
+// keepalive pings the plugin every interval, killing it if it does
+// not send anything for three intervals.
+func (p *PluginConn) keepalive(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+		case <-p.stop:
+			return
+		case <-p.done:
+			return
+		}
+
+		// Keepalives are not read while a message waits for Receive.
+		if !p.busy.Load() && time.Since(time.Unix(0, p.last.Load())) > 3*interval {
+			p.cmd.abort(ErrPluginTimeout)
+			return
+		}
+		p.write(pluginMsg{Type: "ping"})
+	}
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
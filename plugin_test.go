@@ -0,0 +1,92 @@
+package exex_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+func TestStartPlugin(t *testing.T) {
+	plugin := func(args ...string) *exex.Cmd {
+		return exex.Command(os.Args[0], args...).Apply(exex.WithEnv("TEST_MAIN=plugin"))
+	}
+
+	p, err := exex.StartPlugin(plugin("1,2"), exex.PluginConfig{
+		Versions:  []int{3, 2, 1},
+		Keepalive: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Version != 2 {
+		t.Fatalf("expecting version 2, got %d", p.Version)
+	}
+
+	for _, msg := range []string{"hello", "world"} {
+		if err := p.Send(msg); err != nil {
+			t.Fatal(err)
+		}
+		// Let keepalives go by.
+		time.Sleep(30 * time.Millisecond)
+
+		var got map[string]any
+		if err := p.Receive(&got); err != nil {
+			t.Fatal(err)
+		}
+		if exp := map[string]any{"version": 2.0, "echo": msg}; !reflect.DeepEqual(got, exp) {
+			t.Fatalf("expecting %v, got %v", exp, got)
+		}
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Receive(new(any)); err != io.EOF {
+		t.Fatalf("expecting io.EOF after Close, got %v", err)
+	}
+
+	t.Run("version", func(t *testing.T) {
+		_, err := exex.StartPlugin(plugin("3"), exex.PluginConfig{Versions: []int{1, 2}})
+		if !errors.Is(err, exex.ErrPluginVersion) {
+			t.Fatalf("expecting ErrPluginVersion, got %v", err)
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		p, err := exex.StartPlugin(plugin("1", "boom"), exex.PluginConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Receive(new(any)); err != io.EOF {
+			t.Fatalf("expecting io.EOF, got %v", err)
+		}
+		assertErr(t, p.Close(), "boom")
+	})
+
+	t.Run("keepalive", func(t *testing.T) {
+		cmd := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=plugin-hang"))
+		p, err := exex.StartPlugin(cmd, exex.PluginConfig{Keepalive: 10 * time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Receive(new(any)); err != io.EOF {
+			t.Fatalf("expecting io.EOF, got %v", err)
+		}
+		if err := p.Close(); !errors.Is(err, exex.ErrPluginTimeout) {
+			t.Fatalf("expecting ErrPluginTimeout, got %v", err)
+		}
+	})
+
+	t.Run("handshake", func(t *testing.T) {
+		cmd := exex.Command(os.Args[0], "silent").Apply(exex.WithEnv("TEST_MAIN=plugin-hang"))
+		_, err := exex.StartPlugin(cmd, exex.PluginConfig{HandshakeTimeout: 10 * time.Millisecond})
+		if !errors.Is(err, exex.ErrPluginTimeout) {
+			t.Fatalf("expecting ErrPluginTimeout, got %v", err)
+		}
+	})
+}
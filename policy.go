@@ -0,0 +1,107 @@
+package exex
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrDenied is matched, using errors.Is, by the errors returned when
+// starting a command denied by the Policy set with SetPolicy.
+var ErrDenied = errors.New("exex: denied by policy")
+
+// ErrNotAllowed is the error returned by the Policy of Allowlist for
+// the executables not in the list.
+var ErrNotAllowed = errors.New("exex: executable not in allowlist")
+
+// Policy decides whether commands can be executed, e.g. based on the
+// path of their executable, their arguments or environment. Allow
+// returns nil if c can be executed, or an error explaining why not.
+type Policy interface {
+	Allow(c *Cmd) error
+}
+
+// PolicyFunc is a function implementing Policy.
+type PolicyFunc func(c *Cmd) error
+
+// Allow returns f(c).
+func (f PolicyFunc) Allow(c *Cmd) error { return f(c) }
+
+// DeniedError is the error returned when starting a command denied by
+// the Policy set with SetPolicy.
+type DeniedError struct {
+	// Path is the path of the denied command.
+	Path string
+
+	// Args holds the command line arguments, including the command
+	// as Args[0], redacted as described in RedactArgs.
+	Args []string
+
+	// Err is the error returned by the Policy.
+	Err error
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("exex: %s denied by policy: %v", e.Path, e.Err)
+}
+
+func (e *DeniedError) Unwrap() error { return e.Err }
+
+// Is reports whether target is ErrDenied.
+func (e *DeniedError) Is(target error) bool { return target == ErrDenied }
+
+var (
+	policyMu sync.RWMutex
+	policy   Policy
+)
+
+// SetPolicy sets the Policy checked before starting every command,
+// regardless of its Runner, or removes it if p is nil. Commands
+// denied by p are not started, and Start returns a *DeniedError.
+func SetPolicy(p Policy) {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+
+	policy = p
+}
+
+// checkPolicy returns a *DeniedError if the policy denies the command.
+func (c *Cmd) checkPolicy() error {
+	policyMu.RLock()
+	p := policy
+	policyMu.RUnlock()
+
+	if p == nil {
+		return nil
+	}
+	if err := p.Allow(c); err != nil {
+		return &DeniedError{Path: c.Path, Args: RedactArgs(c.Args), Err: err}
+	}
+	return nil
+}
+
+// Allowlist returns a Policy allowing only the given executables.
+// Names containing a path separator must match the path of the
+// command, while other names match its base name, e.g. "git" allows
+// both "/usr/bin/git" and "/usr/local/bin/git", and also "git.exe".
+func Allowlist(names ...string) Policy {
+	paths := make(map[string]bool, len(names))
+	bases := make(map[string]bool, len(names))
+	for _, name := range names {
+		if strings.ContainsRune(name, filepath.Separator) || strings.ContainsRune(name, '/') {
+			paths[filepath.Clean(name)] = true
+		} else {
+			bases[name] = true
+		}
+	}
+
+	return PolicyFunc(func(c *Cmd) error {
+		base := filepath.Base(c.Path)
+		if paths[filepath.Clean(c.Path)] || bases[base] || bases[strings.TrimSuffix(base, ".exe")] {
+			return nil
+		}
+		return ErrNotAllowed
+	})
+}
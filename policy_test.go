@@ -0,0 +1,54 @@
+package exex_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestSetPolicy(t *testing.T) {
+	defer exex.SetPolicy(nil)
+
+	tests := map[string]struct {
+		policy  exex.Policy
+		allowed bool
+	}{
+		"base name": {exex.Allowlist("git", filepath.Base(os.Args[0])), true},
+		"path":      {exex.Allowlist(os.Args[0]), true},
+		"other":     {exex.Allowlist("git"), false},
+		"args": {exex.PolicyFunc(func(c *exex.Cmd) error {
+			for _, arg := range c.Args[1:] {
+				if arg == "--force" {
+					return errors.New("forcing is not allowed")
+				}
+			}
+			return nil
+		}), false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			exex.SetPolicy(tt.policy)
+
+			err := exex.Run(os.Args[0], "--force")
+			if tt.allowed {
+				assertErr(t, err, "error: --force")
+				return
+			}
+
+			var dErr *exex.DeniedError
+			if !errors.Is(err, exex.ErrDenied) || !errors.As(err, &dErr) {
+				t.Fatalf("expecting *exex.DeniedError, got %v", err)
+			}
+			if dErr.Path != os.Args[0] {
+				t.Errorf("expecting path %q, got %q", os.Args[0], dErr.Path)
+			}
+			if name == "other" && !errors.Is(err, exex.ErrNotAllowed) {
+				t.Errorf("expecting exex.ErrNotAllowed, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package exex
+
+// IOClass is an I/O scheduling class set with WithIOClass.
+type IOClass int
+
+const (
+	// IOClassRealtime gets access to the disk first, regardless of
+	// other activity. It requires privileges.
+	IOClassRealtime IOClass = 1
+
+	// IOClassBestEffort is the default class, sharing the disk
+	// according to the priority level.
+	IOClassBestEffort IOClass = 2
+
+	// IOClassIdle only gets access to the disk when no other process
+	// needs it.
+	IOClassIdle IOClass = 3
+)
+
+// WithNice sets the niceness of the command, from -20, the highest
+// priority, to 19, the lowest, e.g. to keep background jobs from
+// starving the main workload. Decreasing it below the one of the
+// current process requires privileges.
+//
+// On Unix systems it is set right after the command is started with
+// setpriority, and the command is killed if that fails. On Windows
+// the command is started with the closest priority class: idle for
+// 10 and above, below normal for positive values, above normal for
+// negative ones, and high for -10 and below. Elsewhere starting the
+// command fails with an *UnsupportedFeatureError, unless set
+// otherwise with WithDegradation.
+func WithNice(n int) Option {
+	return func(c *Cmd) { c.nice = &n }
+}
+
+// WithIOClass sets the I/O scheduling class of the command, and its
+// priority level within the class, from 0, the highest, to 7, as
+// ionice does. It is ignored by the idle class.
+//
+// It is set right after the command is started with ioprio_set, and
+// the command is killed if that fails. It is only supported on Linux;
+// elsewhere starting the command fails with an
+// *UnsupportedFeatureError, unless set otherwise with
+// WithDegradation.
+func WithIOClass(class IOClass, level int) Option {
+	return func(c *Cmd) { c.ioPriority = &ioPriority{class: class, level: level} }
+}
+
+type ioPriority struct {
+	class IOClass
+	level int
+}
+
+// startPriority sets the priorities of the started command, killing
+// it if that fails.
+func (c *Cmd) startPriority() error {
+	if c.Process == nil {
+		return nil
+	}
+
+	if c.nice != nil {
+		if err := setNice(c.Process.Pid, *c.nice); err != nil {
+			c.Process.Kill()
+			return err
+		}
+	}
+	if c.ioPriority != nil {
+		if err := setIOPriority(c.Process.Pid, *c.ioPriority); err != nil {
+			c.Process.Kill()
+			return err
+		}
+	}
+	return nil
+}
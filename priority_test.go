@@ -0,0 +1,80 @@
+package exex_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithNice(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("niceness is a priority class on Windows")
+	}
+
+	r, w := io.Pipe()
+	var out bytes.Buffer
+	cmd := exex.Command("/bin/sh", "-c", "read x; nice").Apply(
+		exex.WithEnv("PATH=/usr/bin:/bin"),
+		exex.WithNice(7),
+	)
+	cmd.Stdin, cmd.Stdout = r, &out
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("\n"))
+	w.Close()
+	if err := cmd.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "7\n" {
+		t.Fatalf("expecting %q, got %q", "7\n", out.String())
+	}
+}
+
+func TestWithIOClass(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		cmd := exex.Command(os.Args[0], "0").Apply(
+			exex.WithEnv("TEST_MAIN=exit"),
+			exex.WithIOClass(exex.IOClassBestEffort, 6),
+		)
+		if err := cmd.Run(); !errors.Is(err, errors.ErrUnsupported) {
+			t.Fatalf("expecting unsupported error, got %v", err)
+		}
+		if cmd.Process != nil {
+			t.Fatal("expecting command not to be started")
+		}
+		return
+	}
+
+	invalid := exex.Command(os.Args[0], "0").Apply(
+		exex.WithEnv("TEST_MAIN=exit"),
+		exex.WithIOClass(exex.IOClassBestEffort, 8),
+	)
+	if err := invalid.Run(); err == nil || invalid.Process != nil {
+		t.Fatalf("expecting invalid I/O priority to fail before starting, got %v", err)
+	}
+
+	cmd := exex.Command("/bin/sh", "-c", "read x; ionice -p $$").Apply(
+		exex.WithEnv("PATH=/usr/bin:/bin"),
+		exex.WithIOClass(exex.IOClassBestEffort, 6),
+	)
+	r, w := io.Pipe()
+	var out bytes.Buffer
+	cmd.Stdin, cmd.Stdout = r, &out
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("\n"))
+	w.Close()
+	if err := cmd.Wait(); err != nil {
+		t.Skipf("ionice not available: %v", err)
+	}
+	if exp := "best-effort: prio 6\n"; out.String() != exp {
+		t.Fatalf("expecting %q, got %q", exp, out.String())
+	}
+}
@@ -0,0 +1,69 @@
+package exex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// SudoCommand is the command, and its arguments, that RunPrivileged
+// prefixes to a command to run it with elevated privileges. It must
+// not prompt for a password.
+var SudoCommand = []string{"sudo", "-n"}
+
+// permissionMessages are substrings of the standard error of
+// commands that failed because of missing privileges.
+var permissionMessages = [][]byte{
+	[]byte("Permission denied"),
+	[]byte("Operation not permitted"),
+	[]byte("must be root"),
+	[]byte("must be run as root"),
+	[]byte("requires root"),
+	[]byte("superuser"),
+}
+
+// RunPrivileged runs the named command and, if it fails because of
+// missing privileges, runs it again prefixed with SudoCommand, as
+// long as it is available. elevated reports whether the returned
+// error, if any, is the result of the latter.
+//
+// A command is considered to have failed because of missing
+// privileges if starting it fails with a permission error, like
+// EACCES or EPERM, or if it exits with a non-zero status and its
+// standard error contains messages such as "Permission denied" or
+// "Operation not permitted".
+//
+// As the command may run twice, it should be safe to retry.
+func RunPrivileged(ctx context.Context, name string, args ...string) (elevated bool, err error) {
+	err = RunContext(ctx, name, args...)
+	if err == nil || !permissionError(err) || ctx.Err() != nil {
+		return false, err
+	}
+
+	sudo, lerr := LookPath(SudoCommand[0])
+	if lerr != nil {
+		return false, err
+	}
+
+	sargs := append(append(SudoCommand[1:len(SudoCommand):len(SudoCommand)], name), args...)
+	return true, RunContext(ctx, sudo, sargs...)
+}
+
+func permissionError(err error) bool {
+	if errors.Is(err, os.ErrPermission) {
+		return true
+	}
+
+	var exErr *exec.ExitError
+	if !errors.As(err, &exErr) {
+		return false
+	}
+	for _, msg := range permissionMessages {
+		if bytes.Contains(exErr.Stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package exex_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestRunPrivileged(t *testing.T) {
+	ctx := context.Background()
+
+	sudo := exex.SudoCommand
+	defer func() { exex.SudoCommand = sudo }()
+
+	// Fake sudo using the test binary, which prints its arguments
+	// to standard error and fails.
+	exex.SudoCommand = []string{os.Args[0], "-n"}
+
+	t.Run("not a permission error", func(t *testing.T) {
+		elevated, err := exex.RunPrivileged(ctx, os.Args[0], "foo")
+		if elevated {
+			t.Fatal("not expecting command to be elevated")
+		}
+		assertErr(t, err, "error: foo")
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		elevated, err := exex.RunPrivileged(ctx, os.Args[0], "Permission denied")
+		if !elevated {
+			t.Fatal("expecting command to be elevated")
+		}
+		assertErr(t, err, "error: -n "+os.Args[0]+" Permission denied")
+	})
+
+	t.Run("not executable", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "tool")
+		if err := os.WriteFile(name, []byte("#!/bin/sh\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		elevated, err := exex.RunPrivileged(ctx, name, "bar")
+		if !elevated {
+			t.Fatalf("expecting command to be elevated: %v", err)
+		}
+		assertErr(t, err, "error: -n "+name+" bar")
+	})
+
+	t.Run("sudo not found", func(t *testing.T) {
+		exex.SudoCommand = []string{"foobarbazquux"}
+
+		elevated, err := exex.RunPrivileged(ctx, os.Args[0], "Operation not permitted")
+		if elevated {
+			t.Fatal("not expecting command to be elevated")
+		}
+		var cmdErr *exex.CmdError
+		if !errors.As(err, &cmdErr) {
+			t.Fatalf("expecting original error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package exex
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Check is a capability of the host detected by Probe running a
+// command, e.g. whether a tool is installed or a kernel feature is
+// available.
+type Check struct {
+	// Name identifies the capability in the Capabilities report.
+	Name string
+
+	// Args is the command line to run, e.g. {"git", "--version"}.
+	Args []string
+
+	// Match, if not nil, must match the combined output of the
+	// command for the capability to be available.
+	Match *regexp.Regexp
+}
+
+// ToolCheck returns a Check for the availability and version of the
+// named tool, running it with the --version flag.
+func ToolCheck(name string) Check {
+	return Check{Name: name, Args: []string{name, "--version"}}
+}
+
+// Capability is the result of a Check.
+type Capability struct {
+	// Available reports whether the command ran successfully and,
+	// if the Check has a Match, its output matched.
+	Available bool
+
+	// Version is the first version number, like 1.2.3, found in the
+	// output of the command, if any.
+	Version string
+
+	// Output is the combined output of the command, trimmed.
+	Output string
+
+	// Err is the error running the command, if any.
+	Err error
+}
+
+// Capabilities is the report returned by Probe, keyed by the name of
+// the checks.
+type Capabilities map[string]Capability
+
+// Available reports whether the named capability is available.
+func (c Capabilities) Available(name string) bool {
+	return c[name].Available
+}
+
+var probeCache sync.Map // command line -> Capability
+
+// Probe runs the commands of the checks concurrently and returns the
+// report of the capabilities of the host. The results of the commands
+// are cached for the lifetime of the process, so checks with the same
+// command line are run only once, unless they fail because of ctx.
+func Probe(ctx context.Context, checks ...Check) Capabilities {
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		res = make(Capabilities, len(checks))
+	)
+
+	for _, chk := range checks {
+		wg.Add(1)
+		go func(chk Check) {
+			defer wg.Done()
+
+			capability := probe(ctx, chk.Args)
+			if capability.Available && chk.Match != nil {
+				capability.Available = chk.Match.MatchString(capability.Output)
+			}
+
+			mu.Lock()
+			res[chk.Name] = capability
+			mu.Unlock()
+		}(chk)
+	}
+	wg.Wait()
+
+	return res
+}
+
+// probe runs the command line args, or returns its cached result.
+func probe(ctx context.Context, args []string) Capability {
+	key := strings.Join(args, "\x00")
+	if c, ok := probeCache.Load(key); ok {
+		return c.(Capability)
+	}
+
+	if len(args) == 0 {
+		return Capability{Err: ErrNotFound}
+	}
+
+	out, err := CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	c := Capability{
+		Available: err == nil,
+		Output:    strings.TrimSpace(string(out)),
+		Err:       err,
+	}
+	c.Version = versionRe.FindString(c.Output)
+
+	if err == nil || ctx.Err() == nil {
+		probeCache.Store(key, c)
+	}
+
+	return c
+}
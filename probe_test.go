@@ -0,0 +1,42 @@
+package exex_test
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestProbe(t *testing.T) {
+	t.Setenv("TEST_MAIN", "version")
+
+	checks := []exex.Check{
+		{Name: "tool", Args: []string{os.Args[0], "probe"}},
+		{Name: "match", Args: []string{os.Args[0], "probe"}, Match: regexp.MustCompile(`version 1\.`)},
+		{Name: "no match", Args: []string{os.Args[0], "probe"}, Match: regexp.MustCompile(`version 2\.`)},
+		exex.ToolCheck("non-existing-command"),
+	}
+
+	caps := exex.Probe(context.Background(), checks...)
+
+	if c := caps["tool"]; !c.Available || c.Version != "1.2.3" || c.Output != "exex-test version 1.2.3" {
+		t.Errorf("unexpected capability %+v", c)
+	}
+	if !caps.Available("match") {
+		t.Error("expecting match to be available")
+	}
+	if caps.Available("no match") {
+		t.Error("expecting no match not to be available")
+	}
+	if c := caps["non-existing-command"]; c.Available || c.Err == nil {
+		t.Errorf("expecting non-existing-command not to be available, got %+v", c)
+	}
+
+	// Results are cached, so the command is not run again.
+	t.Setenv("TEST_MAIN", "error")
+	if caps := exex.Probe(context.Background(), checks[0]); !caps.Available("tool") {
+		t.Error("expecting cached result")
+	}
+}
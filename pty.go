@@ -0,0 +1,170 @@
+package exex
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// WithPTY runs the command in a new pseudo-terminal of the given size,
+// for commands that behave differently, or refuse to run, when not
+// connected to a terminal, like ssh or interactive tools. The command
+// runs in a new session with the terminal as its controlling terminal
+// and as its standard input and outputs.
+//
+// Stdin, if set, is copied to the terminal, and the terminal output,
+// which includes the standard error of the command and the echo of
+// its input, is copied to Stdout, which must be set unless the
+// command does not write anything, e.g. using Output. Stderr must not
+// be set, as the terminal has a single output. The terminal is
+// resized with ResizePTY.
+//
+// Pseudo-terminals are only supported on Linux; elsewhere starting
+// the command fails with an *UnsupportedFeatureError, unless set
+// otherwise with WithDegradation.
+func WithPTY(rows, cols uint16) Option {
+	return func(c *Cmd) { c.pty = &ptyState{rows: rows, cols: cols} }
+}
+
+// RunPTY runs the command in a pseudo-terminal as set with WithPTY,
+// of 24 rows and 80 columns if not set.
+func (c *Cmd) RunPTY() error {
+	if c.pty == nil {
+		WithPTY(24, 80)(c)
+	}
+	return c.Run()
+}
+
+// ResizePTY sets the size of the pseudo-terminal of the command,
+// which is notified with SIGWINCH.
+func (c *Cmd) ResizePTY(rows, cols uint16) error {
+	if c.pty == nil {
+		return errors.New("exex: no pseudo-terminal")
+	}
+
+	c.pty.mu.Lock()
+	defer c.pty.mu.Unlock()
+
+	c.pty.rows, c.pty.cols = rows, cols
+	if c.pty.master == nil {
+		return nil
+	}
+	if err := setPTYSize(c.pty.master, rows, cols); err != nil {
+		return err
+	}
+	if c.pty.transcript != nil {
+		c.pty.transcript.resize(rows, cols)
+	}
+	return nil
+}
+
+type ptyState struct {
+	mu         sync.Mutex
+	rows, cols uint16
+	master     *os.File
+	slave      *os.File // closed once the command started
+	stdin      io.Reader
+	stdout     io.Writer
+	copied     chan struct{} // closed once the output was copied
+	transcript *transcript   // set by WithPTYRecording
+}
+
+// setup opens the pseudo-terminal and connects the command to it.
+func (p *ptyState) setup(c *Cmd) error {
+	if c.Stderr != nil && !c.mergeStderr {
+		return errors.New("exex: Stderr already set")
+	}
+
+	master, slave, err := openPTY()
+	if err != nil {
+		return err
+	}
+	if err := setPTYSize(master, p.rows, p.cols); err != nil {
+		master.Close()
+		slave.Close()
+		return err
+	}
+
+	p.mu.Lock()
+	p.master, p.slave = master, slave
+	if c.recording != nil {
+		p.transcript = newTranscript(c.recording, p.rows, p.cols)
+	}
+	p.mu.Unlock()
+
+	p.stdin, p.stdout = c.Stdin, c.Stdout
+	c.Stdin, c.Stdout, c.Stderr = slave, slave, slave
+	c.setControllingTTY()
+	return nil
+}
+
+// start copies the input and output of the started command.
+func (p *ptyState) start() {
+	p.slave.Close()
+	p.slave = nil
+
+	if p.stdin != nil {
+		r := p.stdin
+		if w := p.record(RecordInput, "i"); w != nil {
+			r = io.TeeReader(r, w)
+		}
+		// Like exec.Cmd does with non-file readers, the copy is not
+		// waited for, as reading may block indefinitely.
+		go io.Copy(p.master, r)
+	}
+
+	p.copied = make(chan struct{})
+	go func() {
+		defer close(p.copied)
+		w := p.stdout
+		if w == nil {
+			w = io.Discard
+		}
+		if rw := p.record(RecordOutput, "o"); rw != nil {
+			w = io.MultiWriter(w, rw)
+		}
+		// Reading fails once the terminal is closed by all the
+		// processes using it.
+		io.Copy(w, p.master)
+	}()
+}
+
+// record returns the writer recording the events of the given code
+// in the transcript of the session, or nil if they are not recorded.
+func (p *ptyState) record(what Recording, code string) io.Writer {
+	if p.transcript == nil {
+		return nil
+	}
+	return p.transcript.writer(what, code)
+}
+
+// closePTY closes the pseudo-terminal of the command, if any.
+func (c *Cmd) closePTY() {
+	if c.pty != nil {
+		c.pty.close()
+	}
+}
+
+// close waits for the output of the command to be copied and closes
+// the pseudo-terminal.
+func (p *ptyState) close() {
+	if p.slave != nil {
+		p.slave.Close()
+		p.slave = nil
+	}
+	if p.copied != nil {
+		<-p.copied
+	}
+	if p.transcript != nil {
+		p.transcript.flush()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.master != nil {
+		p.master.Close()
+		p.master = nil
+	}
+}
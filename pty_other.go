@@ -0,0 +1,15 @@
+//go:build !linux
+
+package exex
+
+import "os"
+
+func openPTY() (master, slave *os.File, err error) {
+	return nil, nil, unsupported("pseudo-terminal")
+}
+
+func setPTYSize(f *os.File, rows, cols uint16) error {
+	return unsupported("pseudo-terminal")
+}
+
+func (c *Cmd) setControllingTTY() {}
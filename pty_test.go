@@ -0,0 +1,73 @@
+//go:build linux
+
+package exex_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithPTY(t *testing.T) {
+	t.Run("output", func(t *testing.T) {
+		out, err := exex.Command("/bin/sh", "-c", "test -t 0 && test -t 1 && test -t 2 && stty size").Apply(
+			exex.WithEnv("PATH=/usr/bin:/bin"),
+			exex.WithPTY(30, 100),
+		).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := "30 100\r\n"; string(out) != exp {
+			t.Fatalf("expecting %q, got %q", exp, out)
+		}
+	})
+
+	t.Run("resize", func(t *testing.T) {
+		r, w := io.Pipe()
+		var out bytes.Buffer
+		cmd := exex.Command("/bin/sh", "-c", "read x; stty size").Apply(
+			exex.WithEnv("PATH=/usr/bin:/bin"),
+			exex.WithPTY(30, 100),
+		)
+		cmd.Stdin, cmd.Stdout = r, &out
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		if err := cmd.ResizePTY(40, 120); err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, "go\n")
+		if err := cmd.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		if exp := "go\r\n40 120\r\n"; out.String() != exp {
+			t.Fatalf("expecting %q, got %q", exp, out.String())
+		}
+	})
+
+	t.Run("run", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := exex.Command("/bin/sh", "-c", "stty size; exit 3").Apply(exex.WithEnv("PATH=/usr/bin:/bin"))
+		cmd.Stdout = &out
+		err := cmd.RunPTY()
+		var cmdErr *exex.CmdError
+		if !errors.As(err, &cmdErr) || cmdErr.ExitCode != 3 {
+			t.Fatalf("expecting exit code 3, got %v", err)
+		}
+		if !strings.HasPrefix(out.String(), "24 80") {
+			t.Fatalf("unexpected output %q", out.String())
+		}
+	})
+
+	t.Run("stderr", func(t *testing.T) {
+		cmd := exex.Command("/bin/sh").Apply(exex.WithPTY(24, 80))
+		cmd.Stderr = io.Discard
+		if err := cmd.Run(); err == nil {
+			t.Fatal("expecting error")
+		}
+	})
+}
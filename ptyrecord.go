@@ -0,0 +1,178 @@
+package exex
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Recording sets what WithPTYRecording records of a terminal session.
+type Recording int
+
+const (
+	// RecordOutput records the output of the terminal, which includes
+	// the echo of the input, if enabled.
+	RecordOutput Recording = 1 << iota
+
+	// RecordInput records the input sent to the terminal as it is
+	// typed, including what is not echoed, like passwords entered at
+	// prompts. It must only be set with the consent of whoever types
+	// the input, e.g. for audited break-glass sessions.
+	RecordInput
+)
+
+// WithPTYRecording records the session of a command run with WithPTY
+// to w, in the asciicast v2 format: a line with a JSON header holding
+// the size of the terminal, followed by a line with a JSON array for
+// each event, holding the seconds since the recording started, the
+// event code, "o" for output, "i" for input and "r" for resizing, and
+// its data. Only the kinds of events set in what are recorded, along
+// with resizing; in particular, input is never recorded unless
+// RecordInput is set.
+//
+// If writing to w fails, recording stops and the error is returned by
+// Wait, unless the command failed.
+func WithPTYRecording(w io.Writer, what Recording) Option {
+	return func(c *Cmd) { c.recording = &recording{w: w, what: what} }
+}
+
+type recording struct {
+	w    io.Writer
+	what Recording
+}
+
+// transcript writes the events of a terminal session as recorded by
+// WithPTYRecording.
+type transcript struct {
+	mu      sync.Mutex
+	w       io.Writer
+	what    Recording
+	start   time.Time
+	pending map[string][]byte // incomplete UTF-8 sequence by event code
+	err     error
+}
+
+func newTranscript(r *recording, rows, cols uint16) *transcript {
+	t := &transcript{w: r.w, what: r.what, start: time.Now(), pending: make(map[string][]byte)}
+
+	hdr, _ := json.Marshal(struct {
+		Version   int    `json:"version"`
+		Width     uint16 `json:"width"`
+		Height    uint16 `json:"height"`
+		Timestamp int64  `json:"timestamp"`
+	}{2, cols, rows, t.start.Unix()})
+	t.write(hdr)
+	return t
+}
+
+// writer returns a writer recording its input as events of the given
+// code, or nil if they are not recorded.
+func (t *transcript) writer(what Recording, code string) io.Writer {
+	if t.what&what == 0 {
+		return nil
+	}
+	return transcriptWriter{t: t, code: code}
+}
+
+// event records data as an event of the given code. Incomplete UTF-8
+// sequences at the end of data are held until the next event of the
+// same code, as the JSON strings of the events must be valid UTF-8.
+func (t *transcript) event(code string, data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.err != nil {
+		return
+	}
+	data = append(t.pending[code], data...)
+	n := completeRunes(data)
+	t.pending[code] = append([]byte(nil), data[n:]...)
+	if n > 0 {
+		t.emit(code, data[:n])
+	}
+}
+
+// resize records the new size of the terminal.
+func (t *transcript) resize(rows, cols uint16) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.err == nil {
+		t.emit("r", []byte(fmt.Sprintf("%dx%d", cols, rows)))
+	}
+}
+
+// flush records the incomplete UTF-8 sequences held, if any.
+func (t *transcript) flush() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for code, data := range t.pending {
+		if len(data) > 0 && t.err == nil {
+			t.emit(code, data)
+		}
+		delete(t.pending, code)
+	}
+}
+
+func (t *transcript) emit(code string, data []byte) {
+	secs := math.Round(time.Since(t.start).Seconds()*1e6) / 1e6
+	line, _ := json.Marshal([]any{secs, code, string(data)})
+	t.write(line)
+}
+
+func (t *transcript) write(line []byte) {
+	if t.err == nil {
+		_, t.err = t.w.Write(append(line, '\n'))
+	}
+}
+
+// Err returns the error writing the transcript, if any.
+func (t *transcript) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.err != nil {
+		return fmt.Errorf("exex: recording: %w", t.err)
+	}
+	return nil
+}
+
+// transcriptWriter records its input as events of a transcript. It
+// never fails, so that recording does not affect the session.
+type transcriptWriter struct {
+	t    *transcript
+	code string
+}
+
+func (w transcriptWriter) Write(p []byte) (int, error) {
+	w.t.event(w.code, p)
+	return len(p), nil
+}
+
+// completeRunes returns the length of b without its trailing
+// incomplete UTF-8 sequence, if any.
+func completeRunes(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(b)
+}
+
+// recordingErr returns the error recording the terminal session of
+// the command, if any.
+func (c *Cmd) recordingErr() error {
+	if c.pty == nil || c.pty.transcript == nil {
+		return nil
+	}
+	return c.pty.transcript.Err()
+}
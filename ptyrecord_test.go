@@ -0,0 +1,146 @@
+//go:build linux
+
+package exex_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+type transcriptEvent struct {
+	time       float64
+	code, data string
+}
+
+func parseTranscript(t *testing.T, b []byte) (width, height int, events []transcriptEvent) {
+	t.Helper()
+
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	if !sc.Scan() {
+		t.Fatal("missing header")
+	}
+	var hdr struct {
+		Version       int
+		Width, Height int
+	}
+	if err := json.Unmarshal(sc.Bytes(), &hdr); err != nil {
+		t.Fatal(err)
+	}
+	if hdr.Version != 2 {
+		t.Fatalf("expecting version 2, got %d", hdr.Version)
+	}
+
+	for sc.Scan() {
+		var ev []any
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			t.Fatal(err)
+		}
+		if len(ev) != 3 {
+			t.Fatalf("unexpected event %s", sc.Bytes())
+		}
+		events = append(events, transcriptEvent{ev[0].(float64), ev[1].(string), ev[2].(string)})
+	}
+	return hdr.Width, hdr.Height, events
+}
+
+func recordPTY(t *testing.T, what exex.Recording) []byte {
+	t.Helper()
+
+	r, w := io.Pipe()
+	var out, rec bytes.Buffer
+	cmd := exex.Command("/bin/sh", "-c", "stty -echo; echo ready; read x; echo \"got $x\"").Apply(
+		exex.WithEnv("PATH=/usr/bin:/bin"),
+		exex.WithPTY(24, 80),
+		exex.WithPTYRecording(&rec, what),
+	)
+	cmd.Stdin, cmd.Stdout = r, &out
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.ResizePTY(30, 100); err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, "s3cr3t\n")
+	if err := cmd.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "got s3cr3t") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+	return rec.Bytes()
+}
+
+func TestWithPTYRecording(t *testing.T) {
+	t.Run("input", func(t *testing.T) {
+		width, height, events := parseTranscript(t, recordPTY(t, exex.RecordOutput|exex.RecordInput))
+		if width != 80 || height != 24 {
+			t.Fatalf("expecting 80x24, got %dx%d", width, height)
+		}
+
+		var in, out strings.Builder
+		var resized bool
+		last := 0.0
+		for _, ev := range events {
+			if ev.time < last {
+				t.Fatalf("events out of order: %v", events)
+			}
+			last = ev.time
+			switch ev.code {
+			case "i":
+				in.WriteString(ev.data)
+			case "o":
+				out.WriteString(ev.data)
+			case "r":
+				resized = ev.data == "100x30"
+			}
+		}
+		if in.String() != "s3cr3t\n" {
+			t.Fatalf("expecting input %q, got %q", "s3cr3t\n", in.String())
+		}
+		if !strings.Contains(out.String(), "got s3cr3t") {
+			t.Fatalf("unexpected output %q", out.String())
+		}
+		if !resized {
+			t.Fatalf("expecting resize event, got %v", events)
+		}
+	})
+
+	t.Run("output only", func(t *testing.T) {
+		_, _, events := parseTranscript(t, recordPTY(t, exex.RecordOutput))
+		var out bool
+		for _, ev := range events {
+			if ev.code == "i" {
+				t.Fatalf("unexpected input event %v", ev)
+			}
+			out = out || ev.code == "o"
+		}
+		if !out {
+			t.Fatal("expecting output events")
+		}
+	})
+
+	t.Run("write error", func(t *testing.T) {
+		cmd := exex.Command("/bin/sh", "-c", "echo hi").Apply(
+			exex.WithEnv("PATH=/usr/bin:/bin"),
+			exex.WithPTY(24, 80),
+			exex.WithPTYRecording(failingWriter{}, exex.RecordOutput),
+		)
+		cmd.Stdout = io.Discard
+		if err := cmd.Run(); !errors.Is(err, errRecording) {
+			t.Fatalf("expecting recording error, got %v", err)
+		}
+	})
+}
+
+var errRecording = errors.New("disk full")
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errRecording }
@@ -0,0 +1,49 @@
+package exex
+
+import (
+	"context"
+	"fmt"
+)
+
+// Limiter throttles the start of commands. It is satisfied by
+// *rate.Limiter of golang.org/x/time/rate.
+type Limiter interface {
+	// Wait blocks until the next command can start, or returns an
+	// error if ctx is done first or it will never be allowed.
+	Wait(ctx context.Context) error
+}
+
+// RateLimit returns a Middleware waiting for l before starting each
+// command, so that launches of the same tools, e.g. CLIs calling a
+// rate-limited API, are throttled in a single place when used with
+// Use:
+//
+//	exex.Use(exex.RateLimit(rate.NewLimiter(rate.Every(time.Second), 5)))
+//
+// The wait is bounded by the context of the command. If it fails, the
+// command is not started and Start returns the error.
+func RateLimit(l Limiter) Middleware {
+	return func(next Runner) Runner { return &rateLimitRunner{next: next, l: l} }
+}
+
+// WithRateLimit throttles the start of the command with l, as
+// RateLimit does. Commands sharing l are throttled together.
+func WithRateLimit(l Limiter) Option {
+	return WithMiddleware(RateLimit(l))
+}
+
+type rateLimitRunner struct {
+	next Runner
+	l    Limiter
+}
+
+func (r *rateLimitRunner) Start(c *Cmd) error {
+	if err := r.l.Wait(c.Context()); err != nil {
+		return fmt.Errorf("exex: rate limit: %w", err)
+	}
+	return r.next.Start(c)
+}
+
+func (r *rateLimitRunner) Wait(c *Cmd) error {
+	return r.next.Wait(c)
+}
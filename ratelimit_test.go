@@ -0,0 +1,56 @@
+package exex_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+// tokenLimiter allows starting a command for each token sent to it.
+type tokenLimiter chan struct{}
+
+func (l tokenLimiter) Wait(ctx context.Context) error {
+	select {
+	case <-l:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	l := make(tokenLimiter, 1)
+	l <- struct{}{}
+
+	cmd := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=version"), exex.WithRateLimit(l))
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	cmd = exex.CommandContext(ctx, os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=version"), exex.WithRateLimit(l))
+	err := cmd.Run()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expecting context.DeadlineExceeded, got %v", err)
+	}
+	if cmd.Process != nil {
+		t.Fatal("expecting the command not to be started")
+	}
+
+	t.Run("released", func(t *testing.T) {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			l <- struct{}{}
+		}()
+		err := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=version"), exex.WithRateLimit(l)).Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package exex
+
+import "sync"
+
+// The reaper enabled by EnableReaper must not reap the processes of
+// commands that are waited for by Wait. Those are tracked while they
+// run, and the reaper only reaps the zombie children that are not
+// tracked. Commands are started and tracked holding a read lock, so
+// the reaper, which holds the write lock, never sees an untracked
+// command that exited right after being started.
+var reaper struct {
+	mu      sync.RWMutex
+	enabled bool
+
+	pidsMu sync.Mutex
+	pids   map[int]bool
+}
+
+// startTracked calls start, tracking the process of the command if
+// it succeeds and the reaper is enabled.
+func (c *Cmd) startTracked(start func() error) error {
+	reaper.mu.RLock()
+	defer reaper.mu.RUnlock()
+
+	err := start()
+	if err == nil && reaper.enabled && c.Process != nil {
+		reaper.pidsMu.Lock()
+		reaper.pids[c.Process.Pid] = true
+		reaper.pidsMu.Unlock()
+	}
+	return err
+}
+
+// untrack stops tracking the process of the finished command.
+func (c *Cmd) untrack() {
+	if c.Process == nil {
+		return
+	}
+
+	reaper.pidsMu.Lock()
+	delete(reaper.pids, c.Process.Pid)
+	reaper.pidsMu.Unlock()
+}
+
+// tracked reports whether pid is the process of a running command.
+func tracked(pid int) bool {
+	reaper.pidsMu.Lock()
+	defer reaper.pidsMu.Unlock()
+
+	return reaper.pids[pid]
+}
@@ -0,0 +1,96 @@
+package exex
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+const prSetChildSubreaper = 36
+
+// EnableReaper makes the current process a child subreaper, so that
+// orphaned descendants of the commands it runs are reparented to it
+// instead of to init, and reaps them when they exit. This is needed
+// when running as PID 1, e.g. in a container, where otherwise exited
+// orphans are left as zombies.
+//
+// The processes of commands run with this package are not reaped, so
+// that Wait still reports their status. Any other child process must
+// not be started once the reaper is enabled, as it could be reaped
+// before being waited for. Calling EnableReaper more than once has
+// no effect.
+func EnableReaper() error {
+	reaper.mu.Lock()
+	defer reaper.mu.Unlock()
+
+	if reaper.enabled {
+		return nil
+	}
+
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetChildSubreaper, 1, 0); errno != 0 {
+		return fmt.Errorf("exex: enabling reaper: %w", errno)
+	}
+
+	reaper.enabled = true
+	reaper.pids = make(map[int]bool)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGCHLD)
+	go func() {
+		for range sigs {
+			reap()
+		}
+	}()
+
+	return nil
+}
+
+// reap waits for the zombie children that are not the process of a
+// running command.
+func reap() {
+	reaper.mu.Lock()
+	defer reaper.mu.Unlock()
+
+	for _, pid := range zombieChildren() {
+		if tracked(pid) {
+			continue
+		}
+		var ws syscall.WaitStatus
+		syscall.Wait4(pid, &ws, syscall.WNOHANG, nil)
+	}
+}
+
+// zombieChildren lists the children of the current process that
+// exited and were not waited for yet.
+func zombieChildren() []int {
+	stats, _ := filepath.Glob("/proc/[0-9]*/stat")
+	ppid := strconv.Itoa(os.Getpid())
+
+	var pids []int
+	for _, name := range stats {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			continue
+		}
+
+		// The fields after the command name, which is enclosed in
+		// parens, are the state and the parent PID.
+		i := bytes.LastIndexByte(b, ')')
+		if i < 0 {
+			continue
+		}
+		f := bytes.Fields(b[i+1:])
+		if len(f) < 2 || string(f[0]) != "Z" || string(f[1]) != ppid {
+			continue
+		}
+		if pid, err := strconv.Atoi(filepath.Base(filepath.Dir(name))); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+
+	return pids
+}
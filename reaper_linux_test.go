@@ -0,0 +1,66 @@
+package exex_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+// testReaper is run in a child process by TestEnableReaper, as the
+// reaper cannot be disabled once enabled. It returns the exit code of
+// the child.
+func testReaper() int {
+	if err := exex.EnableReaper(); err != nil {
+		fmt.Fprint(os.Stderr, err)
+		return 1
+	}
+
+	// The orphan left by the daemon is reparented to this process.
+	var stdout bytes.Buffer
+	cmd := exex.Command(os.Args[0]).Apply(
+		exex.WithEnv("TEST_MAIN=daemon"),
+		exex.WithStdout(&stdout),
+	)
+	cmd.WaitDelay = 100 * time.Millisecond
+	if err := cmd.Run(); err != nil {
+		fmt.Fprint(os.Stderr, err)
+		return 1
+	}
+
+	pid, _ := strconv.Atoi(strings.SplitN(stdout.String(), "\n", 2)[0])
+	stat := fmt.Sprintf("/proc/%d/stat", pid)
+	if b, err := os.ReadFile(stat); err != nil || !bytes.Contains(b, []byte(") S "+strconv.Itoa(os.Getpid())+" ")) {
+		fmt.Fprintf(os.Stderr, "orphan %d not reparented: %s", pid, b)
+		return 1
+	}
+
+	syscall.Kill(pid, syscall.SIGKILL)
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(stat); os.IsNotExist(err) {
+			// Commands are still waited for by Wait.
+			if err := exex.Command(os.Args[0], "0").Apply(exex.WithEnv("TEST_MAIN=exit")).Run(); err != nil {
+				fmt.Fprint(os.Stderr, err)
+				return 1
+			}
+			return 0
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	fmt.Fprintf(os.Stderr, "orphan %d not reaped", pid)
+	return 1
+}
+
+func TestEnableReaper(t *testing.T) {
+	err := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=reaper")).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
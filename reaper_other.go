@@ -0,0 +1,10 @@
+//go:build !linux
+
+package exex
+
+// EnableReaper makes the current process reap orphaned descendants.
+// It is only supported on Linux; elsewhere it returns an
+// *UnsupportedFeatureError.
+func EnableReaper() error {
+	return unsupported("reaper")
+}
@@ -0,0 +1,5 @@
+//go:build !linux
+
+package exex_test
+
+func testReaper() int { return 1 }
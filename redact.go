@@ -0,0 +1,77 @@
+package exex
+
+import (
+	"regexp"
+	"sync"
+)
+
+var (
+	redactorsMu sync.Mutex
+	redactors   []*redactor
+)
+
+type redactor struct {
+	fn func([]byte) []byte
+}
+
+// RegisterRedactor adds fn to the functions applied to the captured
+// standard error of every command, as reported in ExitError.Stderr
+// and CmdError.Stderr, and to the messages of CmdError, to remove
+// secrets the commands might print. fn must return its input
+// unmodified if it has nothing to redact, and is allowed to modify it
+// in place. The returned function removes fn from the registered
+// redactors.
+func RegisterRedactor(fn func([]byte) []byte) (unregister func()) {
+	r := &redactor{fn: fn}
+
+	redactorsMu.Lock()
+	defer redactorsMu.Unlock()
+
+	redactors = append(redactors[:len(redactors):len(redactors)], r)
+
+	return func() {
+		redactorsMu.Lock()
+		defer redactorsMu.Unlock()
+
+		for i, x := range redactors {
+			if x == r {
+				redactors = append(redactors[:i:i], redactors[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// RedactSecrets replaces the text matching any of the regular
+// expression patterns with "xxxxx" in the captured standard error of
+// the command and in the messages of the errors it returns, in
+// addition to the redactors added with RegisterRedactor. It panics if
+// a pattern is not a valid regular expression.
+//
+// The output given to other writers, like the ones set with TeeStderr
+// or StreamStderr, is not redacted.
+func (c *Cmd) RedactSecrets(patterns ...string) {
+	for _, p := range patterns {
+		c.secrets = append(c.secrets, regexp.MustCompile(p))
+	}
+}
+
+// redact applies the registered redactors and the patterns set with
+// RedactSecrets to b.
+func (c *Cmd) redact(b []byte) []byte {
+	redactorsMu.Lock()
+	rs := redactors
+	redactorsMu.Unlock()
+
+	if len(b) == 0 || (len(rs) == 0 && len(c.secrets) == 0) {
+		return b
+	}
+
+	for _, r := range rs {
+		b = r.fn(b)
+	}
+	for _, re := range c.secrets {
+		b = re.ReplaceAll(b, []byte(redacted))
+	}
+	return b
+}
@@ -0,0 +1,42 @@
+package exex_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestCmd_RedactSecrets(t *testing.T) {
+	cmd := exex.Command(os.Args[0], "token=s3cr3t", "user=bob")
+	cmd.RedactSecrets(`s3cr3t`, `bob`)
+
+	err := cmd.Run()
+	assertErr(t, err, "error: token=xxxxx user=xxxxx")
+
+	var cmdErr *exex.CmdError
+	if !errors.As(err, &cmdErr) || string(cmdErr.Stderr) != "error: token=xxxxx user=xxxxx" {
+		t.Fatalf("expecting redacted CmdError.Stderr, got %v", err)
+	}
+	// The argument is not redacted by RedactArgs.
+	if msg := err.Error(); strings.Contains(msg, "bob") {
+		t.Fatalf("expecting redacted error message, got %q", msg)
+	}
+}
+
+func TestRegisterRedactor(t *testing.T) {
+	unregister := exex.RegisterRedactor(func(b []byte) []byte {
+		return bytes.ReplaceAll(b, []byte("hunter2"), []byte("*******"))
+	})
+	t.Cleanup(unregister)
+
+	err := exex.Run(os.Args[0], "password", "hunter2")
+	assertErr(t, err, "error: password *******")
+
+	unregister()
+	err = exex.Run(os.Args[0], "password", "hunter2")
+	assertErr(t, err, "error: password hunter2")
+}
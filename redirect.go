@@ -0,0 +1,80 @@
+package exex
+
+import (
+	"fmt"
+	"os"
+)
+
+// RedirectFD connects the file descriptor fd of the command to f.
+// File descriptors 0, 1 and 2 set Stdin, Stdout and Stderr
+// respectively, while 3 and above are passed using ExtraFiles.
+//
+// Redirections are validated when the command is started, which
+// fails if the same file descriptor is redirected more than once or
+// if the corresponding field of the command was already set.
+func RedirectFD(fd int, f *os.File) Option {
+	return func(c *Cmd) {
+		c.redirects = append(c.redirects, redirect{fd: fd, f: f})
+	}
+}
+
+// MergeStderrIntoStdout sends the standard error of the command to
+// the same destination as its standard output, like the shell's
+// "2>&1". Standard error is then not captured separately.
+func MergeStderrIntoStdout() Option {
+	return func(c *Cmd) { c.mergeStderr = true }
+}
+
+type redirect struct {
+	fd int
+	f  *os.File
+}
+
+// applyRedirects validates and applies the redirections requested
+// with RedirectFD and MergeStderrIntoStdout.
+func (c *Cmd) applyRedirects() error {
+	seen := make(map[int]bool, len(c.redirects))
+
+	for _, r := range c.redirects {
+		if r.fd < 0 {
+			return fmt.Errorf("exex: invalid file descriptor %d", r.fd)
+		}
+		if seen[r.fd] || (r.fd == 2 && c.mergeStderr) {
+			return fmt.Errorf("exex: conflicting redirections of file descriptor %d", r.fd)
+		}
+		seen[r.fd] = true
+
+		var set bool
+		switch r.fd {
+		case 0:
+			set = c.Stdin != nil
+			c.Stdin = r.f
+		case 1:
+			set = c.Stdout != nil
+			c.Stdout = r.f
+		case 2:
+			set = c.Stderr != nil
+			c.Stderr = r.f
+		default:
+			i := r.fd - 3
+			for len(c.ExtraFiles) <= i {
+				c.ExtraFiles = append(c.ExtraFiles, nil)
+			}
+			set = c.ExtraFiles[i] != nil
+			c.ExtraFiles[i] = r.f
+		}
+		if set {
+			return fmt.Errorf("exex: file descriptor %d already set", r.fd)
+		}
+	}
+	c.redirects = nil
+
+	if c.mergeStderr {
+		if c.Stderr != nil {
+			return fmt.Errorf("exex: conflicting redirections of file descriptor 2")
+		}
+		c.Stderr = c.Stdout
+	}
+
+	return nil
+}
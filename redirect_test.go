@@ -0,0 +1,83 @@
+package exex_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestRedirectFD(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	err = exex.Command(os.Args[0], "extra", "file").Apply(
+		exex.WithEnv("TEST_MAIN=fd3"),
+		exex.RedirectFD(3, w),
+	).Run()
+	w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "extra file"; string(b) != exp {
+		t.Fatalf("expecting %q, got %q", exp, b)
+	}
+}
+
+func TestMergeStderrIntoStdout(t *testing.T) {
+	t.Run("run", func(t *testing.T) {
+		var stdout bytes.Buffer
+		err := exex.Command(os.Args[0], "merged").Apply(
+			exex.WithStdout(&stdout),
+			exex.MergeStderrIntoStdout(),
+		).Run()
+		if err == nil {
+			t.Fatal("expecting an error")
+		}
+		if exp := "error: merged"; stdout.String() != exp {
+			t.Fatalf("expecting %q, got %q", exp, stdout.String())
+		}
+	})
+
+	t.Run("output", func(t *testing.T) {
+		out, err := exex.Command(os.Args[0], "merged").Apply(exex.MergeStderrIntoStdout()).Output()
+		if err == nil {
+			t.Fatal("expecting an error")
+		}
+		if exp := "error: merged"; string(out) != exp {
+			t.Fatalf("expecting %q, got %q", exp, out)
+		}
+	})
+}
+
+func TestRedirectConflicts(t *testing.T) {
+	tests := map[string][]exex.Option{
+		"duplicate":    {exex.RedirectFD(4, os.Stdout), exex.RedirectFD(4, os.Stderr)},
+		"already set":  {exex.WithStdout(io.Discard), exex.RedirectFD(1, os.Stdout)},
+		"merge":        {exex.RedirectFD(2, os.Stdout), exex.MergeStderrIntoStdout()},
+		"negative":     {exex.RedirectFD(-1, os.Stdout)},
+		"stderr piped": {exex.MergeStderrIntoStdout(), func(c *exex.Cmd) { c.Stderr = io.Discard }},
+	}
+
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := exex.Command(os.Args[0]).Apply(opts...).Run()
+			var cmdErr *exex.CmdError
+			if err == nil || errors.As(err, &cmdErr) || !strings.Contains(err.Error(), "file descriptor") {
+				t.Fatalf("expecting a redirection error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package exex
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+)
+
+// Result holds information about a finished command.
+type Result struct {
+	// ExitCode is the exit code of the command, or -1 if it was
+	// terminated by a signal.
+	ExitCode int
+
+	// Duration is the time elapsed between starting the command and
+	// its end.
+	Duration time.Duration
+
+	// Changes lists the files that were created, modified or removed
+	// while the command ran, sorted by path. It is only populated
+	// when using WithDirSnapshot.
+	Changes []FileChange
+
+	// Touched lists the files that were created or modified while the
+	// command ran, sorted by path. It is only populated when using
+	// WithWatch.
+	Touched []FileChange
+
+	// Artifacts lists the slash-separated paths of the files collected
+	// by WithArtifacts, relative to ArtifactsFS.
+	Artifacts []string
+
+	// ArtifactsFS provides access to the collected Artifacts.
+	ArtifactsFS fs.FS
+
+	// DroppedLines is the number of output lines that the streaming
+	// APIs dropped because of BackpressureDrop.
+	DroppedLines int
+
+	// StdinClosed reports whether the command closed its standard
+	// input, or exited, before all of Stdin was written to it. It is
+	// always false if Stdin is nil or an *os.File.
+	StdinClosed bool
+
+	// StdioHeld reports whether the command exited successfully but
+	// its standard streams were kept open by other processes, e.g.
+	// its descendants when it daemonizes, until they were closed
+	// after WaitDelay. Wait then returns nil instead of
+	// exec.ErrWaitDelay.
+	StdioHeld bool
+
+	// Orphans lists the processes found by WithOrphanCheck still
+	// running after the command finished, sorted by PID.
+	Orphans []int
+
+	// TempFiles lists the slash-separated paths of the files that the
+	// command left in its temporary directory, relative to it, when
+	// using WithTempTracking. They were removed along with the
+	// directory.
+	TempFiles []string
+
+	// TempBytes is the total size of TempFiles.
+	TempBytes int64
+
+	// OutputTruncated reports whether the output of the command was
+	// truncated because of WithTruncatedOutput or WithMaxOutput.
+	OutputTruncated bool
+
+	// Usage is the resource usage of the command, or nil if it was
+	// not executed as a local process.
+	Usage *Usage
+
+	// Warnings lists the problems detected by WithHeuristics in a
+	// command that finished successfully, such as a
+	// *StderrOutputWarning, and the features skipped because of
+	// DegradeWarn, as *UnsupportedFeatureError.
+	Warnings []error
+}
+
+// Result returns information about the command once it finished, or
+// nil if it has not finished or could not be started.
+func (c *Cmd) Result() *Result {
+	return c.result
+}
+
+// Duration returns the time elapsed between starting the command and
+// its end, as reported in Result.Duration and CmdError.Duration, or
+// since it was started if it is still running. It is zero if the
+// command has not been started.
+func (c *Cmd) Duration() time.Duration {
+	switch {
+	case c.result != nil:
+		return c.result.Duration
+	case c.started.IsZero():
+		return 0
+	}
+	return time.Since(c.started)
+}
+
+// setResult populates the Result of the finished command, given the
+// error returned by its Runner.
+func (c *Cmd) setResult(err error) error {
+	if !c.running {
+		return nil
+	}
+
+	c.result = &Result{
+		Duration: time.Since(c.started),
+	}
+
+	c.result.StdinClosed = c.stdinEOF != nil && !c.stdinEOF.eof.Load()
+	c.result.OutputTruncated = c.outputLimit != nil && c.outputLimit.exceeded.Load()
+
+	var ec exitCoder
+	switch {
+	case c.ProcessState != nil:
+		c.result.ExitCode = c.ProcessState.ExitCode()
+		c.result.Usage = processUsage(c.ProcessState)
+	case errors.As(err, &ec):
+		c.result.ExitCode = ec.ExitCode()
+	case err != nil:
+		c.result.ExitCode = -1
+	}
+
+	c.result.Warnings = append(c.result.Warnings, c.unsupported...)
+	if err == nil {
+		c.result.Warnings = append(c.result.Warnings, c.heuristics.warnings()...)
+	}
+
+	if c.watch != nil {
+		c.result.Touched = c.watch.finish()
+	}
+
+	if c.snapshot != nil {
+		changes, err := c.snapshot.diff(c.Dir)
+		if err != nil {
+			return err
+		}
+		c.result.Changes = changes
+	}
+
+	if c.artifacts != nil {
+		return c.artifacts.collect(c.Dir, c.result)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package exex_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+func TestDuration(t *testing.T) {
+	cmd := exex.Command("/bin/sh", "-c", "read x; exit 1")
+	if d := cmd.Duration(); d != 0 {
+		t.Fatalf("expecting no duration before starting, got %v", d)
+	}
+
+	w, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if d := cmd.Duration(); d < 50*time.Millisecond {
+		t.Fatalf("expecting running duration of at least 50ms, got %v", d)
+	}
+	w.Close()
+
+	err = cmd.Wait()
+	d := cmd.Duration()
+	if d != cmd.Result().Duration {
+		t.Fatalf("expecting %v, got %v", cmd.Result().Duration, d)
+	}
+
+	var cmdErr *exex.CmdError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("expecting *CmdError, got %v", err)
+	}
+	if cmdErr.Duration != d {
+		t.Fatalf("expecting error duration %v, got %v", d, cmdErr.Duration)
+	}
+}
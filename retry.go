@@ -0,0 +1,141 @@
+package exex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"time"
+)
+
+// RetryPolicy decides whether, and after how long, a failed command
+// must be run again by Retry.
+type RetryPolicy interface {
+	// Backoff is called after attempt, starting at 1, failed with
+	// err. It returns how long to wait before the next attempt, or
+	// false if no further attempts must be made.
+	Backoff(attempt int, err error) (time.Duration, bool)
+}
+
+// Backoff is a RetryPolicy that retries commands that exited with a
+// non-zero status, waiting exponentially longer between attempts.
+// Errors that are not exit errors, such as the executable not being
+// found, are never retried.
+//
+// The zero value makes up to 3 attempts waiting 100ms, 200ms and so
+// on between them.
+type Backoff struct {
+	// MaxAttempts is the maximum number of times the command is run,
+	// including the first one. Defaults to 3.
+	MaxAttempts int
+
+	// Initial is the delay before the first retry. Defaults to 100ms.
+	Initial time.Duration
+
+	// Max, if positive, caps the delay between attempts.
+	Max time.Duration
+
+	// Multiplier is the factor by which the delay grows after each
+	// retry. Defaults to 2.
+	Multiplier float64
+
+	// Jitter is the fraction, between 0 and 1, by which each delay is
+	// randomly reduced, to avoid retrying in lockstep.
+	Jitter float64
+
+	// ExitCodes, if not empty, restricts retries to commands exiting
+	// with one of these codes.
+	ExitCodes []int
+
+	// StderrPattern, if not nil, restricts retries to commands whose
+	// captured standard error matches it.
+	StderrPattern *regexp.Regexp
+}
+
+// Backoff implements RetryPolicy.
+func (b *Backoff) Backoff(attempt int, err error) (time.Duration, bool) {
+	max := b.MaxAttempts
+	if max == 0 {
+		max = 3
+	}
+	if attempt >= max || !b.retryable(err) {
+		return 0, false
+	}
+
+	d := b.Initial
+	if d == 0 {
+		d = 100 * time.Millisecond
+	}
+	m := b.Multiplier
+	if m == 0 {
+		m = 2
+	}
+	for i := 1; i < attempt && (b.Max <= 0 || d < b.Max); i++ {
+		d = time.Duration(float64(d) * m)
+	}
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+
+	if b.Jitter > 0 {
+		d -= time.Duration(b.Jitter * rand.Float64() * float64(d))
+	}
+
+	return d, true
+}
+
+func (b *Backoff) retryable(err error) bool {
+	var exErr *ExitError
+	if !errors.As(err, &exErr) {
+		return false
+	}
+
+	if len(b.ExitCodes) > 0 {
+		var found bool
+		for _, code := range b.ExitCodes {
+			if code == exErr.ExitCode() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return b.StderrPattern == nil || b.StderrPattern.Match(exErr.Stderr)
+}
+
+// Retry runs the command returned by newCmd until it succeeds or
+// policy decides to stop retrying, and returns the error of the last
+// attempt. As a Cmd cannot be reused, newCmd is called for every
+// attempt; Cmd.Clone of a configured command can be used as newCmd.
+//
+// If ctx is done during an attempt or while waiting between attempts,
+// the returned error matches ctx.Err() when using errors.Is, and also
+// wraps the error of the last attempt.
+func Retry(ctx context.Context, policy RetryPolicy, newCmd func() *Cmd) error {
+	for attempt := 1; ; attempt++ {
+		err := newCmd().Run()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w (%w)", ctx.Err(), err)
+		}
+
+		d, ok := policy.Backoff(attempt, err)
+		if !ok {
+			return err
+		}
+
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("%w (%w)", ctx.Err(), err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package exex_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	policy := &exex.Backoff{MaxAttempts: 5, Initial: time.Millisecond}
+
+	t.Run("succeeds", func(t *testing.T) {
+		var n int
+		err := exex.Retry(ctx, policy, func() *exex.Cmd {
+			n++
+			if n < 3 {
+				return exex.Command(os.Args[0], "flaky")
+			}
+			return exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=version"))
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != 3 {
+			t.Fatalf("expecting 3 attempts, got %d", n)
+		}
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		var n int
+		err := exex.Retry(ctx, policy, func() *exex.Cmd {
+			n++
+			return exex.Command(os.Args[0], "broken")
+		})
+		assertErr(t, err, "error: broken")
+		if n != 5 {
+			t.Fatalf("expecting 5 attempts, got %d", n)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var n int
+		err := exex.Retry(ctx, policy, func() *exex.Cmd {
+			n++
+			return exex.Command("foobarbazquux")
+		})
+		if !errors.Is(err, exex.ErrNotFound) {
+			t.Fatalf("expecting exex.ErrNotFound, got %v", err)
+		}
+		if n != 1 {
+			t.Fatalf("expecting a single attempt, got %d", n)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// The command is not bound to ctx, so it runs to completion
+		// and fails after ctx is cancelled.
+		err := exex.Retry(ctx, &exex.Backoff{Initial: time.Minute}, func() *exex.Cmd {
+			cancel()
+			return exex.Command(os.Args[0], "cancelled")
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expecting context.Canceled, got %v", err)
+		}
+		assertErr(t, err, "error: cancelled")
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	run := func(code, stderr string) error {
+		return exex.Command(os.Args[0], code, stderr).Apply(exex.WithEnv("TEST_MAIN=exit")).Run()
+	}
+
+	b := &exex.Backoff{
+		MaxAttempts:   4,
+		Initial:       time.Second,
+		Max:           3 * time.Second,
+		ExitCodes:     []int{2, 3},
+		StderrPattern: regexp.MustCompile(`(?i)timed out`),
+	}
+
+	tests := []struct {
+		name    string
+		attempt int
+		err     error
+		delay   time.Duration
+		retry   bool
+	}{
+		{"first", 1, run("2", "connection timed out"), time.Second, true},
+		{"second", 2, run("3", "Timed out"), 2 * time.Second, true},
+		{"capped", 3, run("2", "timed out"), 3 * time.Second, true},
+		{"exhausted", 4, run("2", "timed out"), 0, false},
+		{"exit code", 1, run("1", "timed out"), 0, false},
+		{"stderr", 1, run("2", "not found"), 0, false},
+		{"not exit error", 1, errors.New("foo"), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, retry := b.Backoff(tt.attempt, tt.err)
+			if retry != tt.retry || d != tt.delay {
+				t.Fatalf("expecting (%v, %v), got (%v, %v)", tt.delay, tt.retry, d, retry)
+			}
+		})
+	}
+
+	t.Run("jitter", func(t *testing.T) {
+		b := &exex.Backoff{Initial: time.Second, Jitter: 0.5}
+		err := run("1", "")
+		for i := 0; i < 100; i++ {
+			d, _ := b.Backoff(1, err)
+			if d < 500*time.Millisecond || d > time.Second {
+				t.Fatalf("delay %v out of range", d)
+			}
+		}
+	})
+}
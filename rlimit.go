@@ -0,0 +1,65 @@
+package exex
+
+// RLimitResource is a resource limited with WithRLimit.
+type RLimitResource int
+
+const (
+	// RLimitCPU is the CPU time, in seconds.
+	RLimitCPU RLimitResource = iota
+
+	// RLimitAS is the size of the address space, in bytes.
+	RLimitAS
+
+	// RLimitNOFILE is the number of open files.
+	RLimitNOFILE
+
+	// RLimitCore is the size of the core dumps, in bytes.
+	RLimitCore
+
+	// RLimitFSize is the size of the files created, in bytes.
+	RLimitFSize
+
+	// RLimitData is the size of the data segment, in bytes.
+	RLimitData
+
+	// RLimitStack is the size of the stack, in bytes.
+	RLimitStack
+)
+
+// RLimitInfinity is the value of a resource limit meaning no limit.
+const RLimitInfinity = ^uint64(0)
+
+// WithRLimit sets the soft and hard limits of the given resource for
+// the command, and the processes it starts. It can be used multiple
+// times to limit several resources.
+//
+// Go cannot run code between fork and exec, so the limits are set
+// right after the command is started with prlimit, and the command
+// is killed if that fails; commands reading their limits at startup,
+// like shells, should do so after reading their input. It is only
+// supported on Linux; elsewhere starting the command fails with an
+// *UnsupportedFeatureError, unless set otherwise with
+// WithDegradation.
+func WithRLimit(resource RLimitResource, soft, hard uint64) Option {
+	return func(c *Cmd) {
+		c.rlimits = append(c.rlimits, rlimit{resource: resource, soft: soft, hard: hard})
+	}
+}
+
+type rlimit struct {
+	resource   RLimitResource
+	soft, hard uint64
+}
+
+// startRLimits sets the resource limits of the started command,
+// killing it if that fails.
+func (c *Cmd) startRLimits() error {
+	if c.Process == nil {
+		return nil
+	}
+	if err := setRLimits(c.Process.Pid, c.rlimits); err != nil {
+		c.Process.Kill()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package exex
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var rlimitResources = map[RLimitResource]int{
+	RLimitCPU:    syscall.RLIMIT_CPU,
+	RLimitAS:     syscall.RLIMIT_AS,
+	RLimitNOFILE: syscall.RLIMIT_NOFILE,
+	RLimitCore:   syscall.RLIMIT_CORE,
+	RLimitFSize:  syscall.RLIMIT_FSIZE,
+	RLimitData:   syscall.RLIMIT_DATA,
+	RLimitStack:  syscall.RLIMIT_STACK,
+}
+
+// checkRLimits reports whether the resources of limits are known.
+func checkRLimits(limits []rlimit) error {
+	for _, l := range limits {
+		if _, ok := rlimitResources[l.resource]; !ok {
+			return fmt.Errorf("exex: unknown resource %d", l.resource)
+		}
+	}
+	return nil
+}
+
+// setRLimits sets the resource limits of the process pid.
+func setRLimits(pid int, limits []rlimit) error {
+	for _, l := range limits {
+		res := rlimitResources[l.resource]
+
+		// struct rlimit64 of prlimit64 has 64 bits fields in all
+		// architectures.
+		lim := [2]uint64{l.soft, l.hard}
+		_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(res), uintptr(unsafe.Pointer(&lim)), 0, 0, 0)
+		if errno != 0 {
+			return fmt.Errorf("exex: rlimit %d: %w", l.resource, errno)
+		}
+	}
+	return nil
+}
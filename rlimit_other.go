@@ -0,0 +1,9 @@
+//go:build !linux
+
+package exex
+
+func checkRLimits(limits []rlimit) error {
+	return unsupported("resource limits")
+}
+
+func setRLimits(pid int, limits []rlimit) error { return nil }
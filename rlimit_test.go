@@ -0,0 +1,65 @@
+package exex_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithRLimit(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		cmd := exex.Command(os.Args[0], "0").Apply(
+			exex.WithEnv("TEST_MAIN=exit"),
+			exex.WithRLimit(exex.RLimitNOFILE, 32, 32),
+		)
+		if err := cmd.Run(); !errors.Is(err, errors.ErrUnsupported) {
+			t.Fatalf("expecting unsupported error, got %v", err)
+		}
+		if cmd.Process != nil {
+			t.Fatal("expecting command not to be started")
+		}
+
+		cmd = exex.Command(os.Args[0], "0").Apply(
+			exex.WithEnv("TEST_MAIN=exit"),
+			exex.WithRLimit(exex.RLimitNOFILE, 32, 32),
+			exex.WithDegradation(exex.DegradeIgnore),
+		)
+		if err := cmd.Run(); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	unknown := exex.Command(os.Args[0], "0").Apply(
+		exex.WithEnv("TEST_MAIN=exit"),
+		exex.WithRLimit(exex.RLimitResource(-1), 0, 0),
+	)
+	if err := unknown.Run(); err == nil || unknown.Process != nil {
+		t.Fatalf("expecting unknown resource to fail before starting, got %v", err)
+	}
+
+	r, w := io.Pipe()
+	cmd := exex.Command("/bin/sh", "-c", "read x; ulimit -Sn; ulimit -Hn; ulimit -c").Apply(
+		exex.WithRLimit(exex.RLimitNOFILE, 32, 64),
+		exex.WithRLimit(exex.RLimitCore, 0, 0),
+	)
+	cmd.Stdin = r
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("\n"))
+	w.Close()
+	if err := cmd.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "32\n64\n0\n"; out.String() != exp {
+		t.Fatalf("expecting %q, got %q", exp, out.String())
+	}
+}
@@ -0,0 +1,104 @@
+package exex
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// RunAllError is the error returned by RunAll and RunAllParallel when
+// any of the commands fails.
+type RunAllError struct {
+	// Errors holds the error of each command, in the order they were
+	// given, being nil for the commands that succeeded.
+	Errors []error
+}
+
+func (e *RunAllError) Error() string {
+	var (
+		b      strings.Builder
+		failed int
+	)
+	for _, err := range e.Errors {
+		if err != nil {
+			failed++
+		}
+	}
+	fmt.Fprintf(&b, "exex: %d of %d commands failed", failed, len(e.Errors))
+	for i, err := range e.Errors {
+		if err != nil {
+			fmt.Fprintf(&b, "; command %d: %v", i, err)
+		}
+	}
+	return b.String()
+}
+
+// Unwrap returns the non-nil errors of the commands, so errors.Is and
+// errors.As match any of them.
+func (e *RunAllError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// RunAll runs the independent commands one after the other, even if
+// some of them fail, and reports all the failures at once: if any
+// command fails, the returned error is a *RunAllError holding the
+// error of every command, each of them with its own captured standard
+// error as described in *Cmd.Run.
+//
+// If ctx is done, the running command is killed and the remaining ones
+// are not started, their error being ctx.Err().
+func RunAll(ctx context.Context, cmds ...*Cmd) error {
+	errs := make([]error, len(cmds))
+	for i, c := range cmds {
+		errs[i] = runWithContext(ctx, c)
+	}
+	return runAllError(errs)
+}
+
+// RunAllParallel is like RunAll but runs all the commands
+// concurrently.
+func RunAllParallel(ctx context.Context, cmds ...*Cmd) error {
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(cmds))
+	)
+	for i, c := range cmds {
+		wg.Add(1)
+		go func(i int, c *Cmd) {
+			defer wg.Done()
+			errs[i] = runWithContext(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	return runAllError(errs)
+}
+
+// runWithContext runs c, killing it if ctx is done first.
+func runWithContext(ctx context.Context, c *Cmd) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := c.Start(); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { c.abort(ctx.Err()) })
+	defer stop()
+	return c.Wait()
+}
+
+func runAllError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return &RunAllError{Errors: errs}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package exex_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestRunAll(t *testing.T) {
+	tests := map[string]func(context.Context, ...*exex.Cmd) error{
+		"sequential": exex.RunAll,
+		"parallel":   exex.RunAllParallel,
+	}
+
+	cmd := func(args ...string) *exex.Cmd {
+		return exex.Command(os.Args[0], args...).Apply(exex.WithEnv("TEST_MAIN=exit"))
+	}
+
+	for name, runAll := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := runAll(context.Background(), cmd("0", "ok"), cmd("1", "first"), cmd("2", "second"))
+
+			var raErr *exex.RunAllError
+			if !errors.As(err, &raErr) {
+				t.Fatalf("expecting *RunAllError, got %v", err)
+			}
+			if len(raErr.Errors) != 3 || raErr.Errors[0] != nil {
+				t.Fatalf("unexpected errors %v", raErr.Errors)
+			}
+			assertErr(t, raErr.Errors[1], "first")
+			assertErr(t, raErr.Errors[2], "second")
+
+			if code, _ := exex.ExitCode(raErr.Errors[2]); code != 2 {
+				t.Fatalf("expecting exit code 2, got %d", code)
+			}
+
+			if err := runAll(context.Background(), cmd("0"), cmd("0")); err != nil {
+				t.Fatal(err)
+			}
+		})
+
+		t.Run(name+" cancelled", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := runAll(ctx, cmd("0"), cmd("0"))
+			var raErr *exex.RunAllError
+			if !errors.As(err, &raErr) {
+				t.Fatalf("expecting *RunAllError, got %v", err)
+			}
+			for _, err := range raErr.Errors {
+				if !errors.Is(err, context.Canceled) {
+					t.Fatalf("expecting context.Canceled, got %v", err)
+				}
+			}
+		})
+	}
+}
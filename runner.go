@@ -0,0 +1,96 @@
+package exex
+
+import (
+	"context"
+	"os/exec"
+)
+
+// Runner executes commands. Start and Wait on a Cmd delegate to its
+// Runner for the actual execution, which allows replacing how
+// commands are executed, e.g. to fake them in tests, or to wrap their
+// execution with additional behavior.
+//
+// When a Runner is called, the Cmd has already been prepared: options
+// have been applied and its Stdin, Stdout and Stderr fields are set
+// to the streams the command must use, with Stderr capturing the
+// standard error if it was not set by the caller. Runners wrapping
+// another Runner should delegate to it for actually starting and
+// waiting for the command.
+//
+// Errors returned by Wait having an ExitCode() int method, such as
+// *ExitError, are reported as a *CmdError.
+//
+// Runners that record the commands they run, e.g. for auditing,
+// metrics or logging, should honor Cmd.Quiet and Cmd.AuditTags.
+type Runner interface {
+	// Start starts the command but does not wait for it to complete.
+	Start(c *Cmd) error
+
+	// Wait waits for the command started with Start to exit and for
+	// the copying of its streams to complete.
+	Wait(c *Cmd) error
+}
+
+// DefaultRunner is the Runner used by commands without one set with
+// WithRunner.
+var DefaultRunner Runner = LocalRunner{}
+
+// WithRunner sets the Runner that executes the command.
+func WithRunner(r Runner) Option {
+	return func(c *Cmd) { c.runner = r }
+}
+
+// Context returns the context the command was created with, or
+// context.Background if it was created without one.
+func (c *Cmd) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+func (c *Cmd) runnerOrDefault() Runner {
+	if c.runner != nil {
+		return c.runner
+	}
+	return DefaultRunner
+}
+
+// LocalRunner is the Runner that executes commands as processes of
+// the local machine using os/exec.
+//
+// Its methods mirror the top-level helper functions of this package,
+// with the commands they create always executed by the LocalRunner
+// regardless of DefaultRunner.
+type LocalRunner struct{}
+
+// Start implements Runner.
+func (LocalRunner) Start(c *Cmd) error { return c.Cmd.Start() }
+
+// Wait implements Runner.
+func (LocalRunner) Wait(c *Cmd) error { return c.Cmd.Wait() }
+
+// Command is like the top-level Command function.
+func (r LocalRunner) Command(name string, args ...string) *Cmd {
+	return Command(name, args...).Apply(WithRunner(r))
+}
+
+// CommandContext is like the top-level CommandContext function.
+func (r LocalRunner) CommandContext(ctx context.Context, name string, args ...string) *Cmd {
+	return CommandContext(ctx, name, args...).Apply(WithRunner(r))
+}
+
+// RunCommand is like the top-level RunCommand function.
+func (r LocalRunner) RunCommand(cmd *exec.Cmd, opts ...Option) error {
+	return (&Cmd{Cmd: cmd, runner: r}).Apply(opts...).Run()
+}
+
+// Run is like the top-level Run function.
+func (r LocalRunner) Run(cmd string, args ...string) error {
+	return r.Command(cmd, args...).Run()
+}
+
+// RunContext is like the top-level RunContext function.
+func (r LocalRunner) RunContext(ctx context.Context, cmd string, args ...string) error {
+	return r.CommandContext(ctx, cmd, args...).Run()
+}
@@ -0,0 +1,87 @@
+package exex_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+// recordingRunner records the commands it runs before delegating to
+// the LocalRunner.
+type recordingRunner struct {
+	started, waited []string
+}
+
+func (r *recordingRunner) Start(c *exex.Cmd) error {
+	r.started = append(r.started, c.Args[1])
+	return exex.LocalRunner{}.Start(c)
+}
+
+func (r *recordingRunner) Wait(c *exex.Cmd) error {
+	r.waited = append(r.waited, c.Args[1])
+	return exex.LocalRunner{}.Wait(c)
+}
+
+// refusingRunner refuses to run any command.
+type refusingRunner struct{}
+
+var errRefused = errors.New("refused")
+
+func (refusingRunner) Start(*exex.Cmd) error { return errRefused }
+func (refusingRunner) Wait(*exex.Cmd) error  { return errRefused }
+
+func TestWithRunner(t *testing.T) {
+	r := &recordingRunner{}
+
+	err := exex.Command(os.Args[0], "foo").Apply(exex.WithRunner(r)).Run()
+	assertErr(t, err, "error: foo")
+
+	out, err := exex.Command(os.Args[0], "bar").Apply(
+		exex.WithRunner(r),
+		exex.WithEnv("TEST_MAIN=echo"),
+	).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "bar\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+
+	if len(r.started) != 2 || r.started[0] != "foo" || r.started[1] != "bar" {
+		t.Errorf("unexpected started commands %q", r.started)
+	}
+	if len(r.waited) != 2 || r.waited[0] != "foo" || r.waited[1] != "bar" {
+		t.Errorf("unexpected waited commands %q", r.waited)
+	}
+}
+
+func TestDefaultRunner(t *testing.T) {
+	defer func(r exex.Runner) { exex.DefaultRunner = r }(exex.DefaultRunner)
+	exex.DefaultRunner = refusingRunner{}
+
+	if err := exex.Run(os.Args[0]); !errors.Is(err, errRefused) {
+		t.Fatalf("expecting errRefused, got %v", err)
+	}
+
+	// LocalRunner always runs commands locally.
+	err := exex.LocalRunner{}.Run(os.Args[0], "local")
+	assertErr(t, err, "error: local")
+
+	err = exex.LocalRunner{}.RunContext(context.Background(), os.Args[0], "local", "context")
+	assertErr(t, err, "error: local context")
+}
+
+func TestCmdContext(t *testing.T) {
+	if ctx := exex.Command(os.Args[0]).Context(); ctx != context.Background() {
+		t.Errorf("expecting background context, got %v", ctx)
+	}
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	if got := exex.CommandContext(ctx, os.Args[0]).Context(); got != ctx {
+		t.Errorf("expecting %v, got %v", ctx, got)
+	}
+}
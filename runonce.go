@@ -0,0 +1,82 @@
+package exex
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// AlreadyRanError is the error returned when starting a command set
+// with WithRunOnce whose marker file exists.
+type AlreadyRanError struct {
+	// Marker is the path of the marker file.
+	Marker string
+
+	// ModTime is the modification time of the marker file, usually
+	// when the command succeeded.
+	ModTime time.Time
+}
+
+func (e *AlreadyRanError) Error() string {
+	return fmt.Sprintf("exex: already ran at %s, marker %s exists", e.ModTime.Format(time.RFC3339), e.Marker)
+}
+
+// WithRunOnce makes the command run at most once successfully, which
+// is useful for one-shot commands like migrations. If the file at
+// markerPath exists, the command is not started and an
+// *AlreadyRanError is returned. Otherwise the marker file is written
+// atomically once the command succeeds; errors writing it are
+// returned by Wait.
+//
+// A relative markerPath is relative to the current directory, not to
+// the working directory of the command.
+func WithRunOnce(markerPath string) Option {
+	return func(c *Cmd) {
+		c.runOnce = markerPath
+	}
+}
+
+// checkRunOnce returns an *AlreadyRanError if the marker file set
+// with WithRunOnce exists.
+func (c *Cmd) checkRunOnce() error {
+	if c.runOnce == "" {
+		return nil
+	}
+
+	fi, err := os.Stat(c.runOnce)
+	if err == nil {
+		return &AlreadyRanError{Marker: c.runOnce, ModTime: fi.ModTime()}
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("exex: run once: %w", err)
+	}
+
+	return nil
+}
+
+// markRan atomically writes the marker file set with WithRunOnce.
+func (c *Cmd) markRan() error {
+	if c.runOnce == "" {
+		return nil
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(c.runOnce), ".runonce-*")
+	if err != nil {
+		return fmt.Errorf("exex: run once: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = fmt.Fprintln(f, time.Now().Format(time.RFC3339))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), c.runOnce)
+	}
+	if err != nil {
+		return fmt.Errorf("exex: run once: %w", err)
+	}
+
+	return nil
+}
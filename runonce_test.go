@@ -0,0 +1,55 @@
+package exex_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithRunOnce(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "migrated")
+	count := filepath.Join(dir, "count")
+
+	run := func() error {
+		return exex.Command(os.Args[0], count+"=ran").Apply(
+			exex.WithEnv("TEST_MAIN=files"),
+			exex.WithRunOnce(marker),
+		).Run()
+	}
+
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("expecting marker to be written: %v", err)
+	}
+
+	os.Remove(count)
+
+	err := run()
+	var arErr *exex.AlreadyRanError
+	if !errors.As(err, &arErr) {
+		t.Fatalf("expecting *exex.AlreadyRanError, got %v", err)
+	}
+	if arErr.Marker != marker {
+		t.Errorf("expecting marker %q, got %q", marker, arErr.Marker)
+	}
+	if _, err := os.Stat(count); !os.IsNotExist(err) {
+		t.Error("expecting command not to run again")
+	}
+}
+
+func TestWithRunOnceFailure(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "migrated")
+
+	err := exex.Command(os.Args[0], "failed").Apply(exex.WithRunOnce(marker)).Run()
+	assertErr(t, err, "error: failed")
+
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatal("expecting no marker after failure")
+	}
+}
@@ -0,0 +1,68 @@
+package exex
+
+import "path/filepath"
+
+// SandboxSystemPaths are the paths that commands confined with
+// WithFilesystemSandbox can read and execute files from, so that
+// they can load their libraries and configuration. Paths that do not
+// exist are ignored.
+var SandboxSystemPaths = []string{"/bin", "/sbin", "/usr", "/lib", "/lib32", "/lib64", "/etc"}
+
+// WithFilesystemSandbox confines the command, and the processes it
+// starts, to the given paths, e.g. its working directory, which it
+// can fully access, while it can only read and execute the files in
+// SandboxSystemPaths and the executable of the command, and read and
+// write the null device. Relative paths are relative to the working
+// directory of the command.
+//
+// On Linux 5.13 and later the sandbox is enforced with Landlock,
+// where it does not affect the files the command already has open,
+// like its standard input and outputs, and the command cannot gain
+// privileges, e.g. running setuid executables. Otherwise, if the
+// current process runs as root and a single path is allowed, the
+// command is run with the path as its root directory using chroot, so
+// that its executable and libraries must be inside it. Elsewhere
+// starting the command fails with an *UnsupportedFeatureError, unless
+// set otherwise with WithDegradation.
+func WithFilesystemSandbox(allowedPaths ...string) Option {
+	return func(c *Cmd) {
+		c.sandbox = &fsSandbox{paths: append([]string(nil), allowedPaths...)}
+	}
+}
+
+type fsSandbox struct {
+	paths []string
+}
+
+// allowed returns the absolute allowed paths of the command.
+func (s *fsSandbox) allowed(c *Cmd) ([]string, error) {
+	paths := make([]string, len(s.paths))
+	for i, p := range s.paths {
+		if !filepath.IsAbs(p) && c.Dir != "" {
+			p = filepath.Join(c.Dir, p)
+		}
+		p, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		paths[i] = p
+	}
+	return paths, nil
+}
+
+// startSandboxed calls start, which starts the command, confining it
+// as set with WithFilesystemSandbox.
+func (c *Cmd) startSandboxed(start func() error) error {
+	if c.sandbox == nil {
+		return start()
+	}
+
+	run, err := c.sandbox.prepare(c)
+	if err != nil {
+		if err := c.degrade(err); err != nil {
+			return err
+		}
+		return start()
+	}
+	return run(start)
+}
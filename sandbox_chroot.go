@@ -0,0 +1,9 @@
+//go:build unix && !linux
+
+package exex
+
+// prepare returns the function starting the command confined to its
+// allowed paths.
+func (s *fsSandbox) prepare(c *Cmd) (func(func() error) error, error) {
+	return s.chroot(c)
+}
@@ -0,0 +1,157 @@
+package exex
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// Landlock system calls, numbered the same in all architectures.
+const (
+	sysLandlockCreateRuleset = 444
+	sysLandlockAddRule       = 445
+	sysLandlockRestrictSelf  = 446
+)
+
+const (
+	landlockCreateRulesetVersion = 1 << 0
+	landlockRulePathBeneath      = 1
+
+	landlockAccessExecute   = 1 << 0
+	landlockAccessWriteFile = 1 << 1
+	landlockAccessReadFile  = 1 << 2
+	landlockAccessReadDir   = 1 << 3
+	landlockAccessTruncate  = 1 << 14
+
+	// landlockFileAccess are the access rights that apply to files
+	// rather than directories.
+	landlockFileAccess = landlockAccessExecute | landlockAccessWriteFile | landlockAccessReadFile | landlockAccessTruncate
+
+	prSetNoNewPrivs = 38
+
+	// oPath is O_PATH, missing in package syscall.
+	oPath = 0x200000
+)
+
+// prepare returns the function starting the command confined to its
+// allowed paths, using Landlock if available, or chroot otherwise.
+func (s *fsSandbox) prepare(c *Cmd) (func(func() error) error, error) {
+	abi, _, errno := syscall.Syscall(sysLandlockCreateRuleset, 0, 0, landlockCreateRulesetVersion)
+	if errno == syscall.ENOSYS || errno == syscall.EOPNOTSUPP {
+		return s.chroot(c)
+	}
+	if errno != 0 {
+		return nil, fmt.Errorf("exex: filesystem sandbox: %w", errno)
+	}
+
+	// The access rights handled by each version of the ABI; later
+	// versions handle rights not relevant to the file system.
+	handled := uint64(1<<13 - 1)
+	switch {
+	case abi >= 3:
+		handled = 1<<15 - 1
+	case abi == 2:
+		handled = 1<<14 - 1
+	}
+
+	fd, _, errno := syscall.Syscall(sysLandlockCreateRuleset, uintptr(unsafe.Pointer(&handled)), unsafe.Sizeof(handled), 0)
+	if errno != 0 {
+		return nil, fmt.Errorf("exex: filesystem sandbox: %w", errno)
+	}
+	ruleset := int(fd)
+
+	if err := s.addRules(c, ruleset, handled); err != nil {
+		syscall.Close(ruleset)
+		return nil, fmt.Errorf("exex: filesystem sandbox: %w", err)
+	}
+
+	return func(start func() error) error {
+		defer syscall.Close(ruleset)
+		return restricted(ruleset, start)
+	}, nil
+}
+
+// addRules adds the paths the command can access to ruleset.
+func (s *fsSandbox) addRules(c *Cmd, ruleset int, handled uint64) error {
+	readExec := uint64(landlockAccessExecute | landlockAccessReadFile | landlockAccessReadDir)
+
+	for _, p := range SandboxSystemPaths {
+		if err := addLandlockRule(ruleset, p, readExec&handled); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := addLandlockRule(ruleset, os.DevNull, (landlockAccessReadFile|landlockAccessWriteFile)&handled); err != nil {
+		return err
+	}
+	if c.Path != "" {
+		if err := addLandlockRule(ruleset, c.Path, readExec&handled); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	paths, err := s.allowed(c)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if err := addLandlockRule(ruleset, p, handled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addLandlockRule allows access to the path name and, if a directory,
+// everything beneath it.
+func addLandlockRule(ruleset int, name string, access uint64) error {
+	fd, err := syscall.Open(name, oPath|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	defer syscall.Close(fd)
+
+	var st syscall.Stat_t
+	if err := syscall.Fstat(fd, &st); err != nil {
+		return &os.PathError{Op: "stat", Path: name, Err: err}
+	}
+	if st.Mode&syscall.S_IFMT != syscall.S_IFDIR {
+		access &= landlockFileAccess
+	}
+
+	// struct landlock_path_beneath_attr is packed.
+	var attr [12]byte
+	binary.NativeEndian.PutUint64(attr[:8], access)
+	binary.NativeEndian.PutUint32(attr[8:], uint32(fd))
+
+	_, _, errno := syscall.Syscall6(sysLandlockAddRule, uintptr(ruleset), landlockRulePathBeneath, uintptr(unsafe.Pointer(&attr)), 0, 0, 0)
+	if errno != 0 {
+		return &os.PathError{Op: "landlock_add_rule", Path: name, Err: errno}
+	}
+	return nil
+}
+
+// restricted calls start in a new thread restricted by ruleset, so
+// that the processes it starts inherit the restrictions.
+func restricted(ruleset int, start func() error) error {
+	errc := make(chan error, 1)
+	go func() {
+		// The thread is never unlocked, so that it exits with the
+		// goroutine instead of running other goroutines.
+		runtime.LockOSThread()
+
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); errno != 0 {
+			errc <- fmt.Errorf("exex: filesystem sandbox: %w", errno)
+			return
+		}
+		if _, _, errno := syscall.RawSyscall(sysLandlockRestrictSelf, uintptr(ruleset), 0, 0); errno != 0 {
+			errc <- fmt.Errorf("exex: filesystem sandbox: %w", errno)
+			return
+		}
+		errc <- start()
+	}()
+	return <-errc
+}
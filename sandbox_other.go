@@ -0,0 +1,7 @@
+//go:build !unix
+
+package exex
+
+func (s *fsSandbox) prepare(c *Cmd) (func(func() error) error, error) {
+	return nil, unsupported("filesystem sandbox")
+}
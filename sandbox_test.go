@@ -0,0 +1,58 @@
+//go:build unix
+
+package exex_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithFilesystemSandbox(t *testing.T) {
+	dir, other := t.TempDir(), t.TempDir()
+	for _, d := range []string{dir, other} {
+		if err := os.WriteFile(filepath.Join(d, "file"), []byte("secret"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(script string) ([]byte, error) {
+		cmd := exex.Command("/bin/sh", "-c", script).Apply(
+			exex.WithEnv("PATH=/usr/bin:/bin"),
+			exex.WithFilesystemSandbox("."),
+		)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if errors.Is(err, errors.ErrUnsupported) {
+			t.Skip(err)
+		}
+		return out, err
+	}
+
+	out, err := run("cat file && echo new > new && cat new")
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	if string(out) != "secretnew\n" {
+		t.Fatalf("expecting %q, got %q", "secretnew\n", out)
+	}
+
+	for _, script := range []string{
+		"cat " + filepath.Join(other, "file"),
+		"echo new > " + filepath.Join(other, "new"),
+	} {
+		out, err := run(script)
+		if err == nil || !strings.Contains(string(out), "Permission denied") {
+			t.Fatalf("expecting %q to be denied, got %v: %s", script, err, out)
+		}
+	}
+
+	// The current process is not restricted.
+	if _, err := os.ReadFile(filepath.Join(other, "file")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package exex
+
+import (
+	"os"
+	"syscall"
+)
+
+// chroot prepares the command to run with its single allowed path as
+// its root directory, if the current process runs as root.
+func (s *fsSandbox) chroot(c *Cmd) (func(func() error) error, error) {
+	if len(s.paths) != 1 || os.Geteuid() != 0 {
+		return nil, unsupported("filesystem sandbox")
+	}
+	paths, err := s.allowed(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.Chroot = paths[0]
+	return func(start func() error) error { return start() }, nil
+}
@@ -0,0 +1,122 @@
+package exex
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrNULByte is reported by WithSanitize for arguments and
+	// environment variables containing NUL bytes, which cannot be
+	// passed to a process.
+	ErrNULByte = errors.New("contains a NUL byte")
+
+	// ErrInvalidUTF8 is reported by WithSanitize for arguments and
+	// environment variables that are not valid UTF-8.
+	ErrInvalidUTF8 = errors.New("invalid UTF-8")
+
+	// ErrControlChar is reported by WithSanitize for arguments and
+	// environment variables containing control characters, other
+	// than tab, newline and carriage return, or Unicode bidirectional
+	// formatting characters, which can make them display differently
+	// from how they are interpreted.
+	ErrControlChar = errors.New("contains a control character")
+)
+
+// Sanitize configures the checks done by WithSanitize. The zero value
+// enables all of them.
+type Sanitize struct {
+	// AllowInvalidUTF8 accepts arguments and environment variables
+	// that are not valid UTF-8, e.g. binary data or file names in
+	// other encodings.
+	AllowInvalidUTF8 bool
+
+	// AllowControlChars accepts control and bidirectional formatting
+	// characters.
+	AllowControlChars bool
+}
+
+// SanitizeError is the error returned when starting a command set
+// with WithSanitize that has an invalid argument or environment
+// variable.
+type SanitizeError struct {
+	// Env reports whether the invalid value is an environment
+	// variable, or otherwise an argument.
+	Env bool
+
+	// Index is the position of the value in Args or Env.
+	Index int
+
+	// Err is the reason, one of ErrNULByte, ErrInvalidUTF8 or
+	// ErrControlChar.
+	Err error
+}
+
+func (e *SanitizeError) Error() string {
+	if e.Env {
+		return fmt.Sprintf("exex: environment variable %d %v", e.Index, e.Err)
+	}
+	return fmt.Sprintf("exex: argument %d %v", e.Index, e.Err)
+}
+
+func (e *SanitizeError) Unwrap() error { return e.Err }
+
+// WithSanitize makes starting the command fail with a *SanitizeError
+// if any of its arguments or environment variables contain NUL bytes
+// or, unless allowed by s, invalid UTF-8 or control characters. These
+// otherwise fail with confusing errors from the operating system, or
+// are passed to the command unnoticed.
+func WithSanitize(s Sanitize) Option {
+	return func(c *Cmd) { c.sanitize = &s }
+}
+
+// checkSanitize returns the errors of the arguments and environment
+// variables of the command according to WithSanitize.
+func (c *Cmd) checkSanitize() []error {
+	if c.sanitize == nil {
+		return nil
+	}
+
+	var errs []error
+	for i, arg := range c.Args {
+		if err := c.sanitize.check(arg); err != nil {
+			errs = append(errs, &SanitizeError{Index: i, Err: err})
+		}
+	}
+	for i, kv := range c.Env {
+		if err := c.sanitize.check(kv); err != nil {
+			errs = append(errs, &SanitizeError{Env: true, Index: i, Err: err})
+		}
+	}
+	return errs
+}
+
+func (s *Sanitize) check(v string) error {
+	if strings.IndexByte(v, 0) >= 0 {
+		return ErrNULByte
+	}
+	if !s.AllowInvalidUTF8 && !utf8.ValidString(v) {
+		return ErrInvalidUTF8
+	}
+	if !s.AllowControlChars && strings.IndexFunc(v, suspicious) >= 0 {
+		return ErrControlChar
+	}
+	return nil
+}
+
+// suspicious reports whether r is a control character other than
+// tab, newline and carriage return, or a bidirectional formatting
+// character.
+func suspicious(r rune) bool {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return false
+	case r < 0x20 || (r >= 0x7f && r <= 0x9f):
+		return true
+	case (r >= 0x202a && r <= 0x202e) || (r >= 0x2066 && r <= 0x2069):
+		return true
+	}
+	return false
+}
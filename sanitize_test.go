@@ -0,0 +1,57 @@
+package exex_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithSanitize(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		env  []string
+		s    exex.Sanitize
+		exp  error
+	}{
+		"valid":        {args: []string{"tab\there", "ünïcode"}},
+		"nul":          {args: []string{"a\x00b"}, exp: exex.ErrNULByte},
+		"nul allowed":  {args: []string{"a\x00b"}, s: exex.Sanitize{AllowControlChars: true}, exp: exex.ErrNULByte},
+		"invalid utf8": {args: []string{"\xff"}, exp: exex.ErrInvalidUTF8},
+		"allowed utf8": {args: []string{"\xff"}, s: exex.Sanitize{AllowInvalidUTF8: true}},
+		"escape":       {args: []string{"\x1b[31mred"}, exp: exex.ErrControlChar},
+		"bidi":         {args: []string{"access‮"}, exp: exex.ErrControlChar},
+		"allowed ctrl": {args: []string{"\x1b[31mred"}, s: exex.Sanitize{AllowControlChars: true}},
+		"env":          {env: []string{"FOO=\x07"}, exp: exex.ErrControlChar},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmd := exex.Command(os.Args[0], append([]string{"0"}, tt.args...)...).Apply(
+				exex.WithEnv("TEST_MAIN=exit"),
+				exex.WithEnv(tt.env...),
+				exex.WithSanitize(tt.s),
+			)
+
+			err := cmd.Run()
+			if tt.exp == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var sErr *exex.SanitizeError
+			if !errors.As(err, &sErr) || !errors.Is(err, tt.exp) {
+				t.Fatalf("expecting *exex.SanitizeError with %v, got %v", tt.exp, err)
+			}
+			if sErr.Env != (tt.env != nil) {
+				t.Errorf("expecting Env %v, got %v", tt.env != nil, sErr.Env)
+			}
+			if err := cmd.Validate(); !errors.Is(err, tt.exp) {
+				t.Errorf("expecting Validate to report %v, got %v", tt.exp, err)
+			}
+		})
+	}
+}
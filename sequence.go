@@ -0,0 +1,92 @@
+package exex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Sequence runs commands one after the other, like a script. By
+// default it stops at the first step that fails; set ContinueOnError
+// to run all of them and report all the failures.
+//
+//	err := new(exex.Sequence).
+//		Add("fetch", exex.Command("git", "fetch")).
+//		Add("rebase", exex.Command("git", "rebase", "origin/main")).
+//		Run(ctx)
+type Sequence struct {
+	// Steps are the commands to run, in order.
+	Steps []Step
+
+	// ContinueOnError runs all the steps even if some of them fail.
+	ContinueOnError bool
+}
+
+// Step is a command of a Sequence.
+type Step struct {
+	// Name, if not empty, identifies the step in errors.
+	Name string
+
+	// Cmd is the command to run.
+	Cmd *Cmd
+}
+
+// StepError is the error of a failed step of a Sequence.
+type StepError struct {
+	// Step is the index of the step.
+	Step int
+
+	// Name is the name of the step, if any.
+	Name string
+
+	// Stderr holds the captured standard error of the command, if
+	// any, as described in *Cmd.Run.
+	Stderr []byte
+
+	// Err is the error of the command.
+	Err error
+}
+
+func (e *StepError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("exex: step %d (%s): %v", e.Step, e.Name, e.Err)
+	}
+	return fmt.Sprintf("exex: step %d: %v", e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error { return e.Err }
+
+// Add appends a step running c, named name, and returns s.
+func (s *Sequence) Add(name string, c *Cmd) *Sequence {
+	s.Steps = append(s.Steps, Step{Name: name, Cmd: c})
+	return s
+}
+
+// Run runs the steps in order. It returns the *StepError of the first
+// step that fails, and the remaining steps are not run, unless
+// ContinueOnError is set, in which case the *StepError of every step
+// that failed are joined.
+//
+// If ctx is done, the running step is killed and the following ones
+// fail with ctx.Err() without being run.
+func (s *Sequence) Run(ctx context.Context) error {
+	var errs []error
+	for i, step := range s.Steps {
+		err := runWithContext(ctx, step.Cmd)
+		if err == nil {
+			continue
+		}
+
+		stepErr := &StepError{Step: i, Name: step.Name, Err: err}
+		var cmdErr *CmdError
+		if errors.As(err, &cmdErr) {
+			stepErr.Stderr = cmdErr.Stderr
+		}
+		if !s.ContinueOnError {
+			return stepErr
+		}
+		errs = append(errs, stepErr)
+	}
+
+	return errors.Join(errs...)
+}
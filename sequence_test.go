@@ -0,0 +1,85 @@
+package exex_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestSequence(t *testing.T) {
+	var ran []string
+	step := func(name, code string) *exex.Cmd {
+		return exex.Command(os.Args[0], code, name+" failed").Apply(
+			exex.WithEnv("TEST_MAIN=exit"),
+			exex.WithMiddleware(exex.Hooks{
+				Before: func(*exex.Cmd) error {
+					ran = append(ran, name)
+					return nil
+				},
+			}.Middleware()),
+		)
+	}
+
+	t.Run("fail fast", func(t *testing.T) {
+		ran = nil
+		err := new(exex.Sequence).
+			Add("first", step("first", "0")).
+			Add("second", step("second", "1")).
+			Add("third", step("third", "2")).
+			Run(context.Background())
+
+		var stepErr *exex.StepError
+		if !errors.As(err, &stepErr) {
+			t.Fatalf("expecting *StepError, got %v", err)
+		}
+		if stepErr.Step != 1 || stepErr.Name != "second" || string(stepErr.Stderr) != "second failed" {
+			t.Fatalf("unexpected step error %+v", stepErr)
+		}
+		if exp := `exex: step 1 (second): `; err.Error()[:len(exp)] != exp {
+			t.Fatalf("expecting error to start with %q, got %q", exp, err)
+		}
+		assertErr(t, err, "second failed")
+		if len(ran) != 2 {
+			t.Fatalf("expecting 2 steps to run, got %v", ran)
+		}
+	})
+
+	t.Run("continue", func(t *testing.T) {
+		ran = nil
+		s := exex.Sequence{
+			Steps: []exex.Step{
+				{Name: "first", Cmd: step("first", "1")},
+				{Cmd: step("second", "0")},
+				{Cmd: step("third", "2")},
+			},
+			ContinueOnError: true,
+		}
+		err := s.Run(context.Background())
+		if len(ran) != 3 {
+			t.Fatalf("expecting 3 steps to run, got %v", ran)
+		}
+
+		errs := err.(interface{ Unwrap() []error }).Unwrap()
+		if len(errs) != 2 {
+			t.Fatalf("expecting 2 errors, got %v", errs)
+		}
+		if exp := "exex: step 2: "; errs[1].Error()[:len(exp)] != exp {
+			t.Fatalf("expecting error to start with %q, got %q", exp, errs[1])
+		}
+		assertErr(t, errs[0], "first failed")
+		assertErr(t, errs[1], "third failed")
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ran = nil
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := new(exex.Sequence).Add("first", step("first", "0")).Run(ctx)
+		if !errors.Is(err, context.Canceled) || len(ran) != 0 {
+			t.Fatalf("expecting context.Canceled and no steps run, got %v and %v", err, ran)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package exex
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ShellPath is the shell run by Shell.
+var ShellPath = "sh"
+
+// Shell returns the Cmd struct to execute script with the POSIX shell
+// ShellPath, passing args as its positional parameters. The script
+// should refer to them as "$1", "$2" or "$@", always quoted, instead
+// of interpolating them into the script, so they are never
+// interpreted by the shell:
+//
+//	exex.Shell(ctx, `grep -r "$1" . | wc -l`, pattern)
+//
+// On Windows it requires a POSIX shell in the PATH, e.g. the one of
+// Git for Windows.
+func Shell(ctx context.Context, script string, args ...string) *Cmd {
+	return CommandContext(ctx, ShellPath, append([]string{"-c", script, ShellPath}, args...)...)
+}
+
+// ShellQuote returns s quoted to be used as a single word in a POSIX
+// shell script. Words made only of characters that are never special
+// for the shell are returned unmodified.
+func ShellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if strings.Trim(s, shellSafe) == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ShellJoin returns args quoted with ShellQuote and joined with
+// spaces, to be used as a command line in a POSIX shell script.
+func ShellJoin(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = ShellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+const shellSafe = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-"
+
+var (
+	// ErrUnterminatedQuote is returned by Split when a quoted string
+	// is not closed.
+	ErrUnterminatedQuote = errors.New("exex: unterminated quote")
+
+	// ErrTrailingBackslash is returned by Split when the command line
+	// ends with an unescaped backslash.
+	ErrTrailingBackslash = errors.New("exex: trailing backslash")
+
+	// ErrEmptyCommandLine is the error of the Cmd returned by
+	// CommandLine for a command line without words.
+	ErrEmptyCommandLine = errors.New("exex: empty command line")
+)
+
+// Split splits the command line cmdline into words following the
+// quoting rules of the POSIX shell, unlike strings.Fields:
+//
+//   - words are separated by spaces, tabs and newlines;
+//   - a backslash preserves the literal value of the next character,
+//     except a newline, which is removed;
+//   - characters enclosed in single quotes are preserved literally;
+//   - characters enclosed in double quotes are preserved literally,
+//     except backslashes followed by $, `, ", \ or a newline.
+//
+// No expansions are performed, so e.g. $HOME or *.go are returned as
+// is, and shell operators like | or > are not recognized.
+func Split(cmdline string) ([]string, error) {
+	var (
+		words  []string
+		word   strings.Builder
+		inWord bool
+	)
+
+	for i := 0; i < len(cmdline); i++ {
+		switch ch := cmdline[i]; ch {
+		case ' ', '\t', '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		case '\\':
+			i++
+			if i == len(cmdline) {
+				return nil, ErrTrailingBackslash
+			}
+			if cmdline[i] != '\n' {
+				word.WriteByte(cmdline[i])
+				inWord = true
+			}
+
+		case '\'':
+			j := strings.IndexByte(cmdline[i+1:], '\'')
+			if j < 0 {
+				return nil, ErrUnterminatedQuote
+			}
+			word.WriteString(cmdline[i+1 : i+1+j])
+			i += j + 1
+			inWord = true
+
+		case '"':
+			inWord = true
+			for i++; ; i++ {
+				if i == len(cmdline) {
+					return nil, ErrUnterminatedQuote
+				}
+				ch := cmdline[i]
+				if ch == '"' {
+					break
+				}
+				if ch == '\\' && i+1 < len(cmdline) && strings.IndexByte("$`\"\\\n", cmdline[i+1]) >= 0 {
+					i++
+					if cmdline[i] == '\n' {
+						continue
+					}
+					ch = cmdline[i]
+				}
+				word.WriteByte(ch)
+			}
+
+		default:
+			word.WriteByte(ch)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// CommandLine returns the Cmd struct to execute the command line
+// cmdline, split into the name of the program and its arguments with
+// Split. If cmdline cannot be split, or it has no words, the error is
+// returned when the command is started.
+func CommandLine(cmdline string) *Cmd {
+	args, err := Split(cmdline)
+	if err == nil && len(args) == 0 {
+		err = ErrEmptyCommandLine
+	}
+	if err != nil {
+		c := Command("")
+		c.Err = err
+		return c
+	}
+	return Command(args[0], args[1:]...)
+}
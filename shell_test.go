@@ -0,0 +1,129 @@
+package exex_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"":              "''",
+		"foo":           "foo",
+		"a/b.c:d=e,f@g": "a/b.c:d=e,f@g",
+		"foo bar":       "'foo bar'",
+		"it's":          `'it'\''s'`,
+		"$HOME":         "'$HOME'",
+		"a;b":           "'a;b'",
+		"*":             "'*'",
+	}
+
+	for s, exp := range tests {
+		if got := exex.ShellQuote(s); got != exp {
+			t.Errorf("ShellQuote(%q): expecting %s, got %s", s, exp, got)
+		}
+	}
+}
+
+func TestShell(t *testing.T) {
+	// The environment of the tests has no PATH.
+	sh, err := exec.LookPath("/bin/sh")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer func(path string) { exex.ShellPath = path }(exex.ShellPath)
+	exex.ShellPath = sh
+
+	args := []string{"plain", "two words", "$(echo pwned)", "it's; rm -rf /", ""}
+	const exp = "[plain][two words][$(echo pwned)][it's; rm -rf /][]"
+
+	t.Run("args", func(t *testing.T) {
+		out, err := exex.Shell(context.Background(), `printf '[%s]' "$@"`, args...).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != exp {
+			t.Fatalf("expecting %s, got %s", exp, out)
+		}
+	})
+
+	t.Run("join", func(t *testing.T) {
+		out, err := exex.Shell(context.Background(), "printf '[%s]' "+exex.ShellJoin(args...)).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != exp {
+			t.Fatalf("expecting %s, got %s", exp, out)
+		}
+	})
+}
+
+func TestSplit(t *testing.T) {
+	tests := map[string]struct {
+		exp []string
+		err error
+	}{
+		"":                             {},
+		"  \t\n":                       {},
+		"git status":                   {exp: []string{"git", "status"}},
+		"  git   commit  -m 'a  msg' ": {exp: []string{"git", "commit", "-m", "a  msg"}},
+		`echo "it's" 'say "hi"'`:       {exp: []string{"echo", "it's", `say "hi"`}},
+		`a\ b c\\d \'e`:                {exp: []string{"a b", `c\d`, "'e"}},
+		`"a\"b\$c\d" ''`:               {exp: []string{`a"b$c\d`, ""}},
+		"foo' bar'\"baz\"":             {exp: []string{"foo barbaz"}},
+		"one\\\ntwo \"th\\\nree\"":     {exp: []string{"onetwo", "three"}},
+		"$HOME *.go | wc":              {exp: []string{"$HOME", "*.go", "|", "wc"}},
+		"echo 'unterminated":           {err: exex.ErrUnterminatedQuote},
+		`echo "unterminated\"`:         {err: exex.ErrUnterminatedQuote},
+		`echo trailing\`:               {err: exex.ErrTrailingBackslash},
+	}
+
+	for in, tt := range tests {
+		got, err := exex.Split(in)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Split(%q): expecting error %v, got %v", in, tt.err, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.exp) {
+			t.Errorf("Split(%q): expecting %q, got %q", in, tt.exp, got)
+		}
+	}
+
+	t.Run("join", func(t *testing.T) {
+		args := []string{"plain", "two words", "it's", `"quoted"`, "", `back\slash`, "$HOME"}
+		got, err := exex.Split(exex.ShellJoin(args...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, args) {
+			t.Fatalf("expecting %q, got %q", args, got)
+		}
+	})
+}
+
+func TestCommandLine(t *testing.T) {
+	out, err := exex.CommandLine(strconv.Quote(os.Args[0]) + ` 'one two' three`).Apply(
+		exex.WithEnv("TEST_MAIN=echo"),
+	).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "one two three\n"; string(out) != exp {
+		t.Fatalf("expecting %q, got %q", exp, out)
+	}
+
+	for in, exp := range map[string]error{
+		"":           exex.ErrEmptyCommandLine,
+		"echo 'oops": exex.ErrUnterminatedQuote,
+	} {
+		if err := exex.CommandLine(in).Run(); !errors.Is(err, exp) {
+			t.Errorf("CommandLine(%q): expecting error %v, got %v", in, exp, err)
+		}
+	}
+}
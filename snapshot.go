@@ -0,0 +1,188 @@
+package exex
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ChangeKind is the kind of change made to a file.
+type ChangeKind int
+
+const (
+	// FileCreated means the file did not exist before the command
+	// ran.
+	FileCreated ChangeKind = iota + 1
+
+	// FileModified means the contents of the file changed.
+	FileModified
+
+	// FileRemoved means the file no longer exists after the command
+	// ran.
+	FileRemoved
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case FileCreated:
+		return "created"
+	case FileModified:
+		return "modified"
+	case FileRemoved:
+		return "removed"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// FileChange describes a file changed by a command.
+type FileChange struct {
+	// Path is the path of the file, relative to the working directory
+	// of the command.
+	Path string
+	Kind ChangeKind
+}
+
+// WithDirSnapshot records the SHA-256 hash of the regular files in
+// the working directory of the command matching any of the given
+// patterns, before and after it runs, and exposes the files that
+// changed in Result.Changes.
+//
+// Patterns use the syntax of filepath.Match and are relative to the
+// working directory of the command. Directories matching a pattern
+// are included recursively, so "." snapshots the whole working
+// directory. Errors while taking the snapshots are returned by Start
+// and Wait.
+func WithDirSnapshot(patterns ...string) Option {
+	return func(c *Cmd) { c.snapshot = &dirSnapshot{patterns: patterns} }
+}
+
+type dirSnapshot struct {
+	patterns []string
+	before   map[string][sha256.Size]byte
+}
+
+// take records the hashes of the files in dir before the command
+// runs.
+func (s *dirSnapshot) take(dir string) error {
+	files, err := s.hash(dir)
+	if err != nil {
+		return err
+	}
+	s.before = files
+	return nil
+}
+
+// diff returns the changes made to the files in dir since take was
+// called.
+func (s *dirSnapshot) diff(dir string) ([]FileChange, error) {
+	after, err := s.hash(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FileChange
+	for name, sum := range after {
+		prev, ok := s.before[name]
+		switch {
+		case !ok:
+			changes = append(changes, FileChange{Path: name, Kind: FileCreated})
+		case prev != sum:
+			changes = append(changes, FileChange{Path: name, Kind: FileModified})
+		}
+	}
+	for name := range s.before {
+		if _, ok := after[name]; !ok {
+			changes = append(changes, FileChange{Path: name, Kind: FileRemoved})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	return changes, nil
+}
+
+func (s *dirSnapshot) hash(dir string) (map[string][sha256.Size]byte, error) {
+	names, err := globFiles(dir, s.patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("exex: snapshot: %w", err)
+	}
+
+	files := make(map[string][sha256.Size]byte, len(names))
+	for _, name := range names {
+		sum, err := hashFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("exex: snapshot: %w", err)
+		}
+		files[name] = sum
+	}
+
+	return files, nil
+}
+
+// globFiles returns the paths, relative to dir, of the regular files
+// matching any of the given patterns, walking recursively the
+// directories that match. An empty dir is the current directory.
+func globFiles(dir string, patterns ...string) ([]string, error) {
+	if dir == "" {
+		dir = "."
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+
+	for _, p := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, p))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range matches {
+			err := filepath.WalkDir(m, func(name string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.Type().IsRegular() {
+					return nil
+				}
+
+				rel, err := filepath.Rel(dir, name)
+				if err != nil {
+					return err
+				}
+				if !seen[rel] {
+					seen[rel] = true
+					files = append(files, rel)
+				}
+
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return files, nil
+}
+
+func hashFile(name string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+
+	f, err := os.Open(name)
+	if err != nil {
+		return sum, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+
+	return sum, nil
+}
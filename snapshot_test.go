@@ -0,0 +1,78 @@
+package exex_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithDirSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"a.txt":       "a",
+		"b.txt":       "b",
+		"c.txt":       "c",
+		"d.log":       "d",
+		"sub/e.txt":   "e",
+		"other/f.txt": "f",
+	} {
+		name = filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(name), 0o755)
+		if err := os.WriteFile(name, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exex.Command(os.Args[0],
+		"a.txt=a",             // unchanged
+		"b.txt=changed",       // modified
+		"-c.txt",              // removed
+		"new.txt=new",         // created
+		"d.log=ignored",       // not matched
+		"sub/e.txt=changed",   // modified in matched directory
+		"other/f.txt=changed", // not matched
+	).Apply(
+		exex.WithDir(dir),
+		exex.WithEnv("TEST_MAIN=files"),
+		exex.WithDirSnapshot("*.txt", "sub"),
+	)
+
+	if r := cmd.Result(); r != nil {
+		t.Fatalf("not expecting a result before running, got %+v", r)
+	}
+
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []exex.FileChange{
+		{Path: "b.txt", Kind: exex.FileModified},
+		{Path: "c.txt", Kind: exex.FileRemoved},
+		{Path: "new.txt", Kind: exex.FileCreated},
+		{Path: filepath.Join("sub", "e.txt"), Kind: exex.FileModified},
+	}
+
+	r := cmd.Result()
+	if r == nil {
+		t.Fatal("expecting a result")
+	}
+	if r.ExitCode != 0 {
+		t.Errorf("expecting exit code 0, got %d", r.ExitCode)
+	}
+	if !reflect.DeepEqual(r.Changes, exp) {
+		t.Fatalf("expecting %v, got %v", exp, r.Changes)
+	}
+}
+
+func TestWithDirSnapshotBadPattern(t *testing.T) {
+	err := exex.Command(os.Args[0]).Apply(exex.WithDirSnapshot("[")).Run()
+	if err == nil {
+		t.Fatal("expecting an error")
+	}
+	if _, ok := exex.ExitCode(err); ok {
+		t.Fatalf("not expecting the command to run, got %v", err)
+	}
+}
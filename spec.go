@@ -0,0 +1,76 @@
+package exex
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// Spec describes a command that can be run any number of times, even
+// concurrently, unlike a Cmd. Each run creates a new Cmd from the
+// Spec, which is never modified.
+//
+//	fetch := exex.Spec{Name: "git", Args: []string{"fetch"}, Dir: repo}
+//	for range ticker.C {
+//		if err := fetch.Run(ctx); err != nil {
+//			log.Print(err)
+//		}
+//	}
+type Spec struct {
+	// Name is the program to execute, looked up as by Command.
+	Name string
+
+	// Args are the arguments of the program, not including its name.
+	Args []string
+
+	// Env is the environment of the command, as in exec.Cmd.Env: if
+	// nil, it inherits the environment of the current process.
+	Env []string
+
+	// Dir is the working directory of the command.
+	Dir string
+
+	// Stdin, if not nil, is written to the standard input of every
+	// run of the command.
+	Stdin []byte
+
+	// Stdout and Stderr are the standard output and standard error
+	// of every run of the command, as in exec.Cmd. If both are the
+	// same writer, at most one goroutine at a time calls Write.
+	// Writers used by concurrent runs must be safe for concurrent
+	// use.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Options are applied to every Cmd created from the Spec.
+	Options []Option
+}
+
+// Command returns a new Cmd, associated with ctx, to run the command
+// described by s.
+func (s Spec) Command(ctx context.Context) *Cmd {
+	c := CommandContext(ctx, s.Name, append([]string(nil), s.Args...)...)
+	if s.Env != nil {
+		c.Env = append([]string(nil), s.Env...)
+	}
+	c.Dir = s.Dir
+	if s.Stdin != nil {
+		c.Stdin = bytes.NewReader(s.Stdin)
+	}
+	c.Stdout = s.Stdout
+	c.Stderr = s.Stderr
+
+	return c.Apply(s.Options...)
+}
+
+// Run runs a new Cmd created from s and waits for it to end. Errors
+// are reported as Cmd.Run does.
+func (s Spec) Run(ctx context.Context) error {
+	return s.Command(ctx).Run()
+}
+
+// Output runs a new Cmd created from s and returns its standard
+// output, as Cmd.Output does. Stdout must be nil.
+func (s Spec) Output(ctx context.Context) ([]byte, error) {
+	return s.Command(ctx).Output()
+}
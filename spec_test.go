@@ -0,0 +1,57 @@
+package exex_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestSpec(t *testing.T) {
+	spec := exex.Spec{
+		Name:    os.Args[0],
+		Env:     []string{"TEST_MAIN=cat"},
+		Stdin:   []byte("hello"),
+		Options: []exex.Option{exex.WithQuiet()},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out, err := spec.Output(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(out) != "hello" {
+				t.Errorf("expecting hello, got %q", out)
+			}
+		}()
+	}
+	wg.Wait()
+
+	t.Run("args", func(t *testing.T) {
+		spec := exex.Spec{Name: os.Args[0], Args: []string{"3", "failed"}, Env: []string{"TEST_MAIN=exit"}}
+		for i := 0; i < 2; i++ {
+			err := spec.Run(context.Background())
+			assertErr(t, err, "failed")
+			if code, _ := exex.ExitCode(err); code != 3 {
+				t.Fatalf("expecting exit code 3, got %d", code)
+			}
+		}
+	})
+
+	t.Run("context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := exex.Spec{Name: os.Args[0], Env: []string{"TEST_MAIN=hang"}}.Run(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expecting context.Canceled, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package exex
+
+import (
+	"bufio"
+	"bytes"
+)
+
+// WithSplit sets the function splitting the output of the command
+// into the tokens passed by the streaming APIs, StreamStdout,
+// StreamStderr and Lines, instead of lines. It can be any
+// bufio.SplitFunc, like bufio.ScanWords, ScanProgressLines for tools
+// updating their progress with carriage returns, or a custom one for
+// e.g. length-prefixed frames.
+func WithSplit(split bufio.SplitFunc) Option {
+	return func(c *Cmd) { c.split = split }
+}
+
+// ScanProgressLines is a bufio.SplitFunc splitting lines terminated
+// by a newline, a carriage return, or both, so that the progress
+// updates that tools like curl or pip write by returning to the
+// beginning of the line are reported as separate lines. The returned
+// lines do not include their terminator.
+func ScanProgressLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// A carriage return may be followed by a newline.
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
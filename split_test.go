@@ -0,0 +1,106 @@
+package exex_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/inkel/exex"
+)
+
+func TestScanProgressLines(t *testing.T) {
+	const in = "start\n10%\r50%\r100%\r\ndone\r\n\nlast"
+	exp := []string{"start", "10%", "50%", "100%", "done", "", "last"}
+
+	s := bufio.NewScanner(iotest.OneByteReader(strings.NewReader(in)))
+	s.Split(exex.ScanProgressLines)
+
+	var got []string
+	for s.Scan() {
+		got = append(got, s.Text())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expecting %q, got %q", exp, got)
+	}
+}
+
+func TestWithSplit(t *testing.T) {
+	errFrame := errors.New("bad frame")
+
+	// frames splits length-prefixed frames, like "3:foo".
+	frames := func(data []byte, atEOF bool) (int, []byte, error) {
+		size, rest, ok := strings.Cut(string(data), ":")
+		if !ok {
+			if atEOF && len(data) > 0 {
+				return 0, nil, errFrame
+			}
+			return 0, nil, nil
+		}
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			return 0, nil, errFrame
+		}
+		if len(rest) < n {
+			if atEOF {
+				return 0, nil, errFrame
+			}
+			return 0, nil, nil
+		}
+		return len(size) + 1 + n, []byte(rest[:n]), nil
+	}
+
+	tests := map[string]struct {
+		split bufio.SplitFunc
+		in    string
+		exp   []string
+		err   error
+	}{
+		"words":    {split: bufio.ScanWords, in: "one two\n  three", exp: []string{"one", "two", "three"}},
+		"progress": {split: exex.ScanProgressLines, in: "0%\r50%\r100%\n", exp: []string{"0%", "50%", "100%"}},
+		"frames":   {split: frames, in: "3:foo5:a\nb c0:", exp: []string{"foo", "a\nb c", ""}},
+		"invalid":  {split: frames, in: "3:foox:bar", exp: []string{"foo"}, err: errFrame},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			err := exex.Command(os.Args[0], strconv.Quote(tt.in)).Apply(
+				exex.WithEnv("TEST_MAIN=print"),
+				exex.WithSplit(tt.split),
+			).StreamStdout(func(tok string) { got = append(got, tok) })
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expecting error %v, got %v", tt.err, err)
+			}
+			if !reflect.DeepEqual(got, tt.exp) {
+				t.Fatalf("expecting %q, got %q", tt.exp, got)
+			}
+		})
+	}
+
+	t.Run("lines", func(t *testing.T) {
+		lines, errc := exex.Command(os.Args[0], strconv.Quote("a b\nc")).Apply(
+			exex.WithEnv("TEST_MAIN=print"),
+			exex.WithSplit(bufio.ScanWords),
+		).Lines(context.Background())
+
+		var got []string
+		for l := range lines {
+			got = append(got, string(l.Bytes))
+		}
+		if err := <-errc; err != nil {
+			t.Fatal(err)
+		}
+		if exp := []string{"a", "b", "c"}; !reflect.DeepEqual(got, exp) {
+			t.Fatalf("expecting %q, got %q", exp, got)
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package exex
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// WithSpill captures the standard output and error of the command,
+// unless set, in Spools of the given threshold, so that large
+// outputs, e.g. of database dumps or verbose builds, are written to
+// temporary files instead of memory. They are returned by
+// SpooledStdout and SpooledStderr, and must be closed once done.
+//
+// The standard error reported in CmdError.Stderr is then bounded to
+// the last threshold bytes, unless set otherwise with
+// WithStderrLimit.
+func WithSpill(threshold int) Option {
+	return func(c *Cmd) { c.spill = &threshold }
+}
+
+// SpooledStdout returns the standard output of the command captured
+// because of WithSpill, or nil.
+func (c *Cmd) SpooledStdout() *Spool { return c.stdoutSpool }
+
+// SpooledStderr returns the standard error of the command captured
+// because of WithSpill, or nil.
+func (c *Cmd) SpooledStderr() *Spool { return c.stderrSpool }
+
+// startSpill sets the streams of the command not set to spools.
+func (c *Cmd) startSpill() {
+	n := *c.spill
+
+	if c.Stdout == nil {
+		c.stdoutSpool = NewSpool(n)
+		c.Stdout = c.stdoutSpool
+		if c.mergeStderr {
+			c.Stderr = c.Stdout
+		}
+	}
+	if c.Stderr == nil && !c.mergeStderr {
+		c.stderrSpool = NewSpool(n)
+		if c.stderrLimit == nil {
+			c.stderrLimit = &captureLimit{n: n, mode: KeepTail}
+		}
+		c.captureStderr(c.stderrSpool)
+	}
+}
+
+// Spool is an io.Writer keeping the bytes written to it in memory up
+// to a threshold, beyond which they are all spilled to a temporary
+// file, while the last threshold bytes are still kept in memory.
+// Writes never fail, so the command writing to it is never affected
+// by errors writing the file, which are reported by Err.
+//
+// On Unix systems the file is removed as soon as it is created, so
+// that it is removed by the system once closed, even if the current
+// process crashes; elsewhere it is removed by Close.
+type Spool struct {
+	mu        sync.Mutex
+	threshold int
+	tail      *capture
+	f         *os.File
+	size      int64
+	err       error
+}
+
+// NewSpool returns a Spool spilling the bytes written to it to a file
+// once they exceed threshold bytes. Zero or negative threshold spills
+// every byte.
+func NewSpool(threshold int) *Spool {
+	s := &Spool{threshold: threshold}
+	if threshold > 0 {
+		s.tail = &capture{limit: threshold, mode: KeepTail}
+	}
+	return s
+}
+
+func (s *Spool) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f == nil && s.err == nil && s.size+int64(len(p)) > int64(s.threshold) {
+		s.spill()
+	}
+	if s.f != nil && s.err == nil {
+		if _, err := s.f.Write(p); err != nil {
+			s.err = err
+		}
+	}
+	if s.tail != nil {
+		s.tail.Write(p)
+	}
+	s.size += int64(len(p))
+
+	return len(p), nil
+}
+
+// spill creates the file with the bytes written so far.
+func (s *Spool) spill() {
+	f, err := os.CreateTemp("", "exex-spool-*")
+	if err != nil {
+		s.err = err
+		return
+	}
+	removeOpen(f.Name())
+	s.f = f
+
+	if s.tail != nil {
+		if _, err := f.Write(s.tail.Bytes()); err != nil {
+			s.err = err
+		}
+	}
+}
+
+// Len returns the number of bytes written.
+func (s *Spool) Len() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+// Spilled reports whether the bytes written were spilled to a file.
+func (s *Spool) Spilled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f != nil
+}
+
+// Tail returns the last bytes written, up to the threshold.
+func (s *Spool) Tail() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tail == nil {
+		return nil
+	}
+	return bytes.Clone(s.tail.Bytes())
+}
+
+// Err returns the error creating or writing the file, if any, in
+// which case the bytes written are not available but for the tail.
+func (s *Spool) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// ReadAt reads the bytes written at offset off, as io.ReaderAt does.
+func (s *Spool) ReadAt(p []byte, off int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return 0, s.err
+	}
+	if off < 0 {
+		return 0, errors.New("exex: negative offset")
+	}
+	if s.f != nil {
+		return s.f.ReadAt(p, off)
+	}
+
+	var b []byte
+	if s.tail != nil {
+		b = s.tail.Bytes()
+	}
+	if off >= int64(len(b)) {
+		return 0, io.EOF
+	}
+	n := copy(p, b[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Reader returns a reader of the bytes written so far.
+func (s *Spool) Reader() io.Reader {
+	return io.NewSectionReader(s, 0, s.Len())
+}
+
+// Close removes the file of the spool, if any. The bytes written are
+// not available afterwards.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	if rerr := os.Remove(s.f.Name()); err == nil && !errors.Is(rerr, os.ErrNotExist) {
+		err = rerr
+	}
+	s.f = nil
+	s.err = os.ErrClosed
+	return err
+}
@@ -0,0 +1,6 @@
+//go:build !unix
+
+package exex
+
+// removeOpen does nothing, as open files cannot be removed.
+func removeOpen(name string) {}
@@ -0,0 +1,87 @@
+package exex_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestSpool(t *testing.T) {
+	s := exex.NewSpool(8)
+	io.WriteString(s, "hello")
+	if s.Spilled() {
+		t.Fatal("expecting no spill under the threshold")
+	}
+	io.WriteString(s, ", world!")
+	if !s.Spilled() {
+		t.Fatal("expecting spill over the threshold")
+	}
+	defer s.Close()
+
+	if got := s.Len(); got != 13 {
+		t.Fatalf("expecting 13 bytes, got %d", got)
+	}
+	if got := string(s.Tail()); got != ", world!" {
+		t.Fatalf("unexpected tail %q", got)
+	}
+	b, err := io.ReadAll(s.Reader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello, world!" {
+		t.Fatalf("unexpected output %q", b)
+	}
+	p := make([]byte, 5)
+	if _, err := s.ReadAt(p, 7); err != nil || string(p) != "world" {
+		t.Fatalf("unexpected ReadAt %q, %v", p, err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ReadAt(p, 0); err == nil {
+		t.Fatal("expecting error after Close")
+	}
+}
+
+func TestWithSpill(t *testing.T) {
+	stdout := strings.Repeat("o", 1000)
+	stderr := strings.Repeat("h", 900) + strings.Repeat("t", 100)
+	cmd := exex.Command(os.Args[0], stdout, stderr, "1").Apply(
+		exex.WithEnv("TEST_MAIN=streams"),
+		exex.WithSpill(100),
+	)
+
+	err := cmd.Run()
+	var cerr *exex.CmdError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("expecting CmdError, got %v", err)
+	}
+	if got := string(cerr.Stderr); got != strings.Repeat("t", 100) {
+		t.Fatalf("expecting the tail of stderr, got %q", got)
+	}
+
+	for _, tt := range []struct {
+		s    *exex.Spool
+		want string
+	}{
+		{cmd.SpooledStdout(), stdout},
+		{cmd.SpooledStderr(), stderr},
+	} {
+		if tt.s == nil || !tt.s.Spilled() {
+			t.Fatal("expecting spilled output")
+		}
+		b, err := io.ReadAll(tt.s.Reader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tt.want {
+			t.Fatalf("unexpected output of %d bytes", len(b))
+		}
+		tt.s.Close()
+	}
+}
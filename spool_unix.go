@@ -0,0 +1,9 @@
+//go:build unix
+
+package exex
+
+import "os"
+
+// removeOpen removes the open file name, which remains accessible
+// until closed.
+func removeOpen(name string) { os.Remove(name) }
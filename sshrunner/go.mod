@@ -0,0 +1,10 @@
+module github.com/inkel/exex/sshrunner
+
+go 1.26.0
+
+require (
+	github.com/inkel/exex v0.1.0
+	golang.org/x/crypto v0.57.0
+)
+
+require golang.org/x/sys v0.48.0 // indirect
@@ -0,0 +1,187 @@
+// Package sshrunner provides an exex.Runner that executes commands on
+// a remote host over SSH, so that the same code can run commands
+// locally or remotely:
+//
+//	client, err := ssh.Dial("tcp", "deploy.example.com:22", config)
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	r := &sshrunner.Runner{Client: client}
+//
+//	err = exex.Command("systemctl", "restart", "app").Apply(exex.WithRunner(r)).Run()
+//
+// Each command is executed in a new session of the client, through the
+// shell of the remote user, which must be a POSIX shell. Its
+// arguments are quoted so they are never interpreted by the shell.
+// The standard streams of the command are connected to the session,
+// so the standard error is captured and reported in *exex.CmdError as
+// for local commands.
+package sshrunner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/inkel/exex"
+	"golang.org/x/crypto/ssh"
+)
+
+// Runner is an exex.Runner that executes commands on the remote host
+// of Client.
+//
+// The command executed is Args, as the executable is looked up in the
+// PATH of the remote host, in the directory Dir, if set. Only the
+// variables of Env that are not inherited from the local environment,
+// e.g. those added with exex.WithEnv, are set in the environment of
+// the remote command, which otherwise is the one of the session.
+//
+// The remote command is killed when the context of the Cmd is done.
+// The features of exex that require a local process, such as
+// WithTimeout, Terminate or WithProcessGroup, are not supported.
+type Runner struct {
+	// Client is the connection to the remote host.
+	Client *ssh.Client
+
+	mu       sync.Mutex
+	sessions map[*exex.Cmd]*session
+}
+
+type session struct {
+	*ssh.Session
+	stop     func() bool
+	canceled chan struct{}
+}
+
+// Command is like exex.Command, but the command is executed by r.
+func (r *Runner) Command(name string, args ...string) *exex.Cmd {
+	return exex.Command(name, args...).Apply(exex.WithRunner(r))
+}
+
+// CommandContext is like exex.CommandContext, but the command is
+// executed by r.
+func (r *Runner) CommandContext(ctx context.Context, name string, args ...string) *exex.Cmd {
+	return exex.CommandContext(ctx, name, args...).Apply(exex.WithRunner(r))
+}
+
+// Start implements exex.Runner.
+func (r *Runner) Start(c *exex.Cmd) error {
+	if r.Client == nil {
+		return errors.New("sshrunner: no client")
+	}
+	if len(c.Args) == 0 {
+		return errors.New("sshrunner: no command")
+	}
+
+	s, err := r.Client.NewSession()
+	if err != nil {
+		return err
+	}
+	s.Stdin, s.Stdout, s.Stderr = c.Stdin, c.Stdout, c.Stderr
+
+	if err := s.Start(CommandLine(c)); err != nil {
+		s.Close()
+		return err
+	}
+
+	rs := &session{Session: s, canceled: make(chan struct{})}
+	rs.stop = context.AfterFunc(c.Context(), func() {
+		close(rs.canceled)
+		s.Signal(ssh.SIGKILL)
+		s.Close()
+	})
+
+	r.mu.Lock()
+	if r.sessions == nil {
+		r.sessions = make(map[*exex.Cmd]*session)
+	}
+	r.sessions[c] = rs
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Wait implements exex.Runner. Unsuccessful exits of the remote
+// command are reported as an *ExitError, and if the context of the
+// Cmd is done its error is returned instead.
+func (r *Runner) Wait(c *exex.Cmd) error {
+	r.mu.Lock()
+	s, ok := r.sessions[c]
+	delete(r.sessions, c)
+	r.mu.Unlock()
+
+	if !ok {
+		return errors.New("sshrunner: not started")
+	}
+
+	err := s.Wait()
+	if !s.stop() {
+		<-s.canceled
+		return c.Context().Err()
+	}
+	s.Close()
+
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitError{ExitError: exitErr}
+	}
+	return err
+}
+
+// ExitError reports an unsuccessful exit of a remote command.
+type ExitError struct {
+	*ssh.ExitError
+}
+
+// ExitCode returns the exit status of the remote command, or -1 if it
+// was terminated by a signal.
+func (e *ExitError) ExitCode() int {
+	if e.Signal() != "" {
+		return -1
+	}
+	return e.ExitStatus()
+}
+
+func (e *ExitError) Unwrap() error { return e.ExitError }
+
+// CommandLine returns the shell command line executed by a Runner for
+// c, e.g. for logging.
+func CommandLine(c *exex.Cmd) string {
+	var b strings.Builder
+
+	if c.Dir != "" {
+		b.WriteString("cd " + exex.ShellQuote(c.Dir) + " && ")
+	}
+	b.WriteString("exec ")
+	if env := remoteEnv(c.Env); len(env) > 0 {
+		b.WriteString("env " + exex.ShellJoin(env...) + " ")
+	}
+	b.WriteString(exex.ShellJoin(c.Args...))
+
+	return b.String()
+}
+
+// remoteEnv returns the variables of env not inherited from the local
+// environment.
+func remoteEnv(env []string) []string {
+	if env == nil {
+		return nil
+	}
+
+	local := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		local[kv] = true
+	}
+
+	var vars []string
+	for _, kv := range env {
+		if !local[kv] {
+			vars = append(vars, kv)
+		}
+	}
+	return vars
+}
@@ -0,0 +1,180 @@
+package sshrunner_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+	"github.com/inkel/exex/sshrunner"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestRunner(t *testing.T) {
+	r := &sshrunner.Runner{Client: dial(t)}
+	ctx := context.Background()
+
+	t.Run("output", func(t *testing.T) {
+		cmd := r.CommandContext(ctx, "sh", "-c", `echo "$FOO"; pwd; cat; echo "$0"`, "it's").Apply(
+			exex.WithEnv("FOO=bar baz"),
+			exex.WithDir("/tmp"),
+			exex.WithStdin(strings.NewReader("input\n")),
+		)
+
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := "bar baz\n/tmp\ninput\nit's\n"; string(out) != exp {
+			t.Fatalf("expecting %q, got %q", exp, out)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		err := r.Command("sh", "-c", "echo oops >&2; exit 3").Run()
+
+		var cmdErr *exex.CmdError
+		if !errors.As(err, &cmdErr) {
+			t.Fatalf("expecting *exex.CmdError, got %T: %[1]v", err)
+		}
+		if cmdErr.ExitCode != 3 || string(cmdErr.Stderr) != "oops\n" {
+			t.Fatalf("unexpected error %+v", cmdErr)
+		}
+
+		var exitErr *sshrunner.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Fatalf("expecting *sshrunner.ExitError, got %v", err)
+		}
+	})
+
+	t.Run("context", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := r.CommandContext(ctx, "sleep", "10").Run()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expecting context.DeadlineExceeded, got %v", err)
+		}
+		if d := time.Since(start); d > 5*time.Second {
+			t.Fatalf("command not killed after %v", d)
+		}
+	})
+}
+
+func TestCommandLine(t *testing.T) {
+	cmd := exex.Command("printf", "%s\n", "a b").Apply(exex.WithDir("/srv/app dir"), exex.WithEnv("X=1"))
+
+	exp := `cd '/srv/app dir' && exec env X=1 printf '%s
+' 'a b'`
+	if got := sshrunner.CommandLine(cmd); got != exp {
+		t.Fatalf("expecting %q, got %q", exp, got)
+	}
+}
+
+// dial starts an SSH server executing the commands requested locally
+// with sh, and returns a client connected to it.
+func dial(t *testing.T) *ssh.Client {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn, config)
+		}
+	}()
+
+	client, err := ssh.Dial("tcp", l.Addr().String(), &ssh.ClientConfig{
+		User:            "test",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			nc.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		ch, reqs, err := nc.Accept()
+		if err != nil {
+			continue
+		}
+		go session(ch, reqs)
+	}
+}
+
+func session(ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer ch.Close()
+
+	var cmd *exec.Cmd
+	for req := range reqs {
+		switch req.Type {
+		case "exec":
+			var payload struct{ Command string }
+			if cmd != nil || ssh.Unmarshal(req.Payload, &payload) != nil {
+				req.Reply(false, nil)
+				continue
+			}
+			cmd = exec.Command("/bin/sh", "-c", payload.Command)
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = ch, ch, ch.Stderr()
+			if err := cmd.Start(); err != nil {
+				req.Reply(false, nil)
+				return
+			}
+			req.Reply(true, nil)
+			go func() {
+				cmd.Wait()
+				status := struct{ Status uint32 }{uint32(cmd.ProcessState.ExitCode())}
+				ch.SendRequest("exit-status", false, ssh.Marshal(&status))
+				ch.Close()
+			}()
+		case "signal":
+			if cmd != nil {
+				cmd.Process.Kill()
+			}
+		default:
+			if req.WantReply {
+				req.Reply(false, nil)
+			}
+		}
+	}
+}
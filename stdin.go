@@ -0,0 +1,86 @@
+package exex
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+)
+
+// StdinFromCommand connects the standard output of src to the
+// standard input of c, emulating the shell's "src | c".
+//
+// src is started when c is started and waited for when c finishes.
+// If src fails, its error, including its captured standard error, is
+// returned instead of the result of c, unless src was terminated by
+// a broken pipe because c stopped reading its input.
+//
+// Both the Stdin field of c and the Stdout field of src must be nil.
+func (c *Cmd) StdinFromCommand(src *Cmd) {
+	c.stdinCmd = src
+}
+
+// OutputWithInput runs the command with r as its standard input and
+// returns its standard output, as Output does. The Stdin field of the
+// command must be nil.
+func (c *Cmd) OutputWithInput(r io.Reader) ([]byte, error) {
+	if c.Stdin != nil || c.stdinCmd != nil {
+		return nil, errors.New("exex: Stdin already set")
+	}
+	c.Stdin = r
+	return c.Output()
+}
+
+// RunInput creates a Cmd with stdin as its standard input and returns
+// the result of executing *Cmd.Run.
+func RunInput(stdin []byte, name string, args ...string) error {
+	return Command(name, args...).Apply(WithStdin(bytes.NewReader(stdin))).Run()
+}
+
+func (c *Cmd) startStdinCmd() error {
+	src := c.stdinCmd
+	if src == nil {
+		return nil
+	}
+	if c.Stdin != nil {
+		return errors.New("exex: Stdin already set")
+	}
+	if src.Stdout != nil {
+		return errors.New("exex: Stdout already set in source command")
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	src.Stdout = w
+	err = src.Start()
+	w.Close()
+	if err != nil {
+		r.Close()
+		return err
+	}
+
+	c.stdin = r
+	c.Stdin = r
+
+	return nil
+}
+
+func (c *Cmd) waitStdinCmd(err error) error {
+	if c.stdin == nil {
+		return err
+	}
+
+	// Closing our copy of the pipe makes the source command fail
+	// instead of blocking if c did not consume all of its output.
+	c.stdin.Close()
+	c.stdin = nil
+
+	if srcErr := c.stdinCmd.Wait(); srcErr != nil && !brokenPipe(srcErr) {
+		return srcErr
+	}
+
+	return err
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package exex
+
+// brokenPipe reports whether err is the result of a process being
+// terminated by SIGPIPE, which does not exist on this system.
+func brokenPipe(err error) bool { return false }
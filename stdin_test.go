@@ -0,0 +1,71 @@
+package exex_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestCmd_StdinFromCommand(t *testing.T) {
+	t.Run("output", func(t *testing.T) {
+		src := exex.Command(os.Args[0], "foo", "bar").Apply(exex.WithEnv("TEST_MAIN=echo"))
+		cmd := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=cat"))
+		cmd.StdinFromCommand(src)
+
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if exp := "foo bar\n"; string(out) != exp {
+			t.Fatalf("expecting %q, got %q", exp, out)
+		}
+	})
+
+	t.Run("source fails", func(t *testing.T) {
+		src := exex.Command(os.Args[0], "source")
+		cmd := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=cat"))
+		cmd.StdinFromCommand(src)
+
+		assertErr(t, cmd.Run(), "error: source")
+	})
+
+	t.Run("destination fails", func(t *testing.T) {
+		src := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=yes"))
+		cmd := exex.Command(os.Args[0], "destination")
+		cmd.StdinFromCommand(src)
+
+		assertErr(t, cmd.Run(), "error: destination")
+	})
+
+	t.Run("broken pipe", func(t *testing.T) {
+		src := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=yes"))
+		cmd := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=version"))
+		cmd.StdinFromCommand(src)
+
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestCmd_OutputWithInput(t *testing.T) {
+	out, err := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=cat")).OutputWithInput(strings.NewReader("filtered"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "filtered" {
+		t.Fatalf("expecting %q, got %q", "filtered", out)
+	}
+
+	_, err = exex.Command(os.Args[0]).Apply(exex.WithStdin(os.Stdin)).OutputWithInput(strings.NewReader(""))
+	if err == nil {
+		t.Fatal("expecting an error when Stdin is set")
+	}
+}
+
+func TestRunInput(t *testing.T) {
+	err := exex.RunInput([]byte("ignored"), os.Args[0], "with", "input")
+	assertErr(t, err, "error: with input")
+}
@@ -0,0 +1,20 @@
+//go:build unix
+
+package exex
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// brokenPipe reports whether err is the result of a process being
+// terminated by SIGPIPE.
+func brokenPipe(err error) bool {
+	var exErr *exec.ExitError
+	if !errors.As(err, &exErr) {
+		return false
+	}
+	ws, ok := exErr.Sys().(syscall.WaitStatus)
+	return ok && ws.Signaled() && ws.Signal() == syscall.SIGPIPE
+}
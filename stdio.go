@@ -0,0 +1,48 @@
+package exex
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sync/atomic"
+)
+
+// Some commands close their standard streams before exiting, or
+// leave them open in descendants that keep running after they exit,
+// e.g. when daemonizing. Neither case is reported as an error:
+// Result.StdinClosed and Result.StdioHeld expose them instead.
+
+// eofReader records whether its reader was read until io.EOF.
+type eofReader struct {
+	r   io.Reader
+	eof atomic.Bool
+}
+
+func (r *eofReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err == io.EOF {
+		r.eof.Store(true)
+	}
+	return n, err
+}
+
+// watchStdin wraps the standard input of the command, unless it is a
+// file given directly to it, to learn whether it was fully written.
+func (c *Cmd) watchStdin() {
+	if c.Stdin == nil {
+		return
+	}
+	if _, ok := c.Stdin.(*os.File); ok {
+		return
+	}
+	c.stdinEOF = &eofReader{r: c.Stdin}
+	c.Stdin = c.stdinEOF
+}
+
+// stdioHeld reports whether err is the result of descendants of the
+// command holding its standard streams open after it exited
+// successfully, which is not considered a failure.
+func (c *Cmd) stdioHeld(err error) bool {
+	return errors.Is(err, exec.ErrWaitDelay) && c.ProcessState != nil && c.ProcessState.Success()
+}
@@ -0,0 +1,61 @@
+package exex_test
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+func TestResult_StdinClosed(t *testing.T) {
+	input := strings.Repeat("x", 1<<20)
+
+	tests := map[string]bool{
+		"cat":  false,
+		"exit": true,
+	}
+
+	for mode, exp := range tests {
+		t.Run(mode, func(t *testing.T) {
+			cmd := exex.Command(os.Args[0], "0").Apply(
+				exex.WithEnv("TEST_MAIN="+mode),
+				exex.WithStdin(strings.NewReader(input)),
+			)
+			if _, err := cmd.Output(); err != nil {
+				t.Fatal(err)
+			}
+			if got := cmd.Result().StdinClosed; got != exp {
+				t.Fatalf("expecting StdinClosed %v, got %v", exp, got)
+			}
+		})
+	}
+}
+
+func TestResult_StdioHeld(t *testing.T) {
+	var stdout bytes.Buffer
+	cmd := exex.Command(os.Args[0]).Apply(
+		exex.WithEnv("TEST_MAIN=daemon"),
+		exex.WithStdout(&stdout),
+	)
+	cmd.WaitDelay = 100 * time.Millisecond
+
+	err := cmd.Run()
+
+	lines := strings.SplitN(stdout.String(), "\n", 2)
+	if pid, perr := strconv.Atoi(lines[0]); perr == nil {
+		if p, err := os.FindProcess(pid); err == nil {
+			p.Kill()
+		}
+	}
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cmd.Result().StdioHeld {
+		t.Fatal("expecting StdioHeld")
+	}
+}
@@ -0,0 +1,175 @@
+package exex
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+)
+
+// StreamStdout runs the command calling fn with each line written to
+// its standard output, without the line terminator, as soon as it is
+// written. The last line is passed to fn even if it is not
+// terminated. Errors are reported the same way as Run does.
+//
+// The output is split into lines by newlines, optionally preceded by a
+// carriage return, unless a different bufio.SplitFunc is set with
+// WithSplit, in which case fn is called with each of its tokens. If
+// the SplitFunc fails, the rest of the output is discarded and its
+// error is returned once the command finishes successfully.
+//
+// If fn is slower than the command writing its output, the command is
+// blocked unless a different policy is set with WithBackpressure.
+func (c *Cmd) StreamStdout(fn func(line string)) error {
+	if c.Stdout != nil {
+		return errors.New("exex: Stdout already set")
+	}
+
+	deliver, finish, err := c.pace(fn)
+	if err != nil {
+		return err
+	}
+
+	lw := &lineWriter{fn: deliver, split: c.split}
+	c.Stdout = lw
+	err = c.Run()
+	lw.Flush()
+	finish()
+	if err == nil {
+		err = lw.Err()
+	}
+
+	return err
+}
+
+// StreamStderr runs the command calling fn with each line written to
+// its standard error, as StreamStdout does for the standard output.
+// The standard error is still captured and reported as described in
+// Run.
+func (c *Cmd) StreamStderr(fn func(line string)) error {
+	if c.Stderr != nil || c.mergeStderr {
+		return errors.New("exex: Stderr already set")
+	}
+
+	deliver, finish, err := c.pace(fn)
+	if err != nil {
+		return err
+	}
+
+	lw := &lineWriter{fn: deliver, split: c.split}
+	c.captureStderr(lw)
+	err = c.Run()
+	lw.Flush()
+	finish()
+	if err == nil {
+		err = lw.Err()
+	}
+
+	return err
+}
+
+// TeeStderr copies the standard error of the command to w as it is
+// written, e.g. to os.Stderr to show it live, while still capturing
+// it and reporting it as described in Run. The Stderr field of the
+// command must be nil.
+func (c *Cmd) TeeStderr(w io.Writer) {
+	c.stderrTee = w
+}
+
+// lineWriter is an io.Writer calling fn with each line written to
+// it, without the line terminator, or with each token returned by
+// split if set.
+type lineWriter struct {
+	fn    func(line string)
+	split bufio.SplitFunc
+	mu    sync.Mutex
+	buf   []byte
+	done  bool  // whether split returned bufio.ErrFinalToken
+	err   error // returned by split
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.split != nil {
+		w.scan(p, false)
+		return len(p), nil
+	}
+
+	n := len(p)
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.buf = append(w.buf, p...)
+			return n, nil
+		}
+
+		line := p[:i]
+		if len(w.buf) > 0 {
+			line = append(w.buf, line...)
+			w.buf = w.buf[:0]
+		}
+		w.fn(string(bytes.TrimSuffix(line, []byte{'\r'})))
+		p = p[i+1:]
+	}
+}
+
+// Flush calls fn with the pending unterminated line, if any.
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.split != nil {
+		w.scan(nil, true)
+		return
+	}
+
+	if len(w.buf) > 0 {
+		w.fn(string(bytes.TrimSuffix(w.buf, []byte{'\r'})))
+		w.buf = w.buf[:0]
+	}
+}
+
+// scan appends p to the buffer and calls fn with the tokens that split
+// finds in it. Once split fails, or returns its final token, the rest
+// of the output is discarded.
+func (w *lineWriter) scan(p []byte, atEOF bool) {
+	if w.done || w.err != nil {
+		return
+	}
+
+	w.buf = append(w.buf, p...)
+	var off int
+	for off < len(w.buf) {
+		advance, token, err := w.split(w.buf[off:], atEOF)
+		if err != nil && !errors.Is(err, bufio.ErrFinalToken) {
+			w.err = err
+			break
+		}
+		if advance < 0 || advance > len(w.buf)-off {
+			w.err = bufio.ErrBadReadCount
+			break
+		}
+		if token != nil && (advance > 0 || atEOF || err != nil) {
+			w.fn(string(token))
+		}
+		if err != nil {
+			w.done = true
+			break
+		}
+		if advance == 0 {
+			break
+		}
+		off += advance
+	}
+	w.buf = append(w.buf[:0], w.buf[off:]...)
+}
+
+// Err returns the error returned by split, if any.
+func (w *lineWriter) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
@@ -0,0 +1,62 @@
+package exex_test
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestStreamStdout(t *testing.T) {
+	var lines []string
+	err := exex.Command(os.Args[0], strconv.Quote("one\ntwo\r\n\nthree")).Apply(
+		exex.WithEnv("TEST_MAIN=print"),
+	).StreamStdout(func(line string) { lines = append(lines, line) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{"one", "two", "", "three"}
+	if !reflect.DeepEqual(lines, exp) {
+		t.Fatalf("expecting %q, got %q", exp, lines)
+	}
+}
+
+func TestStreamStderr(t *testing.T) {
+	var lines []string
+	err := exex.Command(os.Args[0], "2", "first\nsecond\n").Apply(
+		exex.WithEnv("TEST_MAIN=exit"),
+	).StreamStderr(func(line string) { lines = append(lines, line) })
+
+	assertErr(t, err, "first\nsecond\n")
+
+	exp := []string{"first", "second"}
+	if !reflect.DeepEqual(lines, exp) {
+		t.Fatalf("expecting %q, got %q", exp, lines)
+	}
+}
+
+func TestStreamAlreadySet(t *testing.T) {
+	if err := exex.Command(os.Args[0]).Apply(exex.WithStdout(os.Stdout)).StreamStdout(func(string) {}); err == nil {
+		t.Error("expecting an error when Stdout is set")
+	}
+	if err := exex.Command(os.Args[0]).Apply(exex.MergeStderrIntoStdout()).StreamStderr(func(string) {}); err == nil {
+		t.Error("expecting an error when Stderr is merged")
+	}
+}
+
+func TestCmd_TeeStderr(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := exex.Command(os.Args[0], "live", "stderr")
+	cmd.TeeStderr(&buf)
+
+	err := cmd.Run()
+	assertErr(t, err, "error: live stderr")
+
+	if got := buf.String(); got != "error: live stderr" {
+		t.Fatalf("expecting %q, got %q", "error: live stderr", got)
+	}
+}
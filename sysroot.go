@@ -0,0 +1,222 @@
+package exex
+
+import (
+	"bufio"
+	"bytes"
+	"debug/elf"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SysrootLibDirs are the directories, relative to the sysroot, where
+// the dynamic loader looks for libraries when running a command set
+// with WithSysroot.
+var SysrootLibDirs = []string{"lib", "lib64", "usr/lib", "usr/lib64", "usr/local/lib"}
+
+const defaultSysrootPATH = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// maxSysrootLinks is the maximum number of interpreters and symbolic
+// links followed while resolving a command in a sysroot.
+const maxSysrootLinks = 40
+
+// WithSysroot runs the command from the directory tree dir, like a
+// cross toolchain or a root file system, as if dir were the root
+// directory, without chroot privileges:
+//
+//   - its name is looked up in the PATH of the command, or a default
+//     one, inside dir, and absolute names are relative to dir;
+//   - if it is a script, its interpreter, from the #! line, is
+//     resolved inside dir and runs the script instead;
+//   - if it is a dynamically linked ELF executable, its dynamic
+//     loader, from its PT_INTERP header, is resolved inside dir and
+//     runs it instead, looking for libraries in the SysrootLibDirs
+//     of dir that exist.
+//
+// The command is resolved when started, which fails if it is not
+// found in dir. Only absolute symbolic links in the last element of
+// the paths are resolved inside dir, while the arguments and working
+// directory of the command are not modified.
+func WithSysroot(dir string) Option {
+	return func(c *Cmd) { c.sysroot = dir }
+}
+
+// resolveSysroot sets the path and arguments of the command to run it
+// from its sysroot.
+func (c *Cmd) resolveSysroot() error {
+	if c.sysroot == "" || len(c.Args) == 0 {
+		return nil
+	}
+
+	args, err := sysrootArgs(c.sysroot, c.Dir, c.sysrootPATH(), c.Args)
+	if err != nil {
+		return fmt.Errorf("exex: sysroot: %w", err)
+	}
+
+	// Clones of the started command run the resolved command line.
+	c.Path, c.Args, c.Err = args[0], args, nil
+	c.sysroot = ""
+	return nil
+}
+
+func (c *Cmd) sysrootPATH() string {
+	for i := len(c.Env) - 1; i >= 0; i-- {
+		if v, ok := strings.CutPrefix(c.Env[i], "PATH="); ok {
+			return v
+		}
+	}
+	return defaultSysrootPATH
+}
+
+// sysrootArgs returns the command line running args inside root,
+// starting with the path of the executable to run.
+func sysrootArgs(root, dir, pathList string, args []string) ([]string, error) {
+	name, err := sysrootLookPath(root, dir, pathList, args[0])
+	if err != nil {
+		return nil, err
+	}
+	args = append([]string{name}, args[1:]...)
+
+	for i := 0; i < maxSysrootLinks; i++ {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return nil, err
+		}
+		interp, arg, isScript, err := readInterp(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", args[0], err)
+		}
+		if interp == "" {
+			return args, nil
+		}
+
+		if interp, err = sysrootFile(root, interp); err != nil {
+			return nil, err
+		}
+
+		head := []string{interp}
+		switch {
+		case !isScript:
+			if libs := sysrootLibs(root); libs != "" {
+				head = append(head, "--library-path", libs)
+			}
+			// The loader is not an ELF with an interpreter itself.
+			return append(head, args...), nil
+		case arg != "":
+			head = append(head, arg)
+		}
+		args = append(head, args...)
+	}
+
+	return nil, errors.New("too many levels of interpreters")
+}
+
+// sysrootLookPath looks for the executable name inside root.
+func sysrootLookPath(root, dir, pathList, name string) (string, error) {
+	if strings.Contains(name, "/") {
+		if !path.IsAbs(name) {
+			return filepath.Join(dir, name), nil
+		}
+		return sysrootFile(root, name)
+	}
+
+	for _, d := range filepath.SplitList(pathList) {
+		if d == "" || !path.IsAbs(d) {
+			continue
+		}
+		p, err := sysrootFile(root, path.Join(d, name))
+		if err != nil {
+			continue
+		}
+		if fi, err := os.Stat(p); err == nil && fi.Mode().IsRegular() && fi.Mode().Perm()&0o111 != 0 {
+			return p, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s: %w", name, ErrNotFound)
+}
+
+// sysrootFile returns the path of the absolute file name inside root,
+// following absolute symbolic links inside root.
+func sysrootFile(root, name string) (string, error) {
+	p := filepath.Join(root, filepath.FromSlash(name))
+	for i := 0; i < maxSysrootLinks; i++ {
+		fi, err := os.Lstat(p)
+		if err != nil {
+			return "", err
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			return p, nil
+		}
+
+		target, err := os.Readlink(p)
+		if err != nil {
+			return "", err
+		}
+		if !path.IsAbs(filepath.ToSlash(target)) {
+			return p, nil
+		}
+		p = filepath.Join(root, target)
+	}
+
+	return "", fmt.Errorf("%s: too many levels of symbolic links", name)
+}
+
+// sysrootLibs returns the library directories of root that exist,
+// separated with colons.
+func sysrootLibs(root string) string {
+	var dirs []string
+	for _, d := range SysrootLibDirs {
+		p := filepath.Join(root, d)
+		if fi, err := os.Stat(p); err == nil && fi.IsDir() {
+			dirs = append(dirs, p)
+		}
+	}
+	return strings.Join(dirs, ":")
+}
+
+// readInterp returns the interpreter of the executable f: the
+// interpreter of a script and its optional argument from its #! line,
+// or the dynamic loader of an ELF executable. The interpreter is
+// empty for other executables.
+func readInterp(f *os.File) (interp, arg string, isScript bool, err error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(f, magic[:]); err != nil {
+		return "", "", false, nil
+	}
+
+	if bytes.HasPrefix(magic[:], []byte("#!")) {
+		line, err := bufio.NewReader(io.MultiReader(bytes.NewReader(magic[2:]), f)).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", "", true, err
+		}
+		interp, arg, _ = strings.Cut(strings.TrimSpace(line), " ")
+		return interp, strings.TrimSpace(arg), true, nil
+	}
+
+	if string(magic[:]) != elf.ELFMAG {
+		return "", "", false, nil
+	}
+
+	e, err := elf.NewFile(f)
+	if err != nil {
+		return "", "", false, err
+	}
+	for _, p := range e.Progs {
+		if p.Type != elf.PT_INTERP {
+			continue
+		}
+		b, err := io.ReadAll(p.Open())
+		if err != nil {
+			return "", "", false, err
+		}
+		return string(bytes.TrimRight(b, "\x00")), "", false, nil
+	}
+
+	return "", "", false, nil
+}
@@ -0,0 +1,120 @@
+package exex_test
+
+import (
+	"debug/elf"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+// testSysroot returns a sysroot with the test binary installed as
+// /usr/bin/tool, and its dynamic loader, if any, linked from the
+// host.
+func testSysroot(t *testing.T) string {
+	t.Helper()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("sysroot test requires Linux")
+	}
+
+	self, err := filepath.Abs(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	link := func(target, name string) {
+		name = filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		rel, err := filepath.Rel(filepath.Dir(name), target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(rel, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	link(self, "usr/bin/tool")
+
+	f, err := elf.Open(self)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	for _, p := range f.Progs {
+		if p.Type == elf.PT_INTERP {
+			b, _ := io.ReadAll(p.Open())
+			interp := strings.TrimRight(string(b), "\x00")
+			link(interp, interp)
+		}
+	}
+
+	return root
+}
+
+func TestWithSysroot(t *testing.T) {
+	root := testSysroot(t)
+
+	script := "#!/usr/bin/tool -x\n"
+	if err := os.WriteFile(filepath.Join(root, "usr/bin/script"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		name string
+		exp  string
+	}{
+		"lookup":   {name: "tool", exp: "a b\n"},
+		"absolute": {name: "/usr/bin/tool", exp: "a b\n"},
+		"script":   {name: "script", exp: "-x " + filepath.Join(root, "usr/bin/script") + " a b\n"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmd := exex.Command(tt.name, "a", "b").Apply(
+				exex.WithEnv("TEST_MAIN=echo", "PATH=/usr/bin"),
+				exex.WithSysroot(root),
+			)
+			out, err := cmd.Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.exp {
+				t.Fatalf("expecting %q, got %q", tt.exp, out)
+			}
+			if !strings.HasPrefix(cmd.Path, root) {
+				t.Fatalf("expecting %s to be inside %s", cmd.Path, root)
+			}
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		err := exex.Command("missing").Apply(exex.WithSysroot(root)).Run()
+		if !errors.Is(err, exex.ErrNotFound) {
+			t.Fatalf("expecting ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("clone", func(t *testing.T) {
+		cmd := exex.Command("tool", "a").Apply(exex.WithEnv("TEST_MAIN=echo", "PATH=/usr/bin"), exex.WithSysroot(root))
+		if err := cmd.Run(); err != nil {
+			t.Fatal(err)
+		}
+		out, err := cmd.Clone().Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != "a\n" {
+			t.Fatalf("expecting %q, got %q", "a\n", out)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package exex
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WithTempTracking runs the command with its own temporary directory,
+// created before it starts and set in its TMPDIR, TMP and TEMP
+// environment variables. Once the command finished, regardless of
+// whether it succeeded, the files it left behind there are reported
+// in Result.TempFiles and Result.TempBytes, and the directory is
+// removed.
+//
+// Errors removing the directory are returned by Wait if the command
+// succeeded.
+func WithTempTracking() Option {
+	return func(c *Cmd) { c.tempDir = &tempTracking{} }
+}
+
+type tempTracking struct {
+	dir string
+}
+
+// setup creates the temporary directory of the command and adds it
+// to its environment.
+func (t *tempTracking) setup(c *Cmd) error {
+	dir, err := os.MkdirTemp("", "exex-tmp-*")
+	if err != nil {
+		return fmt.Errorf("exex: temp tracking: %w", err)
+	}
+	t.dir = dir
+
+	if c.Env == nil {
+		c.Env = os.Environ()
+	}
+	c.Env = append(c.Env, "TMPDIR="+dir, "TMP="+dir, "TEMP="+dir)
+	return nil
+}
+
+// cleanup reports the files left in the temporary directory in r, if
+// not nil, and removes it.
+func (t *tempTracking) cleanup(r *Result) error {
+	if t.dir == "" {
+		return nil
+	}
+	dir := t.dir
+	t.dir = ""
+
+	if r != nil {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			rel, _ := filepath.Rel(dir, path)
+			r.TempFiles = append(r.TempFiles, filepath.ToSlash(rel))
+			if fi, err := d.Info(); err == nil {
+				r.TempBytes += fi.Size()
+			}
+			return nil
+		})
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("exex: temp tracking: %w", err)
+	}
+	return nil
+}
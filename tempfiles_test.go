@@ -0,0 +1,51 @@
+package exex_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithTempTracking(t *testing.T) {
+	cmd := exex.Command(os.Args[0], "leak=hello", "sub/dir/file=12345678").Apply(
+		exex.WithEnv("TEST_MAIN=temp"),
+		exex.WithTempTracking(),
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := string(out)
+	if dir == os.TempDir() {
+		t.Fatalf("expecting the command to use its own temporary directory, got %s", dir)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("expecting %s to be removed, got %v", dir, err)
+	}
+
+	r := cmd.Result()
+	if exp := []string{"leak", "sub/dir/file"}; !reflect.DeepEqual(r.TempFiles, exp) {
+		t.Fatalf("expecting %q, got %q", exp, r.TempFiles)
+	}
+	if r.TempBytes != 13 {
+		t.Fatalf("expecting 13 bytes, got %d", r.TempBytes)
+	}
+
+	t.Run("clean", func(t *testing.T) {
+		cmd := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=temp"), exex.WithTempTracking())
+		if err := cmd.Run(); err != nil {
+			t.Fatal(err)
+		}
+		if r := cmd.Result(); r.TempFiles != nil || r.TempBytes != 0 {
+			t.Fatalf("expecting no files, got %q", r.TempFiles)
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		cmd := exex.Command(os.Args[0], "1", "failed").Apply(exex.WithEnv("TEST_MAIN=exit"), exex.WithTempTracking())
+		assertErr(t, cmd.Run(), "failed")
+	})
+}
@@ -0,0 +1,59 @@
+package exex
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+// WithGracefulStop makes the command receive sig instead of being
+// killed when its context is done. If the command has not exited
+// after timeout, it is then killed.
+//
+// The signal is also the one sent by Terminate. It only affects
+// cancellation when the Cmd was created with CommandContext.
+func WithGracefulStop(sig os.Signal, timeout time.Duration) Option {
+	return func(c *Cmd) {
+		c.stopSignal = sig
+		if c.ctx != nil {
+			c.Cancel = func() error { return c.signal(sig) }
+			c.WaitDelay = timeout
+		}
+	}
+}
+
+// Terminate asks the command to stop by sending it SIGTERM, or the
+// signal set by WithGracefulStop, and kills it if it has not finished
+// after the graceful period. On systems where the signal cannot be
+// sent, like Windows, the command is killed right away.
+//
+// Terminate does not wait for the command: Wait must still be called
+// to release its resources and learn its exit status.
+func (c *Cmd) Terminate(graceful time.Duration) error {
+	if c.Process == nil || c.done == nil {
+		return errors.New("exex: not started")
+	}
+
+	sig := c.stopSignal
+	if sig == nil {
+		sig = syscall.SIGTERM
+	}
+
+	if err := c.signal(sig); err != nil {
+		if errors.Is(err, os.ErrProcessDone) {
+			return nil
+		}
+		return c.kill()
+	}
+
+	t := time.NewTimer(graceful)
+	defer t.Stop()
+
+	select {
+	case <-c.done:
+		return nil
+	case <-t.C:
+		return c.kill()
+	}
+}
@@ -0,0 +1,106 @@
+//go:build unix
+
+package exex_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+// startReady starts cmd and waits for it to print its ready line.
+func startReady(t *testing.T, cmd *exex.Cmd) {
+	t.Helper()
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bufio.NewReader(stdout).ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCmd_Terminate(t *testing.T) {
+	t.Run("graceful", func(t *testing.T) {
+		cmd := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=trap"))
+		startReady(t, cmd)
+
+		errc := make(chan error, 1)
+		go func() { errc <- cmd.Wait() }()
+
+		if err := cmd.Terminate(time.Minute); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		err := <-errc
+		assertErr(t, err, "terminated")
+		if code, _ := exex.ExitCode(err); code != 3 {
+			t.Fatalf("expecting exit code 3, got %d", code)
+		}
+	})
+
+	t.Run("kill", func(t *testing.T) {
+		cmd := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=hang"))
+		startReady(t, cmd)
+
+		if err := cmd.Terminate(50 * time.Millisecond); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		err := cmd.Wait()
+		var exErr *exex.ExitError
+		if !errors.As(err, &exErr) {
+			t.Fatalf("expecting *exec.ExitError, got %T: %[1]v", err)
+		}
+		if ws := exErr.Sys().(syscall.WaitStatus); !ws.Signaled() || ws.Signal() != syscall.SIGKILL {
+			t.Fatalf("expecting command to be killed, got %v", err)
+		}
+	})
+
+	t.Run("not started", func(t *testing.T) {
+		if err := exex.Command(os.Args[0]).Terminate(0); err == nil {
+			t.Fatal("expecting an error")
+		}
+	})
+}
+
+func TestWithGracefulStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cmd := exex.CommandContext(ctx, os.Args[0]).Apply(
+		exex.WithEnv("TEST_MAIN=trap"),
+		exex.WithGracefulStop(syscall.SIGTERM, time.Minute),
+	)
+	startReady(t, cmd)
+	cancel()
+
+	err := cmd.Wait()
+	assertErr(t, err, "terminated")
+}
+
+func TestWithGracefulStopClone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	orig := exex.CommandContext(ctx, os.Args[0]).Apply(
+		exex.WithEnv("TEST_MAIN=trap"),
+		exex.WithGracefulStop(syscall.SIGTERM, time.Minute),
+	)
+	cmd := orig.Clone()
+	startReady(t, cmd)
+	cancel()
+
+	err := cmd.Wait()
+	assertErr(t, err, "terminated")
+}
@@ -0,0 +1,32 @@
+package exex
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTimeout is the error reported when a command is killed because
+// it did not finish within the timeout given to RunTimeout.
+var ErrTimeout = errors.New("exex: timeout")
+
+// RunTimeout starts the command and waits for it to end, like Run,
+// but kills it if it does not finish within d. In that case the
+// returned error matches ErrTimeout when using errors.Is, and still
+// wraps the *exec.ExitError with the standard error captured until
+// then.
+func (c *Cmd) RunTimeout(d time.Duration) error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+
+	t := time.AfterFunc(d, func() { c.abort(ErrTimeout) })
+	defer t.Stop()
+
+	return c.Wait()
+}
+
+// RunTimeout creates a Cmd and returns the result of executing
+// *Cmd.RunTimeout.
+func RunTimeout(d time.Duration, cmd string, args ...string) error {
+	return Command(cmd, args...).RunTimeout(d)
+}
@@ -0,0 +1,28 @@
+package exex_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+func TestRunTimeout(t *testing.T) {
+	t.Run("expired", func(t *testing.T) {
+		err := exex.Command(os.Args[0], "so", "far").Apply(exex.WithEnv("TEST_MAIN=hang")).RunTimeout(2 * time.Second)
+		if !errors.Is(err, exex.ErrTimeout) {
+			t.Fatalf("expecting exex.ErrTimeout, got %v", err)
+		}
+		assertErr(t, err, "so far")
+	})
+
+	t.Run("finished", func(t *testing.T) {
+		err := exex.RunTimeout(time.Minute, os.Args[0], "in", "time")
+		if errors.Is(err, exex.ErrTimeout) {
+			t.Fatalf("unexpected timeout: %v", err)
+		}
+		assertErr(t, err, "error: in time")
+	})
+}
@@ -0,0 +1,215 @@
+package exex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Tool describes an external program that is invoked repeatedly,
+// such as git or docker, along with the defaults that apply to every
+// one of its invocations.
+//
+// The executable path is resolved, and its version checked against
+// MinVersion, the first time a command is created and the result is
+// cached for the lifetime of the Tool, so a Tool must not be copied
+// after first use.
+//
+// All the errors returned by the methods of Tool are of type
+// *ToolError.
+type Tool struct {
+	// Name is the name or path of the executable. If it contains no
+	// path separators it is resolved using LookPath.
+	Name string
+
+	// MinVersion, if not empty, is the minimum version of the tool
+	// required, e.g. "2.30". Commands fail if the installed version
+	// is older.
+	MinVersion string
+
+	// VersionArgs are the arguments used to get the version of the
+	// tool. Defaults to "--version".
+	VersionArgs []string
+
+	// DefaultArgs are prepended to the arguments of every command.
+	DefaultArgs []string
+
+	// Env holds additional environment variables, in the form
+	// "key=value", that are added to the environment of every
+	// command.
+	Env []string
+
+	mu      sync.Mutex
+	checked bool
+	path    string
+	err     error
+}
+
+// ToolError records an error that happened while using a Tool.
+type ToolError struct {
+	Name string
+	Err  error
+}
+
+func (e *ToolError) Error() string { return "exex: " + e.Name + ": " + e.Err.Error() }
+
+func (e *ToolError) Unwrap() error { return e.Err }
+
+// VersionError is the error returned when the installed version of a
+// Tool is older than its MinVersion.
+type VersionError struct {
+	Version    string
+	MinVersion string
+}
+
+func (e *VersionError) Error() string {
+	return "version " + e.Version + " is older than required " + e.MinVersion
+}
+
+// Path returns the resolved path of the tool executable.
+func (t *Tool) Path(ctx context.Context) (string, error) {
+	if err := t.check(ctx); err != nil {
+		return "", err
+	}
+	return t.path, nil
+}
+
+// Command returns a Cmd to execute the tool with DefaultArgs followed
+// by args. The environment of the Cmd is the one of the current
+// process plus Env.
+func (t *Tool) Command(ctx context.Context, args ...string) (*Cmd, error) {
+	if err := t.check(ctx); err != nil {
+		return nil, err
+	}
+
+	cmd := CommandContext(ctx, t.path, append(t.DefaultArgs[:len(t.DefaultArgs):len(t.DefaultArgs)], args...)...)
+	if len(t.Env) > 0 {
+		cmd.Env = append(os.Environ(), t.Env...)
+	}
+
+	return cmd, nil
+}
+
+// Run executes the tool with the given arguments and waits for it to
+// finish. See *Cmd.Run for information on the returned error.
+func (t *Tool) Run(ctx context.Context, args ...string) error {
+	cmd, err := t.Command(ctx, args...)
+	if err != nil {
+		return err
+	}
+	return t.wrap(cmd.Run())
+}
+
+// Output executes the tool with the given arguments and returns its
+// standard output.
+func (t *Tool) Output(ctx context.Context, args ...string) ([]byte, error) {
+	cmd, err := t.Command(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.Output()
+	return out, t.wrap(err)
+}
+
+func (t *Tool) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	var tErr *ToolError
+	if errors.As(err, &tErr) {
+		return err
+	}
+	return &ToolError{Name: t.Name, Err: err}
+}
+
+// check resolves the tool path and version, and caches the result.
+// Errors caused by ctx are not cached.
+func (t *Tool) check(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.checked {
+		return t.err
+	}
+
+	err := t.resolve(ctx)
+	if err != nil && ctx.Err() != nil {
+		return t.wrap(err)
+	}
+
+	t.checked = true
+	t.err = t.wrap(err)
+
+	return t.err
+}
+
+func (t *Tool) resolve(ctx context.Context) error {
+	path, err := LookPath(t.Name)
+	if err != nil {
+		return err
+	}
+	t.path = path
+
+	if t.MinVersion == "" {
+		return nil
+	}
+
+	args := t.VersionArgs
+	if args == nil {
+		args = []string{"--version"}
+	}
+
+	cmd := CommandContext(ctx, path, args...)
+	if len(t.Env) > 0 {
+		cmd.Env = append(os.Environ(), t.Env...)
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return err
+	}
+
+	version := versionRe.FindString(string(out))
+	if version == "" {
+		return fmt.Errorf("cannot parse version from %q", strings.TrimSpace(string(out)))
+	}
+
+	if compareVersions(version, t.MinVersion) < 0 {
+		return &VersionError{Version: version, MinVersion: t.MinVersion}
+	}
+
+	return nil
+}
+
+var versionRe = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// compareVersions compares two dot separated numeric versions,
+// returning -1, 0 or 1 if a is respectively lower, equal or greater
+// than b. Missing components are considered to be zero.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for len(as) < len(bs) {
+		as = append(as, "0")
+	}
+	for len(bs) < len(as) {
+		bs = append(bs, "0")
+	}
+
+	for i := range as {
+		x, _ := strconv.Atoi(as[i])
+		y, _ := strconv.Atoi(bs[i])
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,74 @@
+package exex_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestTool(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("run", func(t *testing.T) {
+		tool := exex.Tool{Name: os.Args[0], DefaultArgs: []string{"default"}}
+
+		err := tool.Run(ctx, "foo")
+		assertErr(t, err, "error: default foo")
+
+		var tErr *exex.ToolError
+		if !errors.As(err, &tErr) {
+			t.Fatalf("expecting *exex.ToolError, got %T", err)
+		}
+		if tErr.Name != os.Args[0] {
+			t.Errorf("expecting tool name %q, got %q", os.Args[0], tErr.Name)
+		}
+	})
+
+	t.Run("env", func(t *testing.T) {
+		tool := exex.Tool{Name: os.Args[0], Env: []string{"TEST_MAIN=version"}}
+
+		out, err := tool.Output(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if exp := "exex-test version 1.2.3\n"; string(out) != exp {
+			t.Fatalf("expecting %q, got %q", exp, out)
+		}
+	})
+
+	t.Run("min version", func(t *testing.T) {
+		tool := exex.Tool{Name: os.Args[0], MinVersion: "1.2", Env: []string{"TEST_MAIN=version"}}
+
+		if err := tool.Run(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("too old", func(t *testing.T) {
+		tool := exex.Tool{Name: os.Args[0], MinVersion: "1.10", Env: []string{"TEST_MAIN=version"}}
+
+		for i := 0; i < 2; i++ {
+			err := tool.Run(ctx)
+
+			var vErr *exex.VersionError
+			if !errors.As(err, &vErr) {
+				t.Fatalf("expecting *exex.VersionError, got %T: %[1]v", err)
+			}
+			if vErr.Version != "1.2.3" || vErr.MinVersion != "1.10" {
+				t.Fatalf("unexpected version error: %v", vErr)
+			}
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		tool := exex.Tool{Name: "foobarbazquux"}
+
+		_, err := tool.Path(ctx)
+		if !errors.Is(err, exex.ErrNotFound) {
+			t.Fatalf("expecting exex.ErrNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package exex
+
+import (
+	"context"
+	"io"
+	"os"
+	"runtime/trace"
+	"strings"
+)
+
+// Commands are traced with runtime/trace when tracing is enabled at
+// the time they are started: each one runs within a task named
+// "exex.Cmd" with regions for starting it, waiting for it, and the
+// copies of its standard streams done by the exec package. Streams
+// that are files are given directly to the command and thus have no
+// regions.
+
+// traceStart creates the task of the command if tracing is enabled,
+// and wraps its streams to record the copies.
+func (c *Cmd) traceStart() {
+	if !trace.IsEnabled() {
+		return
+	}
+
+	c.traceCtx, c.traceTask = trace.NewTask(c.Context(), "exex.Cmd")
+	trace.Log(c.traceCtx, "command", strings.Join(RedactArgs(c.Args), " "))
+
+	if r := c.Stdin; r != nil {
+		if _, ok := r.(*os.File); !ok {
+			c.Stdin = &tracedReader{ctx: c.traceCtx, r: r}
+		}
+	}
+
+	wrap := func(w io.Writer, region string) io.Writer {
+		if _, ok := w.(*os.File); ok || w == nil {
+			return w
+		}
+		return &tracedWriter{ctx: c.traceCtx, w: w, region: region}
+	}
+
+	if sameWriter(c.Stdout, c.Stderr) {
+		c.Stdout = wrap(c.Stdout, "output")
+		c.Stderr = c.Stdout
+		return
+	}
+	c.Stdout = wrap(c.Stdout, "stdout")
+	c.Stderr = wrap(c.Stderr, "stderr")
+}
+
+// traceRegion runs fn within the named region of the command task, if
+// any.
+func (c *Cmd) traceRegion(name string, fn func() error) error {
+	if c.traceCtx == nil {
+		return fn()
+	}
+
+	var err error
+	trace.WithRegion(c.traceCtx, name, func() { err = fn() })
+	return err
+}
+
+func (c *Cmd) traceEnd(err error) {
+	if c.traceTask == nil {
+		return
+	}
+	if err != nil {
+		trace.Log(c.traceCtx, "error", err.Error())
+	}
+	c.traceTask.End()
+	c.traceTask = nil
+}
+
+type tracedReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *tracedReader) Read(p []byte) (n int, err error) {
+	defer trace.StartRegion(r.ctx, "stdin").End()
+	return r.r.Read(p)
+}
+
+type tracedWriter struct {
+	ctx    context.Context
+	w      io.Writer
+	region string
+}
+
+func (w *tracedWriter) Write(p []byte) (n int, err error) {
+	defer trace.StartRegion(w.ctx, w.region).End()
+	return w.w.Write(p)
+}
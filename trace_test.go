@@ -0,0 +1,33 @@
+package exex_test
+
+import (
+	"bytes"
+	"os"
+	"runtime/trace"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestTrace(t *testing.T) {
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Skip(err)
+	}
+
+	out, err := exex.Command(os.Args[0], "traced").Apply(exex.WithEnv("TEST_MAIN=echo")).Output()
+	trace.Stop()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "traced\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+
+	for _, s := range []string{"exex.Cmd", "start", "wait", "stdout", "traced"} {
+		if !bytes.Contains(buf.Bytes(), []byte(s)) {
+			t.Errorf("expecting %q in trace", s)
+		}
+	}
+}
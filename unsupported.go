@@ -0,0 +1,81 @@
+package exex
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+)
+
+// UnsupportedFeatureError is the error returned when a feature
+// requested for a command, e.g. with WithOrphanCheck, is not
+// supported on the current system. It matches errors.ErrUnsupported.
+type UnsupportedFeatureError struct {
+	// Feature is the name of the unsupported feature.
+	Feature string
+
+	// GOOS is the system where it is not supported.
+	GOOS string
+}
+
+func (e *UnsupportedFeatureError) Error() string {
+	return fmt.Sprintf("exex: %s not supported on %s", e.Feature, e.GOOS)
+}
+
+func (e *UnsupportedFeatureError) Is(target error) bool {
+	return target == errors.ErrUnsupported
+}
+
+func unsupported(feature string) error {
+	return &UnsupportedFeatureError{Feature: feature, GOOS: runtime.GOOS}
+}
+
+// Degradation is the policy applied when a command requests features
+// that are not supported on the current system, so that
+// cross-platform programs can use the same options everywhere.
+type Degradation int
+
+const (
+	// DegradeError fails to start the command with an
+	// *UnsupportedFeatureError.
+	DegradeError Degradation = iota
+
+	// DegradeWarn starts the command without the unsupported
+	// features, logs a warning, and reports each of them as an
+	// *UnsupportedFeatureError in Result.Warnings.
+	DegradeWarn
+
+	// DegradeIgnore silently starts the command without the
+	// unsupported features.
+	DegradeIgnore
+)
+
+// WithDegradation sets the policy applied when the command requests
+// features not supported on the current system. It defaults to
+// DegradeError.
+func WithDegradation(d Degradation) Option {
+	return func(c *Cmd) { c.degradation = d }
+}
+
+// degrade applies the Degradation policy of the command to err, the
+// error of setting up a feature, and returns the error that must
+// prevent the command from starting, if any.
+func (c *Cmd) degrade(err error) error {
+	var ufe *UnsupportedFeatureError
+	if !errors.As(err, &ufe) {
+		return err
+	}
+
+	switch c.degradation {
+	case DegradeWarn:
+		c.unsupported = append(c.unsupported, ufe)
+		if l := c.log(); l != nil {
+			attrs := append(c.logAttrs(), slog.String("feature", ufe.Feature))
+			l.LogAttrs(c.Context(), slog.LevelWarn, "feature not supported", attrs...)
+		}
+		return nil
+	case DegradeIgnore:
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package exex_test
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestUnsupportedFeatureError(t *testing.T) {
+	err := error(&exex.UnsupportedFeatureError{Feature: "orphan check", GOOS: "plan9"})
+
+	if exp := "exex: orphan check not supported on plan9"; err.Error() != exp {
+		t.Errorf("expecting %q, got %q", exp, err.Error())
+	}
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Error("expecting error to match errors.ErrUnsupported")
+	}
+}
+
+func TestWithDegradation(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("orphan check is supported on", runtime.GOOS)
+	}
+
+	tests := map[string]struct {
+		d     exex.Degradation
+		err   bool
+		warns int
+	}{
+		"error":  {d: exex.DegradeError, err: true},
+		"warn":   {d: exex.DegradeWarn, warns: 1},
+		"ignore": {d: exex.DegradeIgnore},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmd := exex.Command(os.Args[0]).Apply(
+				exex.WithEnv("TEST_MAIN=version"),
+				exex.WithOrphanCheck(false),
+				exex.WithDegradation(tt.d),
+			)
+			err := cmd.Run()
+
+			var ufe *exex.UnsupportedFeatureError
+			if tt.err {
+				if !errors.As(err, &ufe) {
+					t.Fatalf("expecting *UnsupportedFeatureError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ws := cmd.Result().Warnings
+			if len(ws) != tt.warns {
+				t.Fatalf("expecting %d warnings, got %v", tt.warns, ws)
+			}
+			for _, w := range ws {
+				if !errors.As(w, &ufe) {
+					t.Errorf("expecting *UnsupportedFeatureError, got %T", w)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package exex
+
+import (
+	"os"
+	"time"
+)
+
+// Usage is the resource usage of a finished command, as reported by
+// the system. Fields not reported by the system are zero.
+type Usage struct {
+	// UserTime and SystemTime are the CPU time spent by the command
+	// in user and kernel mode respectively.
+	UserTime   time.Duration
+	SystemTime time.Duration
+
+	// MaxRSS is the maximum resident set size, in bytes.
+	MaxRSS int64
+
+	// MinorFaults and MajorFaults are the number of page faults not
+	// requiring and requiring I/O respectively.
+	MinorFaults int64
+	MajorFaults int64
+}
+
+// Usage returns the resource usage of the command once it finished,
+// which is also reported in Result.Usage, or nil if it has not
+// finished or was not executed as a local process.
+func (c *Cmd) Usage() *Usage {
+	if c.result == nil {
+		return nil
+	}
+	return c.result.Usage
+}
+
+// processUsage returns the resource usage of the process ps.
+func processUsage(ps *os.ProcessState) *Usage {
+	u := &Usage{
+		UserTime:   ps.UserTime(),
+		SystemTime: ps.SystemTime(),
+	}
+	sysUsage(u, ps.SysUsage())
+	return u
+}
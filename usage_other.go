@@ -0,0 +1,5 @@
+//go:build !unix
+
+package exex
+
+func sysUsage(u *Usage, v any) {}
@@ -0,0 +1,32 @@
+package exex_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestUsage(t *testing.T) {
+	cmd := exex.Command("/bin/sh", "-c", `i=0; while [ $i -lt 200000 ]; do i=$((i+1)); done`)
+	if cmd.Usage() != nil {
+		t.Fatal("expecting no usage before running")
+	}
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	u := cmd.Usage()
+	if u == nil {
+		t.Fatal("expecting usage")
+	}
+	if u != cmd.Result().Usage {
+		t.Fatal("expecting usage in result")
+	}
+	if u.UserTime+u.SystemTime <= 0 {
+		t.Fatalf("expecting CPU time, got %+v", u)
+	}
+	if runtime.GOOS != "windows" && (u.MaxRSS < 1<<10 || u.MinorFaults <= 0) {
+		t.Fatalf("expecting memory usage, got %+v", u)
+	}
+}
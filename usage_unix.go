@@ -0,0 +1,25 @@
+//go:build unix
+
+package exex
+
+import (
+	"runtime"
+	"syscall"
+)
+
+// sysUsage sets the fields of u reported in the system dependent
+// resource usage v.
+func sysUsage(u *Usage, v any) {
+	ru, ok := v.(*syscall.Rusage)
+	if !ok || ru == nil {
+		return
+	}
+
+	u.MaxRSS = int64(ru.Maxrss)
+	// Only macOS reports the maximum RSS in bytes rather than KiB.
+	if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+		u.MaxRSS *= 1024
+	}
+	u.MinorFaults = int64(ru.Minflt)
+	u.MajorFaults = int64(ru.Majflt)
+}
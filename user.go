@@ -0,0 +1,81 @@
+package exex
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+)
+
+// WithUser runs the command as the user with the given name or
+// numeric ID, with its primary group and supplementary groups, e.g.
+// to drop the privileges of the current process. The user is looked
+// up when the command is started, which fails if it does not exist.
+//
+// Changing the user requires privileges, and is only supported on
+// Unix systems; elsewhere starting the command fails with an
+// *UnsupportedFeatureError, unless set otherwise with
+// WithDegradation. The environment of the command, like HOME, is not
+// modified.
+func WithUser(name string) Option {
+	return func(c *Cmd) { c.credential = &credential{user: name} }
+}
+
+// WithCredential runs the command with the given user ID, group ID
+// and supplementary groups, as described in WithUser.
+func WithCredential(uid, gid uint32, groups ...uint32) Option {
+	return func(c *Cmd) {
+		c.credential = &credential{uid: uid, gid: gid, groups: append([]uint32(nil), groups...)}
+	}
+}
+
+type credential struct {
+	user     string // looked up when started, if set
+	uid, gid uint32
+	groups   []uint32
+}
+
+// lookup resolves the user name of the credential.
+func (cr *credential) lookup() (credential, error) {
+	if cr.user == "" {
+		return *cr, nil
+	}
+
+	u, err := user.Lookup(cr.user)
+	if _, ok := err.(user.UnknownUserError); ok {
+		if _, perr := strconv.Atoi(cr.user); perr == nil {
+			u, err = user.LookupId(cr.user)
+		}
+	}
+	if err != nil {
+		return credential{}, fmt.Errorf("exex: user %s: %w", cr.user, err)
+	}
+
+	gids, err := u.GroupIds()
+	if err != nil {
+		return credential{}, fmt.Errorf("exex: user %s: %w", cr.user, err)
+	}
+
+	var res credential
+	if res.uid, err = parseID(u.Uid); err != nil {
+		return credential{}, fmt.Errorf("exex: user %s: %w", cr.user, err)
+	}
+	if res.gid, err = parseID(u.Gid); err != nil {
+		return credential{}, fmt.Errorf("exex: user %s: %w", cr.user, err)
+	}
+	for _, g := range gids {
+		id, err := parseID(g)
+		if err != nil {
+			return credential{}, fmt.Errorf("exex: user %s: %w", cr.user, err)
+		}
+		res.groups = append(res.groups, id)
+	}
+	return res, nil
+}
+
+func parseID(s string) (uint32, error) {
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID %q", s)
+	}
+	return uint32(id), nil
+}
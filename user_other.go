@@ -0,0 +1,7 @@
+//go:build !unix
+
+package exex
+
+func (c *Cmd) setCredential() error {
+	return unsupported("user credentials")
+}
@@ -0,0 +1,53 @@
+//go:build unix
+
+package exex_test
+
+import (
+	"os"
+	"os/user"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithUser(t *testing.T) {
+	t.Run("unknown", func(t *testing.T) {
+		err := exex.Command("/bin/sh", "-c", "true").Apply(exex.WithUser("exex-no-such-user")).Run()
+		if err == nil || !strings.Contains(err.Error(), "exex-no-such-user") {
+			t.Fatalf("expecting unknown user error, got %v", err)
+		}
+	})
+
+	if os.Getuid() != 0 {
+		t.Skip("changing the user requires root")
+	}
+	nobody, err := user.Lookup("nobody")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tests := map[string]struct {
+		opt exex.Option
+		exp string
+	}{
+		"name":       {opt: exex.WithUser("nobody"), exp: nobody.Uid + " " + nobody.Gid},
+		"id":         {opt: exex.WithUser(nobody.Uid), exp: nobody.Uid + " " + nobody.Gid},
+		"credential": {opt: exex.WithCredential(12345, 23456, 34567), exp: "12345 23456 34567"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			out, err := exex.Command("/bin/sh", "-c", `echo $(id -u) $(id -G)`).Apply(
+				exex.WithEnv("PATH=/usr/bin:/bin"),
+				tt.opt,
+			).Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(string(out)); got != tt.exp {
+				t.Fatalf("expecting %q, got %q", tt.exp, got)
+			}
+		})
+	}
+}
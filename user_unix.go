@@ -0,0 +1,23 @@
+//go:build unix
+
+package exex
+
+import "syscall"
+
+// setCredential makes the command run with its credential.
+func (c *Cmd) setCredential() error {
+	cr, err := c.credential.lookup()
+	if err != nil {
+		return err
+	}
+
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.Credential = &syscall.Credential{
+		Uid:    cr.uid,
+		Gid:    cr.gid,
+		Groups: cr.groups,
+	}
+	return nil
+}
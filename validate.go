@@ -0,0 +1,128 @@
+package exex
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Validate checks the command before it is started and returns all
+// the problems found joined with errors.Join, or nil if none. It
+// checks that:
+//
+//   - the executable exists, is a regular file and, except on Windows,
+//     has execute permissions;
+//   - the working directory, if set, exists and is a directory;
+//   - each environment variable has the key=value form;
+//   - the arguments and environment variables pass the checks set
+//     with WithSanitize, if any;
+//   - the standard streams are not set more than once, e.g. by
+//     RedirectFD, MergeStderrIntoStdout or StdinFromCommand;
+//   - the options have valid values, e.g. the patterns of
+//     WithDirSnapshot and WithArtifacts.
+//
+// The executable and working directory are looked up in the local
+// filesystem, which might not be meaningful for commands started by
+// other Runners.
+func (c *Cmd) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("exex: "+format, args...))
+	}
+
+	// As in exec.Cmd, a relative path is relative to Dir.
+	path := c.Path
+	if !filepath.IsAbs(path) && c.Dir != "" {
+		path = filepath.Join(c.Dir, path)
+	}
+
+	if c.Err != nil {
+		add("%w", c.Err)
+	} else if fi, err := os.Stat(path); err != nil {
+		add("executable: %w", err)
+	} else if !fi.Mode().IsRegular() {
+		add("executable %s is not a regular file", c.Path)
+	} else if runtime.GOOS != "windows" && fi.Mode().Perm()&0o111 == 0 {
+		add("executable %s: %w", c.Path, os.ErrPermission)
+	}
+
+	if c.Dir != "" {
+		if fi, err := os.Stat(c.Dir); err != nil {
+			add("working directory: %w", err)
+		} else if !fi.IsDir() {
+			add("working directory %s is not a directory", c.Dir)
+		}
+	}
+
+	for _, kv := range c.Env {
+		// On Windows variables like "=C:" are valid.
+		if i := strings.IndexByte(kv, '='); i < 0 || (i == 0 && strings.IndexByte(kv[1:], '=') < 0) {
+			add("invalid environment variable %q", kv)
+		} else if strings.IndexByte(kv, 0) >= 0 {
+			add("environment variable %q contains a NUL byte", kv[:i])
+		}
+	}
+
+	errs = append(errs, c.checkSanitize()...)
+
+	set := map[int]bool{
+		0: c.Stdin != nil,
+		1: c.Stdout != nil,
+		2: c.Stderr != nil,
+	}
+	for i, f := range c.ExtraFiles {
+		set[i+3] = f != nil
+	}
+	if c.stdinCmd != nil {
+		if set[0] {
+			add("Stdin already set")
+		}
+		if c.stdinCmd.Stdout != nil {
+			add("Stdout of the command set by StdinFromCommand already set")
+		}
+		set[0] = true
+	}
+	if c.mergeStderr {
+		if set[2] {
+			add("conflicting redirections of file descriptor 2")
+		}
+		set[2] = true
+	}
+	for _, r := range c.redirects {
+		switch {
+		case r.fd < 0:
+			add("invalid file descriptor %d", r.fd)
+		case set[r.fd]:
+			add("conflicting redirections of file descriptor %d", r.fd)
+		}
+		set[r.fd] = true
+	}
+
+	if c.idle != nil && c.idle.timeout <= 0 {
+		add("invalid idle timeout %v", c.idle.timeout)
+	}
+	if c.snapshot != nil {
+		for _, p := range c.snapshot.patterns {
+			if _, err := filepath.Match(p, ""); err != nil {
+				add("snapshot pattern %q: %w", p, err)
+			}
+		}
+	}
+	if c.artifacts != nil {
+		if _, err := filepath.Match(c.artifacts.pattern, ""); err != nil {
+			add("artifacts pattern %q: %w", c.artifacts.pattern, err)
+		}
+	}
+	if c.runOnce != "" {
+		if fi, err := os.Stat(filepath.Dir(c.runOnce)); err != nil {
+			add("run once marker directory: %w", err)
+		} else if !fi.IsDir() {
+			add("run once marker directory %s is not a directory", filepath.Dir(c.runOnce))
+		}
+	}
+
+	return errors.Join(errs...)
+}
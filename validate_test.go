@@ -0,0 +1,68 @@
+package exex_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestCmd_Validate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		cmd := exex.Command(os.Args[0]).Apply(
+			exex.WithDir(t.TempDir()),
+			exex.WithEnv("FOO=bar"),
+			exex.RedirectFD(3, os.Stdout),
+		)
+		if err := cmd.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		err := exex.Command("non-existing-command").Validate()
+		if !errors.Is(err, exex.ErrNotFound) {
+			t.Fatalf("expecting exex.ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("not executable", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "script")
+		if err := os.WriteFile(name, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		err := exex.Command(name).Validate()
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Fatalf("expecting fs.ErrPermission, got %v", err)
+		}
+	})
+
+	t.Run("all problems", func(t *testing.T) {
+		cmd := exex.Command(os.Args[0]).Apply(
+			exex.WithDir(filepath.Join(t.TempDir(), "missing")),
+			exex.WithEnv("FOO"),
+			exex.WithStdout(os.Stdout),
+			exex.RedirectFD(1, os.Stderr),
+			exex.WithDirSnapshot("["),
+		)
+
+		err := cmd.Validate()
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expecting fs.ErrNotExist, got %v", err)
+		}
+		for _, msg := range []string{
+			"working directory",
+			`invalid environment variable "FOO"`,
+			"conflicting redirections of file descriptor 1",
+			`snapshot pattern "["`,
+		} {
+			if !strings.Contains(err.Error(), msg) {
+				t.Errorf("expecting %q in error, got %v", msg, err)
+			}
+		}
+	})
+}
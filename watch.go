@@ -0,0 +1,165 @@
+package exex
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// WatchInterval is how often the paths given to WithWatch are
+// checked for changes while the command runs.
+var WatchInterval = 100 * time.Millisecond
+
+// WithWatch watches the given paths, which can be files or
+// directories, while the command runs and reports the files that
+// were created or modified in Result.Touched, including the ones
+// that were removed before the command finished.
+//
+// Paths are polled every WatchInterval, comparing the size and
+// modification time of the files under them, so changes to a file
+// happening faster than that may be reported only once or, if it is
+// created and removed between two polls, missed. Relative paths are
+// relative to the working directory of the command, and paths that
+// do not exist yet are watched for their creation.
+func WithWatch(paths ...string) Option {
+	return func(c *Cmd) { c.watch = &fsWatch{paths: paths} }
+}
+
+type fileStat struct {
+	size    int64
+	modTime time.Time
+}
+
+type fsWatch struct {
+	paths   []string
+	dir     string
+	initial map[string]fileStat
+	last    map[string]fileStat
+	touched map[string]ChangeKind
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// start records the initial state of the watched paths.
+func (w *fsWatch) start(dir string) error {
+	w.dir = dir
+	w.touched = make(map[string]ChangeKind)
+
+	files, err := w.scan()
+	if err != nil {
+		return err
+	}
+	w.initial = files
+	w.last = files
+
+	return nil
+}
+
+// poll checks the watched paths periodically until stopped.
+func (w *fsWatch) poll() {
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+
+		t := time.NewTicker(WatchInterval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-t.C:
+				w.check()
+			}
+		}
+	}()
+}
+
+// finish stops polling, checks the paths one last time and returns
+// the files that were touched.
+func (w *fsWatch) finish() []FileChange {
+	if w.stop != nil {
+		close(w.stop)
+		<-w.done
+		w.stop = nil
+	}
+	w.check()
+
+	changes := make([]FileChange, 0, len(w.touched))
+	for name, kind := range w.touched {
+		changes = append(changes, FileChange{Path: name, Kind: kind})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	return changes
+}
+
+func (w *fsWatch) check() {
+	files, err := w.scan()
+	if err != nil {
+		return
+	}
+
+	for name, st := range files {
+		if _, ok := w.touched[name]; ok {
+			continue
+		}
+		if prev, ok := w.initial[name]; !ok {
+			w.touched[name] = FileCreated
+		} else if st != prev || st != w.last[name] {
+			w.touched[name] = FileModified
+		}
+	}
+	w.last = files
+}
+
+// scan returns the size and modification time of the regular files
+// under the watched paths.
+func (w *fsWatch) scan() (map[string]fileStat, error) {
+	files := make(map[string]fileStat)
+
+	for _, p := range w.paths {
+		root := p
+		if !filepath.IsAbs(p) && w.dir != "" {
+			root = filepath.Join(w.dir, p)
+		}
+
+		err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Files can be removed while walking.
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			fi, err := d.Info()
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+
+			rel, err := filepath.Rel(root, name)
+			if err != nil {
+				return err
+			}
+			files[filepath.Join(p, rel)] = fileStat{size: fi.Size(), modTime: fi.ModTime()}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,57 @@
+package exex_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithWatch(t *testing.T) {
+	exex.WatchInterval = 10 * time.Millisecond
+	defer func() { exex.WatchInterval = 100 * time.Millisecond }()
+
+	dir := t.TempDir()
+	out := t.TempDir()
+	for _, name := range []string{
+		filepath.Join(dir, "kept.txt"),
+		filepath.Join(dir, "changed.txt"),
+		filepath.Join(out, "other.txt"),
+	} {
+		if err := os.WriteFile(name, []byte("foo"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exex.Command(os.Args[0],
+		"changed.txt=changed",
+		"tmp.txt=tmp",
+		"@200ms",
+		"-tmp.txt",
+		"reports/new.txt=new",
+		filepath.Join(out, "artifact.bin")+"=bin",
+	).Apply(
+		exex.WithDir(dir),
+		exex.WithEnv("TEST_MAIN=files"),
+		exex.WithWatch(".", "reports", out),
+	)
+	os.Mkdir(filepath.Join(dir, "reports"), 0o755)
+
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []exex.FileChange{
+		{Path: filepath.Join(out, "artifact.bin"), Kind: exex.FileCreated},
+		{Path: "changed.txt", Kind: exex.FileModified},
+		{Path: filepath.Join("reports", "new.txt"), Kind: exex.FileCreated},
+		{Path: "tmp.txt", Kind: exex.FileCreated},
+	}
+
+	if got := cmd.Result().Touched; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expecting %v, got %v", exp, got)
+	}
+}
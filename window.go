@@ -0,0 +1,9 @@
+package exex
+
+// WithHiddenWindow prevents the command from showing a console window
+// on Windows, e.g. when started by a GUI application, setting
+// CREATE_NO_WINDOW and HideWindow in its SysProcAttr. It has no effect
+// elsewhere.
+func WithHiddenWindow() Option {
+	return func(c *Cmd) { c.hideWindow() }
+}
@@ -0,0 +1,5 @@
+//go:build !windows
+
+package exex
+
+func (c *Cmd) hideWindow() {}
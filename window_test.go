@@ -0,0 +1,28 @@
+package exex_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestWithHiddenWindow(t *testing.T) {
+	name, args := "/bin/sh", []string{"-c", "echo ok"}
+	if runtime.GOOS == "windows" {
+		name, args = `C:\Windows\System32\cmd.exe`, []string{"/c", "echo ok"}
+	}
+
+	cmd := exex.Command(name, args...).Apply(exex.WithHiddenWindow())
+	if runtime.GOOS != "windows" && cmd.SysProcAttr != nil {
+		t.Fatalf("expecting no SysProcAttr, got %+v", cmd.SysProcAttr)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) == 0 || out[0] != 'o' {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
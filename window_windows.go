@@ -0,0 +1,14 @@
+package exex
+
+import "syscall"
+
+// createNoWindow is the CREATE_NO_WINDOW process creation flag.
+const createNoWindow = 0x08000000
+
+func (c *Cmd) hideWindow() {
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.HideWindow = true
+	c.SysProcAttr.CreationFlags |= createNoWindow
+}
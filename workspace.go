@@ -0,0 +1,173 @@
+package exex
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutsideWorkspace is the error returned when a command run in a
+// Workspace refers to a path outside of its root directory.
+var ErrOutsideWorkspace = errors.New("exex: path outside of workspace")
+
+// Workspace is a Runner that confines the commands it runs to a root
+// directory: their working directory must be inside Root, and
+// relative working directories are resolved against it.
+type Workspace struct {
+	// Root is the root directory of the workspace.
+	Root string
+
+	// Runner executes the commands once confined. If nil,
+	// DefaultRunner is used.
+	Runner Runner
+
+	// CheckArg, if not nil, is called with every argument of the
+	// commands run in the workspace and returns the argument to use
+	// in its place. If it returns an error the command is refused.
+	// Workspace.Path can be used to validate and rewrite arguments
+	// that are paths.
+	CheckArg func(arg string) (string, error)
+}
+
+// Path returns the absolute path of p inside the workspace. Relative
+// paths are resolved against Root. It returns an error wrapping
+// ErrOutsideWorkspace if the resulting path, after evaluating any
+// symbolic links, is not inside Root. Paths that do not exist are
+// checked as if they were created, following the symbolic links of
+// their existing ancestors. Root must exist.
+func (ws *Workspace) Path(p string) (string, error) {
+	root, err := filepath.Abs(ws.Root)
+	if err != nil {
+		return "", err
+	}
+
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(root, p)
+	}
+	p = filepath.Clean(p)
+
+	if !within(root, p) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideWorkspace, p)
+	}
+
+	// Resolve symbolic links to prevent escaping the workspace
+	// through them.
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	real, err := realPath(p, 0)
+	if err != nil {
+		return "", err
+	}
+	if !within(realRoot, real) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideWorkspace, p)
+	}
+
+	return p, nil
+}
+
+// Start confines c to the workspace and starts it. It returns an
+// error without starting the command if its working directory is
+// outside the workspace or if CheckArg refuses any of its arguments.
+func (ws *Workspace) Start(c *Cmd) error {
+	if err := ws.confine(c); err != nil {
+		return err
+	}
+	return ws.runner().Start(c)
+}
+
+// Wait implements Runner.
+func (ws *Workspace) Wait(c *Cmd) error {
+	return ws.runner().Wait(c)
+}
+
+// Run sets the workspace as the Runner of c and returns the result of
+// running it.
+func (ws *Workspace) Run(c *Cmd) error {
+	return c.Apply(WithRunner(ws)).Run()
+}
+
+func (ws *Workspace) runner() Runner {
+	if ws.Runner != nil {
+		return ws.Runner
+	}
+	return DefaultRunner
+}
+
+func (ws *Workspace) confine(c *Cmd) error {
+	dir, err := ws.Path(c.Dir)
+	if err != nil {
+		return err
+	}
+	c.Dir = dir
+
+	if ws.CheckArg == nil || len(c.Args) < 2 {
+		return nil
+	}
+
+	args := make([]string, len(c.Args))
+	args[0] = c.Args[0]
+	for i, arg := range c.Args[1:] {
+		if args[i+1], err = ws.CheckArg(arg); err != nil {
+			return err
+		}
+	}
+	c.Args = args
+
+	return nil
+}
+
+// maxWorkspaceLinks is the maximum number of dangling symbolic links
+// followed by realPath.
+const maxWorkspaceLinks = 255
+
+// realPath returns the clean absolute path p after evaluating any
+// symbolic links. If p does not exist, the links of its nearest
+// existing ancestor are evaluated instead, including dangling links,
+// so that the result is where p would be created.
+func realPath(p string, links int) (string, error) {
+	var rest string
+	for {
+		real, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			return filepath.Join(real, rest), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		if fi, err := os.Lstat(p); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			if links >= maxWorkspaceLinks {
+				return "", fmt.Errorf("%s: too many levels of symbolic links", p)
+			}
+			target, err := os.Readlink(p)
+			if err != nil {
+				return "", err
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(p), target)
+			}
+			return realPath(filepath.Join(target, rest), links+1)
+		}
+
+		parent := filepath.Dir(p)
+		if parent == p {
+			return filepath.Join(p, rest), nil
+		}
+		rest = filepath.Join(filepath.Base(p), rest)
+		p = parent
+	}
+}
+
+// within reports whether path p is root or inside it. Both must be
+// clean absolute paths.
+func within(root, p string) bool {
+	rel, err := filepath.Rel(root, p)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
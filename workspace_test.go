@@ -0,0 +1,135 @@
+package exex_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/inkel/exex"
+)
+
+func TestWorkspace(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ws := &exex.Workspace{Root: root}
+
+	t.Run("relative dir", func(t *testing.T) {
+		var stdout bytes.Buffer
+		cmd := exex.Command(os.Args[0]).Apply(
+			exex.WithEnv("TEST_MAIN=pwd"),
+			exex.WithDir("sub"),
+			exex.WithStdout(&stdout),
+		)
+		if err := ws.Run(cmd); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if exp := filepath.Join(root, "sub"); stdout.String() != exp {
+			t.Fatalf("expecting %q, got %q", exp, stdout.String())
+		}
+	})
+
+	t.Run("default dir", func(t *testing.T) {
+		var stdout bytes.Buffer
+		cmd := exex.Command(os.Args[0]).Apply(exex.WithEnv("TEST_MAIN=pwd"), exex.WithStdout(&stdout))
+		if err := ws.Run(cmd); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if stdout.String() != root {
+			t.Fatalf("expecting %q, got %q", root, stdout.String())
+		}
+	})
+
+	t.Run("escape", func(t *testing.T) {
+		for _, dir := range []string{"..", "sub/../..", "/"} {
+			err := ws.Run(exex.Command(os.Args[0]).Apply(exex.WithDir(dir)))
+			if !errors.Is(err, exex.ErrOutsideWorkspace) {
+				t.Errorf("%q: expecting exex.ErrOutsideWorkspace, got %v", dir, err)
+			}
+		}
+	})
+
+	t.Run("symlink escape", func(t *testing.T) {
+		if err := os.Symlink(os.TempDir(), filepath.Join(root, "link")); err != nil {
+			t.Skip(err)
+		}
+		err := ws.Run(exex.Command(os.Args[0]).Apply(exex.WithDir("link")))
+		if !errors.Is(err, exex.ErrOutsideWorkspace) {
+			t.Errorf("expecting exex.ErrOutsideWorkspace, got %v", err)
+		}
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		if got, err := ws.Path("sub/new/file"); err != nil || got != filepath.Join(root, "sub", "new", "file") {
+			t.Errorf("unexpected path %q, %v", got, err)
+		}
+
+		if err := os.Symlink(os.TempDir(), filepath.Join(root, "escape")); err != nil {
+			t.Skip(err)
+		}
+		outside := filepath.Join(t.TempDir(), "missing")
+		if err := os.Symlink(outside, filepath.Join(root, "dangling")); err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range []string{"escape/newdir", "escape/new/file", "dangling", "dangling/file"} {
+			if _, err := ws.Path(p); !errors.Is(err, exex.ErrOutsideWorkspace) {
+				t.Errorf("%q: expecting exex.ErrOutsideWorkspace, got %v", p, err)
+			}
+		}
+
+		missing := &exex.Workspace{Root: filepath.Join(root, "missing")}
+		if _, err := missing.Path("file"); err == nil {
+			t.Error("expecting error for a missing root")
+		}
+	})
+
+	t.Run("check args", func(t *testing.T) {
+		strict := &exex.Workspace{
+			Root: root,
+			CheckArg: func(arg string) (string, error) {
+				if strings.HasPrefix(arg, "-") {
+					return arg, nil
+				}
+				return ws.Path(arg)
+			},
+		}
+
+		err := strict.Run(exex.Command(os.Args[0], "-v", "file"))
+		assertErr(t, err, "error: -v "+filepath.Join(root, "file"))
+
+		err = strict.Run(exex.Command(os.Args[0], "-v", "../file"))
+		if !errors.Is(err, exex.ErrOutsideWorkspace) {
+			t.Errorf("expecting exex.ErrOutsideWorkspace, got %v", err)
+		}
+	})
+}
+
+func TestWorkspaceRunner(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &recordingRunner{}
+	ws := &exex.Workspace{Root: root, Runner: r}
+
+	err = exex.Command(os.Args[0], "inside").Apply(exex.WithRunner(ws)).Run()
+	assertErr(t, err, "error: inside")
+
+	err = exex.Command(os.Args[0], "outside").Apply(exex.WithRunner(ws), exex.WithDir("..")).Run()
+	if !errors.Is(err, exex.ErrOutsideWorkspace) {
+		t.Fatalf("expecting exex.ErrOutsideWorkspace, got %v", err)
+	}
+
+	if len(r.started) != 1 || r.started[0] != "inside" {
+		t.Fatalf("expecting only the command inside the workspace to run, got %q", r.started)
+	}
+}